@@ -0,0 +1,310 @@
+// File: anomaly.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// MaxNotifiedAnomalies is the number of new anomalies listed in a Slack summary; the rest are counted.
+const MaxNotifiedAnomalies = 5
+
+// AnomalyRootCause is one combination of service, account, region and usage type Cost Anomaly Detection
+// found responsible for an anomaly.
+type AnomalyRootCause struct {
+	Service     string
+	Account     string
+	AccountName string
+	Region      string
+	UsageType   string
+}
+
+// String returns the set parts of the root cause, e.g. "Amazon EC2, 111111111111 (prod), us-east-1".
+func (c AnomalyRootCause) String() string {
+	account := c.Account
+	if c.AccountName != "" {
+		account += " (" + c.AccountName + ")"
+	}
+	var parts []string
+	for _, part := range []string{c.Service, account, c.Region, c.UsageType} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CostAnomaly is a finding of AWS Cost Anomaly Detection.
+type CostAnomaly struct {
+	ID         string
+	Monitor    string // ARN of the monitor that detected it
+	Start      string // YYYY-MM-DD
+	End        string // YYYY-MM-DD, empty while the anomaly is ongoing
+	Dimension  string // Value of the monitor's dimension, e.g. the service of a service monitor
+	Impact     float64
+	Actual     float64
+	Expected   float64
+	Score      float64 // Highest anomaly score while it lasted
+	Feedback   string
+	RootCauses []AnomalyRootCause
+}
+
+// ImpactPercent returns the impact as a percentage of the expected spend, or 0 if none was expected.
+func (a CostAnomaly) ImpactPercent() float64 {
+	if a.Expected == 0 {
+		return 0
+	}
+	return a.Impact / a.Expected * 100
+}
+
+// anomalyDate returns the date part of a date or timestamp returned by GetAnomalies.
+func anomalyDate(value *string) string {
+	s := aws.ToString(value)
+	if len(s) > len(AWSDateFormat) {
+		return s[:len(AWSDateFormat)]
+	}
+	return s
+}
+
+// anomalyInterval converts GetAnomalies' date interval, whose end is inclusive and optional, to a
+// DateInterval for logging.
+func anomalyInterval(interval *types.AnomalyDateInterval) *types.DateInterval {
+	if interval == nil {
+		return nil
+	}
+	return &types.DateInterval{Start: interval.StartDate, End: interval.EndDate}
+}
+
+// GetAnomalies lists the anomalies Cost Anomaly Detection found in the query range, largest impact first.
+// Anomalies are matched by the last day they were observed, as GetAnomalies does. monitor limits them
+// to one monitor's ARN and minImpact to a total impact of at least that much; either may be zero. The
+// Filter, GroupBy, Granularity and Metrics of q are ignored, since GetAnomalies takes none of them.
+func (ct *CostTracker) GetAnomalies(ctx context.Context, q CostQuery, monitor string, minImpact float64) ([]CostAnomaly, error) {
+	input := &costexplorer.GetAnomaliesInput{
+		// The end date is inclusive, unlike GetCostAndUsage's.
+		DateInterval: &types.AnomalyDateInterval{
+			StartDate: aws.String(q.Start.Format(AWSDateFormat)),
+			EndDate:   aws.String(q.End.AddDate(0, 0, -1).Format(AWSDateFormat)),
+		},
+	}
+	if monitor != "" {
+		input.MonitorArn = aws.String(monitor)
+	}
+	if minImpact > 0 {
+		input.TotalImpact = &types.TotalImpactFilter{
+			NumericOperator: types.NumericOperatorGreaterThanOrEqual,
+			StartValue:      minImpact,
+		}
+	}
+
+	var anomalies []CostAnomaly
+	for {
+		result, err := ct.client.GetAnomalies(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get anomalies from AWS Cost Anomaly Detection: %w", err)
+		}
+		for _, a := range result.Anomalies {
+			anomaly := CostAnomaly{
+				ID:        aws.ToString(a.AnomalyId),
+				Monitor:   aws.ToString(a.MonitorArn),
+				Start:     anomalyDate(a.AnomalyStartDate),
+				End:       anomalyDate(a.AnomalyEndDate),
+				Dimension: aws.ToString(a.DimensionValue),
+				Feedback:  string(a.Feedback),
+			}
+			if a.Impact != nil {
+				anomaly.Impact = a.Impact.TotalImpact
+				anomaly.Actual = aws.ToFloat64(a.Impact.TotalActualSpend)
+				anomaly.Expected = aws.ToFloat64(a.Impact.TotalExpectedSpend)
+			}
+			if a.AnomalyScore != nil {
+				anomaly.Score = a.AnomalyScore.MaxScore
+			}
+			for _, cause := range a.RootCauses {
+				anomaly.RootCauses = append(anomaly.RootCauses, AnomalyRootCause{
+					Service:     aws.ToString(cause.Service),
+					Account:     aws.ToString(cause.LinkedAccount),
+					AccountName: aws.ToString(cause.LinkedAccountName),
+					Region:      aws.ToString(cause.Region),
+					UsageType:   aws.ToString(cause.UsageType),
+				})
+			}
+			anomalies = append(anomalies, anomaly)
+		}
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			break
+		}
+		input.NextPageToken = result.NextPageToken
+	}
+
+	sort.SliceStable(anomalies, func(i, j int) bool { return anomalies[i].Impact > anomalies[j].Impact })
+	return anomalies, nil
+}
+
+// displayAnomalies writes each anomaly with its impact and root causes.
+func displayAnomalies(w io.Writer, anomalies []CostAnomaly, days int) {
+	fmt.Fprintf(w, "Cost anomalies for the last %d days:\n", days)
+	fmt.Fprintln(w, "=====================================")
+	if len(anomalies) == 0 {
+		fmt.Fprintln(w, "No anomalies found for the specified period.")
+		return
+	}
+	fmt.Fprintf(w, "%-10s %-10s %12s %12s %8s  %s\n", "Start", "End", "Impact", "Expected", "Change", "Root cause")
+	var total float64
+	for _, a := range anomalies {
+		end := a.End
+		if end == "" {
+			end = "ongoing"
+		}
+		cause := a.Dimension
+		if len(a.RootCauses) > 0 {
+			cause = a.RootCauses[0].String()
+		}
+		fmt.Fprintf(w, "%-10s %-10s %12.2f %12.2f %7.1f%%  %s\n", a.Start, end, a.Impact, a.Expected, a.ImpactPercent(), cause)
+		for _, c := range a.RootCauses[min(1, len(a.RootCauses)):] {
+			fmt.Fprintf(w, "%-10s %-10s %12s %12s %8s  %s\n", "", "", "", "", "", c)
+		}
+		total += a.Impact
+	}
+	fmt.Fprintf(w, "%-21s %12.2f\n", "Total impact", total)
+}
+
+// readSeenAnomalies reads the anomaly IDs in path, one per line. A missing file has none.
+func readSeenAnomalies(path string) (map[string]bool, error) {
+	seen := make(map[string]bool)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return seen, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seen anomalies: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if id := strings.TrimSpace(line); id != "" {
+			seen[id] = true
+		}
+	}
+	return seen, nil
+}
+
+// writeSeenAnomalies replaces path with the IDs of anomalies. Anomalies that have left the query range
+// are dropped, so the file does not grow with every run.
+func writeSeenAnomalies(path string, anomalies []CostAnomaly) error {
+	var b strings.Builder
+	for _, a := range anomalies {
+		b.WriteString(a.ID + "\n")
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write seen anomalies: %w", err)
+	}
+	return nil
+}
+
+// newAnomalies returns the anomalies whose IDs are not in seen, keeping their order.
+func newAnomalies(anomalies []CostAnomaly, seen map[string]bool) []CostAnomaly {
+	var fresh []CostAnomaly
+	for _, a := range anomalies {
+		if !seen[a.ID] {
+			fresh = append(fresh, a)
+		}
+	}
+	return fresh
+}
+
+// anomalySummary returns the Slack summary of new anomalies, listing the MaxNotifiedAnomalies largest.
+func anomalySummary(anomalies []CostAnomaly) string {
+	var total float64
+	for _, a := range anomalies {
+		total += a.Impact
+	}
+	lines := []string{fmt.Sprintf("%d new cost anomalies with a total impact of %.2f:", len(anomalies), total)}
+	for i, a := range anomalies {
+		if i == MaxNotifiedAnomalies {
+			lines = append(lines, fmt.Sprintf("...and %d more.", len(anomalies)-i))
+			break
+		}
+		cause := a.Dimension
+		if len(a.RootCauses) > 0 {
+			cause = a.RootCauses[0].String()
+		}
+		lines = append(lines, fmt.Sprintf("• %+.2f (%+.0f%%) since %s: %s", a.Impact, a.ImpactPercent(), a.Start, cause))
+	}
+	return strings.Join(lines, "\n")
+}
+
+var anomaliesCmd = &cobra.Command{
+	Use:   "anomalies",
+	Short: "List AWS Cost Anomaly Detection findings with their impact and root causes.",
+	Long: `Lists the anomalies AWS Cost Anomaly Detection found in the last --days or --period, largest impact first, with
+the spend it expected, the impact and the service, account, region and usage type of each root cause. It needs at
+least one anomaly monitor; --filter does not apply, use --monitor and --min-impact instead. --notify sends the
+anomalies not listed in anomalies.seen_file to Slack and records them there, for a daily run:
+
+  cost-tracker anomalies --days 7 --min-impact 50 --notify`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		monitor, _ := cmd.Flags().GetString("monitor")
+		minImpact := viper.GetFloat64("anomalies.min_impact")
+		if cmd.Flags().Changed("min-impact") {
+			minImpact, _ = cmd.Flags().GetFloat64("min-impact")
+		}
+
+		tracker, query, days := setupReport(ctx)
+		anomalies, err := tracker.GetAnomalies(ctx, query, monitor, minImpact)
+		if err != nil {
+			errMsg := fmt.Sprintf("Error getting cost anomalies: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error getting cost anomalies", "error", err)
+		}
+
+		logger.Info("Displaying cost anomalies to console.")
+		out, done := consoleWriter()
+		displayAnomalies(out, anomalies, days)
+		done()
+
+		if notify, _ := cmd.Flags().GetBool("notify"); !notify || explaining() {
+			return
+		}
+		seen := make(map[string]bool)
+		seenFile := viper.GetString("anomalies.seen_file")
+		if seenFile != "" {
+			if seen, err = readSeenAnomalies(seenFile); err != nil {
+				logger.Fatalw("Error reading seen anomalies", "path", seenFile, "error", err)
+			}
+		}
+		if fresh := newAnomalies(anomalies, seen); len(fresh) > 0 {
+			sendSlackNotification(anomalySummary(fresh))
+		} else {
+			logger.Info("No new anomalies. Skipping Slack notification.")
+		}
+		if seenFile != "" {
+			if err := writeSeenAnomalies(seenFile, anomalies); err != nil {
+				logger.Fatalw("Error recording seen anomalies", "path", seenFile, "error", err)
+			}
+		}
+	},
+}
+
+func init() {
+	viper.SetDefault("anomalies.min_impact", 0) // Smallest total impact listed
+	viper.SetDefault("anomalies.seen_file", "") // Anomalies already sent by --notify; without it every anomaly is new
+
+	anomaliesCmd.Flags().String("monitor", "", "ARN of the anomaly monitor to list (default: all monitors)")
+	anomaliesCmd.Flags().Float64("min-impact", 0, "Smallest total impact to list; overrides anomalies.min_impact")
+	anomaliesCmd.Flags().Bool("notify", false, "Send anomalies not yet in anomalies.seen_file to Slack")
+	rootCmd.AddCommand(anomaliesCmd)
+}
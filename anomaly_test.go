@@ -0,0 +1,128 @@
+// File: anomaly_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGetAnomalies(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	anomaly := func(id, start string, impact, expected float64, causes ...types.RootCause) types.Anomaly {
+		return types.Anomaly{
+			AnomalyId:        aws.String(id),
+			AnomalyStartDate: aws.String(start),
+			Impact:           &types.Impact{TotalImpact: impact, TotalExpectedSpend: aws.Float64(expected)},
+			RootCauses:       causes,
+		}
+	}
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetAnomaliesFunc: func(ctx context.Context, params *costexplorer.GetAnomaliesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomaliesOutput, error) {
+			if aws.ToString(params.DateInterval.EndDate) != "2024-06-30" || params.TotalImpact.StartValue != 10 {
+				t.Errorf("expected an inclusive end date and a minimum impact, got %s and %+v", aws.ToString(params.DateInterval.EndDate), params.TotalImpact)
+			}
+			if params.NextPageToken == nil {
+				return &costexplorer.GetAnomaliesOutput{
+					Anomalies:     []types.Anomaly{anomaly("small", "2024-06-02T00:00:00Z", 12, 40)},
+					NextPageToken: aws.String("next"),
+				}, nil
+			}
+			return &costexplorer.GetAnomaliesOutput{Anomalies: []types.Anomaly{
+				anomaly("large", "2024-06-10", 150, 100,
+					types.RootCause{Service: aws.String("Amazon S3"), LinkedAccount: aws.String("111111111111"), LinkedAccountName: aws.String("prod"), Region: aws.String("us-east-1")},
+					types.RootCause{Service: aws.String("Amazon S3"), Region: aws.String("eu-west-1")}),
+			}}, nil
+		},
+	}}
+	q := CostQuery{
+		Start: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	anomalies, err := tracker.GetAnomalies(context.Background(), q, "", 10)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(anomalies) != 2 || anomalies[0].ID != "large" || anomalies[1].Start != "2024-06-02" {
+		t.Fatalf("expected both pages, largest impact first, got %+v", anomalies)
+	}
+	if anomalies[0].ImpactPercent() != 150 || anomalies[0].RootCauses[0].String() != "Amazon S3, 111111111111 (prod), us-east-1" {
+		t.Errorf("unexpected impact or root cause: %+v", anomalies[0])
+	}
+
+	var buf bytes.Buffer
+	displayAnomalies(&buf, anomalies, 30)
+	for _, want := range []string{"ongoing", "Amazon S3, eu-west-1", "Total impact                162.00"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in the output, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestNewAnomaliesAreNotifiedOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen")
+	anomalies := []CostAnomaly{{ID: "a", Impact: 20, Expected: 10, Start: "2024-06-01"}}
+
+	seen, err := readSeenAnomalies(path)
+	if err != nil {
+		t.Fatalf("did not expect an error for a missing file, but got: %v", err)
+	}
+	if fresh := newAnomalies(anomalies, seen); len(fresh) != 1 {
+		t.Fatalf("expected the anomaly to be new, got %+v", fresh)
+	}
+	if err := writeSeenAnomalies(path, anomalies); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+
+	anomalies = append(anomalies, CostAnomaly{ID: "b", Impact: 5, Start: "2024-06-03"})
+	if seen, err = readSeenAnomalies(path); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	fresh := newAnomalies(anomalies, seen)
+	if len(fresh) != 1 || fresh[0].ID != "b" {
+		t.Fatalf("expected only b to be new, got %+v", fresh)
+	}
+	if summary := anomalySummary(fresh); !strings.Contains(summary, "1 new cost anomalies with a total impact of 5.00") {
+		t.Errorf("unexpected summary: %s", summary)
+	}
+}
+
+func TestMockProviderGetAnomalies(t *testing.T) {
+	p := &MockProvider{
+		Anchor:    time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		Services:  map[string]float64{"Amazon S3": 10},
+		Regions:   []string{"us-east-1"},
+		Accounts:  []string{"111111111111"},
+		Anomalies: []MockAnomaly{{Service: "Amazon S3", Start: "2024-06-10", End: "2024-06-11", Factor: 3}},
+	}
+	tracker := &CostTracker{client: p}
+	q := CostQuery{
+		Start: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	anomalies, err := tracker.GetAnomalies(context.Background(), q, "", 0)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(anomalies) != 1 || anomalies[0].Expected != 20 || anomalies[0].Impact != 40 {
+		t.Fatalf("expected the injected anomaly to cost 40 over 20 expected, got %+v", anomalies)
+	}
+	if anomalies[0].RootCauses[0].String() != "Amazon S3, 111111111111, us-east-1" {
+		t.Errorf("unexpected root cause: %+v", anomalies[0].RootCauses)
+	}
+
+	q.End = time.Date(2024, 6, 11, 0, 0, 0, 0, time.UTC)
+	if anomalies, _ := tracker.GetAnomalies(context.Background(), q, "", 0); len(anomalies) != 0 {
+		t.Errorf("expected no anomaly before its last day, got %+v", anomalies)
+	}
+}
@@ -0,0 +1,453 @@
+// File: anomalymonitor.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// AnomalyMonitorAPI is the part of the Cost Explorer API that manages Cost Anomaly Detection monitors and
+// their subscriptions. Unlike CostExplorerAPI it changes the account, so it has no mock or explain client.
+type AnomalyMonitorAPI interface {
+	GetAnomalyMonitors(ctx context.Context, params *costexplorer.GetAnomalyMonitorsInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomalyMonitorsOutput, error)
+	CreateAnomalyMonitor(ctx context.Context, params *costexplorer.CreateAnomalyMonitorInput, optFns ...func(*costexplorer.Options)) (*costexplorer.CreateAnomalyMonitorOutput, error)
+	DeleteAnomalyMonitor(ctx context.Context, params *costexplorer.DeleteAnomalyMonitorInput, optFns ...func(*costexplorer.Options)) (*costexplorer.DeleteAnomalyMonitorOutput, error)
+	GetAnomalySubscriptions(ctx context.Context, params *costexplorer.GetAnomalySubscriptionsInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomalySubscriptionsOutput, error)
+	CreateAnomalySubscription(ctx context.Context, params *costexplorer.CreateAnomalySubscriptionInput, optFns ...func(*costexplorer.Options)) (*costexplorer.CreateAnomalySubscriptionOutput, error)
+	DeleteAnomalySubscription(ctx context.Context, params *costexplorer.DeleteAnomalySubscriptionInput, optFns ...func(*costexplorer.Options)) (*costexplorer.DeleteAnomalySubscriptionOutput, error)
+}
+
+// AnomalySubscriptionConfig is the alert subscription of a configured monitor.
+type AnomalySubscriptionConfig struct {
+	Frequency string   `mapstructure:"frequency"` // DAILY (the default), WEEKLY or IMMEDIATE
+	Threshold float64  `mapstructure:"threshold"` // Smallest total impact alerted on
+	Emails    []string `mapstructure:"emails"`
+	SNSTopic  string   `mapstructure:"sns_topic_arn"` // Required for IMMEDIATE, which cannot email
+}
+
+// AnomalyMonitorConfig is an anomaly monitor in the anomalies.monitors configuration key. A monitor
+// either watches each service separately (dimension "service") or the spend matching a filter.
+type AnomalyMonitorConfig struct {
+	Name         string                     `mapstructure:"name"`
+	Dimension    string                     `mapstructure:"dimension"`
+	Filter       string                     `mapstructure:"filter"` // --filter syntax
+	Subscription *AnomalySubscriptionConfig `mapstructure:"subscription"`
+}
+
+// AnomalyMonitorsFromViper reads the anomalies.monitors configuration key and checks each monitor.
+func AnomalyMonitorsFromViper() ([]AnomalyMonitorConfig, error) {
+	var monitors []AnomalyMonitorConfig
+	if err := unmarshalConfigKey("anomalies.monitors", &monitors); err != nil {
+		return nil, fmt.Errorf("invalid anomalies.monitors: %w", err)
+	}
+	seen := make(map[string]bool)
+	for i, m := range monitors {
+		if m.Name == "" {
+			return nil, fmt.Errorf("anomalies.monitors[%d] has no name", i)
+		}
+		if seen[m.Name] {
+			return nil, fmt.Errorf("anomaly monitor %q is configured more than once", m.Name)
+		}
+		seen[m.Name] = true
+		if _, err := m.monitor(); err != nil {
+			return nil, fmt.Errorf("anomaly monitor %q: %w", m.Name, err)
+		}
+		if m.Subscription != nil {
+			if _, err := m.Subscription.subscription(m.Name, ""); err != nil {
+				return nil, fmt.Errorf("anomaly monitor %q: %w", m.Name, err)
+			}
+		}
+	}
+	return monitors, nil
+}
+
+// monitor returns the Cost Anomaly Detection monitor to create for c.
+func (c AnomalyMonitorConfig) monitor() (*types.AnomalyMonitor, error) {
+	switch {
+	case c.Dimension != "" && c.Filter != "":
+		return nil, fmt.Errorf("set either dimension or filter, not both")
+	case c.Dimension != "":
+		if !strings.EqualFold(c.Dimension, string(types.MonitorDimensionService)) {
+			return nil, fmt.Errorf("dimension must be service, got %q", c.Dimension)
+		}
+		return &types.AnomalyMonitor{
+			MonitorName:      aws.String(c.Name),
+			MonitorType:      types.MonitorTypeDimensional,
+			MonitorDimension: types.MonitorDimensionService,
+		}, nil
+	case c.Filter != "":
+		expr, err := ParseFilter(c.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+		return &types.AnomalyMonitor{
+			MonitorName:          aws.String(c.Name),
+			MonitorType:          types.MonitorTypeCustom,
+			MonitorSpecification: expr,
+		}, nil
+	}
+	return nil, fmt.Errorf("set dimension to service or a filter")
+}
+
+// subscription returns the subscription to create for the monitor named name with ARN monitorARN. It
+// has the monitor's name and alerts on anomalies with a total impact of at least the threshold.
+func (c AnomalySubscriptionConfig) subscription(name, monitorARN string) (*types.AnomalySubscription, error) {
+	frequency := types.AnomalySubscriptionFrequencyDaily
+	if c.Frequency != "" {
+		frequency = types.AnomalySubscriptionFrequency(strings.ToUpper(c.Frequency))
+		if !slices.Contains(frequency.Values(), frequency) {
+			return nil, fmt.Errorf("subscription frequency must be DAILY, WEEKLY or IMMEDIATE, got %q", c.Frequency)
+		}
+	}
+	if c.Threshold < 0 {
+		return nil, fmt.Errorf("subscription threshold must not be negative, got %g", c.Threshold)
+	}
+	var subscribers []types.Subscriber
+	for _, email := range c.Emails {
+		subscribers = append(subscribers, types.Subscriber{Type: types.SubscriberTypeEmail, Address: aws.String(email)})
+	}
+	if c.SNSTopic != "" {
+		subscribers = append(subscribers, types.Subscriber{Type: types.SubscriberTypeSns, Address: aws.String(c.SNSTopic)})
+	}
+	switch {
+	case len(subscribers) == 0:
+		return nil, fmt.Errorf("subscription needs emails or an sns_topic_arn")
+	case frequency == types.AnomalySubscriptionFrequencyImmediate && len(c.Emails) > 0:
+		return nil, fmt.Errorf("IMMEDIATE subscriptions can only alert an sns_topic_arn, not emails")
+	}
+
+	subscription := &types.AnomalySubscription{
+		SubscriptionName: aws.String(name),
+		Frequency:        frequency,
+		Subscribers:      subscribers,
+		ThresholdExpression: &types.Expression{Dimensions: &types.DimensionValues{
+			Key:          types.DimensionAnomalyTotalImpactAbsolute,
+			MatchOptions: []types.MatchOption{types.MatchOptionGreaterThanOrEqual},
+			Values:       []string{strconv.FormatFloat(c.Threshold, 'f', -1, 64)},
+		}},
+	}
+	if monitorARN != "" {
+		subscription.MonitorArnList = []string{monitorARN}
+	}
+	return subscription, nil
+}
+
+// listAnomalyMonitors returns every anomaly monitor of the account.
+func listAnomalyMonitors(ctx context.Context, api AnomalyMonitorAPI) ([]types.AnomalyMonitor, error) {
+	var monitors []types.AnomalyMonitor
+	input := &costexplorer.GetAnomalyMonitorsInput{}
+	for {
+		result, err := api.GetAnomalyMonitors(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list anomaly monitors: %w", err)
+		}
+		monitors = append(monitors, result.AnomalyMonitors...)
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			return monitors, nil
+		}
+		input.NextPageToken = result.NextPageToken
+	}
+}
+
+// listAnomalySubscriptions returns every anomaly subscription of the account.
+func listAnomalySubscriptions(ctx context.Context, api AnomalyMonitorAPI) ([]types.AnomalySubscription, error) {
+	var subscriptions []types.AnomalySubscription
+	input := &costexplorer.GetAnomalySubscriptionsInput{}
+	for {
+		result, err := api.GetAnomalySubscriptions(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list anomaly subscriptions: %w", err)
+		}
+		subscriptions = append(subscriptions, result.AnomalySubscriptions...)
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			return subscriptions, nil
+		}
+		input.NextPageToken = result.NextPageToken
+	}
+}
+
+// createAnomalyMonitors creates each configured monitor that does not exist yet, by name, and the
+// subscription of each that has one configured but not created, writing what it does to w. With dryRun
+// it only writes what it would do. Existing monitors are not updated to match their configuration.
+func createAnomalyMonitors(ctx context.Context, api AnomalyMonitorAPI, w io.Writer, configs []AnomalyMonitorConfig, dryRun bool) error {
+	monitors, err := listAnomalyMonitors(ctx, api)
+	if err != nil {
+		return err
+	}
+	subscriptions, err := listAnomalySubscriptions(ctx, api)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]string) // Monitor name -> ARN
+	for _, m := range monitors {
+		existing[aws.ToString(m.MonitorName)] = aws.ToString(m.MonitorArn)
+	}
+	subscribed := make(map[string]bool) // Subscription names
+	for _, s := range subscriptions {
+		subscribed[aws.ToString(s.SubscriptionName)] = true
+	}
+
+	verb := "Created"
+	if dryRun {
+		verb = "Would create"
+	}
+	for _, c := range configs {
+		arn, ok := existing[c.Name]
+		if ok {
+			fmt.Fprintf(w, "Monitor %s already exists: %s\n", c.Name, arn)
+		} else {
+			monitor, err := c.monitor()
+			if err != nil {
+				return fmt.Errorf("anomaly monitor %q: %w", c.Name, err)
+			}
+			if !dryRun {
+				result, err := api.CreateAnomalyMonitor(ctx, &costexplorer.CreateAnomalyMonitorInput{AnomalyMonitor: monitor})
+				if err != nil {
+					return fmt.Errorf("failed to create anomaly monitor %q: %w", c.Name, err)
+				}
+				arn = aws.ToString(result.MonitorArn)
+			}
+			fmt.Fprintf(w, "%s monitor %s %s\n", verb, c.Name, arn)
+		}
+
+		if c.Subscription == nil || subscribed[c.Name] {
+			continue
+		}
+		subscription, err := c.Subscription.subscription(c.Name, arn)
+		if err != nil {
+			return fmt.Errorf("anomaly monitor %q: %w", c.Name, err)
+		}
+		if !dryRun {
+			if _, err := api.CreateAnomalySubscription(ctx, &costexplorer.CreateAnomalySubscriptionInput{AnomalySubscription: subscription}); err != nil {
+				return fmt.Errorf("failed to create anomaly subscription %q: %w", c.Name, err)
+			}
+		}
+		fmt.Fprintf(w, "%s %s subscription %s alerting %d subscriber(s) on anomalies of at least %g\n",
+			verb, strings.ToLower(string(subscription.Frequency)), c.Name, len(subscription.Subscribers), c.Subscription.Threshold)
+	}
+	return nil
+}
+
+// deleteAnomalyMonitors deletes the monitors named, by name or ARN, writing what it does to w. A
+// subscription covering only a deleted monitor is deleted with it; one that also covers other monitors
+// is left alone. With dryRun it only writes what it would do.
+func deleteAnomalyMonitors(ctx context.Context, api AnomalyMonitorAPI, w io.Writer, targets []string, dryRun bool) error {
+	monitors, err := listAnomalyMonitors(ctx, api)
+	if err != nil {
+		return err
+	}
+	subscriptions, err := listAnomalySubscriptions(ctx, api)
+	if err != nil {
+		return err
+	}
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	for _, target := range targets {
+		i := slices.IndexFunc(monitors, func(m types.AnomalyMonitor) bool {
+			return aws.ToString(m.MonitorName) == target || aws.ToString(m.MonitorArn) == target
+		})
+		if i < 0 {
+			return fmt.Errorf("no anomaly monitor named %q", target)
+		}
+		name, arn := aws.ToString(monitors[i].MonitorName), aws.ToString(monitors[i].MonitorArn)
+
+		for _, s := range subscriptions {
+			if !slices.Contains(s.MonitorArnList, arn) {
+				continue
+			}
+			if len(s.MonitorArnList) > 1 {
+				logger.Warnw("Keeping anomaly subscription that also covers other monitors", "subscription", aws.ToString(s.SubscriptionName), "monitor", name)
+				continue
+			}
+			if !dryRun {
+				if _, err := api.DeleteAnomalySubscription(ctx, &costexplorer.DeleteAnomalySubscriptionInput{SubscriptionArn: s.SubscriptionArn}); err != nil {
+					return fmt.Errorf("failed to delete anomaly subscription %q: %w", aws.ToString(s.SubscriptionName), err)
+				}
+			}
+			fmt.Fprintf(w, "%s subscription %s\n", verb, aws.ToString(s.SubscriptionName))
+		}
+		if !dryRun {
+			if _, err := api.DeleteAnomalyMonitor(ctx, &costexplorer.DeleteAnomalyMonitorInput{MonitorArn: aws.String(arn)}); err != nil {
+				return fmt.Errorf("failed to delete anomaly monitor %q: %w", name, err)
+			}
+		}
+		fmt.Fprintf(w, "%s monitor %s %s\n", verb, name, arn)
+	}
+	return nil
+}
+
+// displayAnomalyMonitors writes each monitor with what it watches, its subscriptions and whether it is
+// in the anomalies.monitors configuration, followed by configured monitors that have not been created.
+func displayAnomalyMonitors(w io.Writer, monitors []types.AnomalyMonitor, subscriptions []types.AnomalySubscription, configs []AnomalyMonitorConfig) {
+	fmt.Fprintln(w, "Anomaly monitors:")
+	fmt.Fprintln(w, "=====================================")
+	configured := make(map[string]AnomalyMonitorConfig, len(configs))
+	for _, c := range configs {
+		configured[c.Name] = c
+	}
+	if len(monitors) == 0 {
+		fmt.Fprintln(w, "No anomaly monitors found.")
+	} else {
+		fmt.Fprintf(w, "%-24s %-30s %-30s %-10s %s\n", "Name", "Watches", "Subscriptions", "Configured", "ARN")
+	}
+	created := make(map[string]bool, len(monitors))
+	for _, m := range monitors {
+		name := aws.ToString(m.MonitorName)
+		created[name] = true
+		watches := "each " + strings.ToLower(string(m.MonitorDimension))
+		if m.MonitorType == types.MonitorTypeCustom {
+			// Show the configured filter rather than the expression it was parsed into, when there is one.
+			watches = describeFilter(m.MonitorSpecification)
+			if c, ok := configured[name]; ok && c.Filter != "" {
+				watches = c.Filter
+			}
+		}
+		var subs []string
+		for _, s := range subscriptions {
+			if slices.Contains(s.MonitorArnList, aws.ToString(m.MonitorArn)) {
+				subs = append(subs, fmt.Sprintf("%s (%s)", aws.ToString(s.SubscriptionName), strings.ToLower(string(s.Frequency))))
+			}
+		}
+		isConfigured := "no"
+		if _, ok := configured[name]; ok {
+			isConfigured = "yes"
+		}
+		fmt.Fprintf(w, "%-24s %-30s %-30s %-10s %s\n", truncateName(name, 24), truncateName(watches, 30),
+			truncateName(strings.Join(subs, ", "), 30), isConfigured, aws.ToString(m.MonitorArn))
+	}
+	for _, c := range configs {
+		if !created[c.Name] {
+			fmt.Fprintf(w, "Configured but not created: %s (run anomalies monitor create)\n", c.Name)
+		}
+	}
+}
+
+// newAnomalyMonitorClient creates a Cost Explorer client for managing anomaly monitors, through
+// aws.role_arn if it is configured. The mock provider cannot manage monitors.
+func newAnomalyMonitorClient(ctx context.Context) (AnomalyMonitorAPI, error) {
+	if provider := viper.GetString("provider"); provider != ProviderAWS && provider != "" {
+		return nil, fmt.Errorf("anomaly monitors can only be managed in AWS, not with the %s provider", provider)
+	}
+	role, err := AssumeRoleConfigFromViper()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := loadAWSConfig(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	return costexplorer.NewFromConfig(cfg), nil
+}
+
+// setupAnomalyMonitors reads the configured monitors and creates a client for a monitor subcommand,
+// exiting on failure.
+func setupAnomalyMonitors(ctx context.Context) (AnomalyMonitorAPI, []AnomalyMonitorConfig) {
+	configs, err := AnomalyMonitorsFromViper()
+	if err != nil {
+		logger.Fatalw("Invalid anomaly monitors", "error", err)
+	}
+	api, err := newAnomalyMonitorClient(ctx)
+	if err != nil {
+		logger.Fatalw("Failed to create Cost Explorer client", "error", err)
+	}
+	return api, configs
+}
+
+var anomalyMonitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Create, list and delete Cost Anomaly Detection monitors and their subscriptions.",
+	Long: `Manages the Cost Anomaly Detection monitors the anomalies command reads, from the anomalies.monitors
+configuration key. Creating and deleting monitors needs write permissions the read-only reporting role does not
+have. With --explain, create and delete print what they would do without changing anything.`,
+}
+
+var anomalyMonitorListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List anomaly monitors with their subscriptions.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		api, configs := setupAnomalyMonitors(ctx)
+		monitors, err := listAnomalyMonitors(ctx, api)
+		if err == nil {
+			var subscriptions []types.AnomalySubscription
+			if subscriptions, err = listAnomalySubscriptions(ctx, api); err == nil {
+				out, done := consoleWriter()
+				displayAnomalyMonitors(out, monitors, subscriptions, configs)
+				done()
+			}
+		}
+		if err != nil {
+			logger.Fatalw("Error listing anomaly monitors", "error", err)
+		}
+	},
+}
+
+var anomalyMonitorCreateCmd = &cobra.Command{
+	Use:   "create [name...]",
+	Short: "Create the configured anomaly monitors and subscriptions that do not exist yet.",
+	Long: `Creates each monitor in anomalies.monitors, or only those named, that does not exist yet, and its subscription
+if one is configured. Monitors are matched by name, so running it again creates only what is missing; an existing
+monitor is not changed to match its configuration:
+
+  cost-tracker anomalies monitor create`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		api, configs := setupAnomalyMonitors(ctx)
+		if len(args) > 0 {
+			for _, name := range args {
+				if !slices.ContainsFunc(configs, func(c AnomalyMonitorConfig) bool { return c.Name == name }) {
+					logger.Fatalw("Anomaly monitor is not configured in anomalies.monitors", "name", name)
+				}
+			}
+			configs = slices.DeleteFunc(configs, func(c AnomalyMonitorConfig) bool { return !slices.Contains(args, c.Name) })
+		}
+		if len(configs) == 0 {
+			logger.Fatalw("No anomaly monitors configured in anomalies.monitors")
+		}
+		if err := createAnomalyMonitors(ctx, api, cmd.OutOrStdout(), configs, explaining()); err != nil {
+			logger.Fatalw("Error creating anomaly monitors", "error", err)
+		}
+	},
+}
+
+var anomalyMonitorDeleteCmd = &cobra.Command{
+	Use:   "delete name-or-arn...",
+	Short: "Delete anomaly monitors and the subscriptions that only cover them.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		api, err := newAnomalyMonitorClient(ctx)
+		if err != nil {
+			logger.Fatalw("Failed to create Cost Explorer client", "error", err)
+		}
+		if err := deleteAnomalyMonitors(ctx, api, cmd.OutOrStdout(), args, explaining()); err != nil {
+			logger.Fatalw("Error deleting anomaly monitors", "error", err)
+		}
+	},
+}
+
+func init() {
+	anomalyMonitorCmd.AddCommand(anomalyMonitorListCmd, anomalyMonitorCreateCmd, anomalyMonitorDeleteCmd)
+	anomaliesCmd.AddCommand(anomalyMonitorCmd)
+}
@@ -0,0 +1,173 @@
+// File: anomalymonitor_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zaptest"
+)
+
+// fakeAnomalyMonitorAPI keeps monitors and subscriptions in memory.
+type fakeAnomalyMonitorAPI struct {
+	monitors      []types.AnomalyMonitor
+	subscriptions []types.AnomalySubscription
+}
+
+func (f *fakeAnomalyMonitorAPI) GetAnomalyMonitors(ctx context.Context, params *costexplorer.GetAnomalyMonitorsInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomalyMonitorsOutput, error) {
+	return &costexplorer.GetAnomalyMonitorsOutput{AnomalyMonitors: f.monitors}, nil
+}
+
+func (f *fakeAnomalyMonitorAPI) CreateAnomalyMonitor(ctx context.Context, params *costexplorer.CreateAnomalyMonitorInput, optFns ...func(*costexplorer.Options)) (*costexplorer.CreateAnomalyMonitorOutput, error) {
+	monitor := *params.AnomalyMonitor
+	monitor.MonitorArn = aws.String(fmt.Sprintf("arn:aws:ce::111111111111:anomalymonitor/%d", len(f.monitors)))
+	f.monitors = append(f.monitors, monitor)
+	return &costexplorer.CreateAnomalyMonitorOutput{MonitorArn: monitor.MonitorArn}, nil
+}
+
+func (f *fakeAnomalyMonitorAPI) DeleteAnomalyMonitor(ctx context.Context, params *costexplorer.DeleteAnomalyMonitorInput, optFns ...func(*costexplorer.Options)) (*costexplorer.DeleteAnomalyMonitorOutput, error) {
+	for i, m := range f.monitors {
+		if aws.ToString(m.MonitorArn) == aws.ToString(params.MonitorArn) {
+			f.monitors = append(f.monitors[:i], f.monitors[i+1:]...)
+			return &costexplorer.DeleteAnomalyMonitorOutput{}, nil
+		}
+	}
+	return nil, fmt.Errorf("UnknownMonitorException")
+}
+
+func (f *fakeAnomalyMonitorAPI) GetAnomalySubscriptions(ctx context.Context, params *costexplorer.GetAnomalySubscriptionsInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomalySubscriptionsOutput, error) {
+	return &costexplorer.GetAnomalySubscriptionsOutput{AnomalySubscriptions: f.subscriptions}, nil
+}
+
+func (f *fakeAnomalyMonitorAPI) CreateAnomalySubscription(ctx context.Context, params *costexplorer.CreateAnomalySubscriptionInput, optFns ...func(*costexplorer.Options)) (*costexplorer.CreateAnomalySubscriptionOutput, error) {
+	subscription := *params.AnomalySubscription
+	subscription.SubscriptionArn = aws.String(fmt.Sprintf("arn:aws:ce::111111111111:anomalysubscription/%d", len(f.subscriptions)))
+	f.subscriptions = append(f.subscriptions, subscription)
+	return &costexplorer.CreateAnomalySubscriptionOutput{SubscriptionArn: subscription.SubscriptionArn}, nil
+}
+
+func (f *fakeAnomalyMonitorAPI) DeleteAnomalySubscription(ctx context.Context, params *costexplorer.DeleteAnomalySubscriptionInput, optFns ...func(*costexplorer.Options)) (*costexplorer.DeleteAnomalySubscriptionOutput, error) {
+	for i, s := range f.subscriptions {
+		if aws.ToString(s.SubscriptionArn) == aws.ToString(params.SubscriptionArn) {
+			f.subscriptions = append(f.subscriptions[:i], f.subscriptions[i+1:]...)
+			return &costexplorer.DeleteAnomalySubscriptionOutput{}, nil
+		}
+	}
+	return nil, fmt.Errorf("UnknownSubscriptionException")
+}
+
+func TestAnomalyMonitorsFromViper(t *testing.T) {
+	t.Cleanup(func() { viper.Set("anomalies.monitors", nil) })
+	viper.Set("anomalies.monitors", []map[string]interface{}{
+		{"name": "services", "dimension": "service"},
+		{"name": "data", "filter": "tag:team = data", "subscription": map[string]interface{}{"threshold": 50, "emails": []string{"finops@example.com"}}},
+	})
+	monitors, err := AnomalyMonitorsFromViper()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(monitors) != 2 || monitors[1].Subscription == nil || monitors[1].Subscription.Threshold != 50 {
+		t.Fatalf("unexpected monitors: %+v", monitors)
+	}
+
+	for _, tc := range []struct {
+		monitor map[string]interface{}
+		want    string
+	}{
+		{map[string]interface{}{"name": "m"}, "set dimension"},
+		{map[string]interface{}{"name": "m", "dimension": "region"}, "dimension must be service"},
+		{map[string]interface{}{"name": "m", "dimension": "service", "filter": "service = x"}, "not both"},
+		{map[string]interface{}{"name": "m", "dimension": "service", "subscription": map[string]interface{}{"frequency": "hourly", "emails": []string{"a@example.com"}}}, "frequency"},
+		{map[string]interface{}{"name": "m", "dimension": "service", "subscription": map[string]interface{}{"frequency": "immediate", "emails": []string{"a@example.com"}}}, "sns_topic_arn"},
+		{map[string]interface{}{"name": "m", "dimension": "service", "subscription": map[string]interface{}{}}, "needs emails"},
+	} {
+		viper.Set("anomalies.monitors", []map[string]interface{}{tc.monitor})
+		if _, err := AnomalyMonitorsFromViper(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: expected an error containing %q, got %v", tc.monitor, tc.want, err)
+		}
+	}
+}
+
+func TestCreateAnomalyMonitors(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	api := &fakeAnomalyMonitorAPI{monitors: []types.AnomalyMonitor{
+		{MonitorName: aws.String("services"), MonitorArn: aws.String("arn:existing"), MonitorType: types.MonitorTypeDimensional, MonitorDimension: types.MonitorDimensionService},
+	}}
+	configs := []AnomalyMonitorConfig{
+		{Name: "services", Dimension: "service", Subscription: &AnomalySubscriptionConfig{Frequency: "weekly", Emails: []string{"finops@example.com"}}},
+		{Name: "data", Filter: "tag:team = data", Subscription: &AnomalySubscriptionConfig{Frequency: "immediate", Threshold: 100, SNSTopic: "arn:aws:sns:us-east-1:111111111111:alerts"}},
+	}
+
+	var buf bytes.Buffer
+	if err := createAnomalyMonitors(context.Background(), api, &buf, configs, true); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(api.monitors) != 1 || len(api.subscriptions) != 0 || !strings.Contains(buf.String(), "Would create monitor data") {
+		t.Fatalf("expected a dry run to change nothing, got %d monitors and output:\n%s", len(api.monitors), buf.String())
+	}
+
+	buf.Reset()
+	if err := createAnomalyMonitors(context.Background(), api, &buf, configs, false); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(api.monitors) != 2 || api.monitors[1].MonitorType != types.MonitorTypeCustom || api.monitors[1].MonitorSpecification.Tags == nil {
+		t.Fatalf("expected a custom monitor for the filter, got %+v", api.monitors)
+	}
+	if len(api.subscriptions) != 2 || api.subscriptions[0].MonitorArnList[0] != "arn:existing" {
+		t.Fatalf("expected a subscription for the existing monitor too, got %+v", api.subscriptions)
+	}
+	if threshold := api.subscriptions[1].ThresholdExpression.Dimensions; threshold.Key != types.DimensionAnomalyTotalImpactAbsolute || threshold.Values[0] != "100" {
+		t.Errorf("unexpected threshold: %+v", threshold)
+	}
+
+	buf.Reset()
+	if err := createAnomalyMonitors(context.Background(), api, &buf, configs, false); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(api.monitors) != 2 || len(api.subscriptions) != 2 {
+		t.Errorf("expected a second run to create nothing, got %d monitors and %d subscriptions", len(api.monitors), len(api.subscriptions))
+	}
+
+	buf.Reset()
+	displayAnomalyMonitors(&buf, api.monitors, api.subscriptions, configs)
+	for _, want := range []string{"each service", "tag:team = data", "services (weekly)", "data (immediate)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in the listing, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestDeleteAnomalyMonitors(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	api := &fakeAnomalyMonitorAPI{
+		monitors: []types.AnomalyMonitor{
+			{MonitorName: aws.String("services"), MonitorArn: aws.String("arn:services")},
+			{MonitorName: aws.String("data"), MonitorArn: aws.String("arn:data")},
+		},
+		subscriptions: []types.AnomalySubscription{
+			{SubscriptionName: aws.String("services"), SubscriptionArn: aws.String("arn:sub-services"), MonitorArnList: []string{"arn:services"}},
+			{SubscriptionName: aws.String("shared"), SubscriptionArn: aws.String("arn:sub-shared"), MonitorArnList: []string{"arn:services", "arn:data"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := deleteAnomalyMonitors(context.Background(), api, &buf, []string{"services"}, false); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(api.monitors) != 1 || aws.ToString(api.monitors[0].MonitorName) != "data" {
+		t.Errorf("expected only data to remain, got %+v", api.monitors)
+	}
+	if len(api.subscriptions) != 1 || aws.ToString(api.subscriptions[0].SubscriptionName) != "shared" {
+		t.Errorf("expected the shared subscription to remain, got %+v", api.subscriptions)
+	}
+	if err := deleteAnomalyMonitors(context.Background(), api, &buf, []string{"services"}, false); err == nil {
+		t.Error("expected an error for an unknown monitor")
+	}
+}
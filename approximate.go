@@ -0,0 +1,160 @@
+// File: approximate.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+// UnattributedLabel names daily spend that the weekly breakdown does not account for, e.g. when the
+// breakdown has no positive costs to split it by.
+const UnattributedLabel = "(unattributed)"
+
+// dailyTotals returns the ungrouped cost of each day in q, keyed by period start, and its unit.
+func (ct *CostTracker) dailyTotals(ctx context.Context, q CostQuery) (map[string]float64, string, error) {
+	input := &costexplorer.GetCostAndUsageInput{
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(q.Start.Format(AWSDateFormat)),
+			End:   aws.String(q.End.Format(AWSDateFormat)),
+		},
+		Filter:      q.Filter,
+		Granularity: types.GranularityDaily,
+		Metrics:     []string{q.metric()},
+	}
+	totals := make(map[string]float64)
+	unit := ""
+	for {
+		result, err := ct.client.GetCostAndUsage(ctx, input)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get daily totals from AWS Cost Explorer: %w", err)
+		}
+		for _, resultByTime := range result.ResultsByTime {
+			metric, ok := resultByTime.Total[q.metric()]
+			if !ok || metric.Amount == nil {
+				continue
+			}
+			amount, err := strconv.ParseFloat(*metric.Amount, 64)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid daily total %q: %w", *metric.Amount, err)
+			}
+			totals[aws.ToString(resultByTime.TimePeriod.Start)] = amount
+			if metric.Unit != nil {
+				unit = *metric.Unit
+			}
+		}
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			break
+		}
+		input.NextPageToken = result.NextPageToken
+	}
+	return totals, unit, nil
+}
+
+// GetCostsApproximate is a cheaper GetCosts for daily and weekly granularity. Instead of a full daily
+// breakdown it fetches ungrouped daily totals plus one breakdown per ISO week, and splits each day's
+// total in the proportions of its week. Results are marked Approximate: the daily totals are exact,
+// but a service's cost on any one day is an estimate.
+func (ct *CostTracker) GetCostsApproximate(ctx context.Context, q CostQuery) ([]CostByTime, error) {
+	if q.Granularity != types.GranularityDaily && q.Granularity != GranularityWeekly {
+		return nil, fmt.Errorf("approximation needs daily or weekly granularity, got %q", q.Granularity)
+	}
+	start := time.Date(q.Start.Year(), q.Start.Month(), q.Start.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(q.End.Year(), q.End.Month(), q.End.Day(), 0, 0, 0, 0, time.UTC)
+	if !start.Before(end) {
+		return nil, fmt.Errorf("start date %s must be before end date %s", start.Format(AWSDateFormat), end.Format(AWSDateFormat))
+	}
+
+	totals, unit, err := ct.dailyTotals(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var daily []CostByTime
+	for weekStart := start; weekStart.Before(end); {
+		weekEnd := isoWeekStart(weekStart).AddDate(0, 0, 7)
+		if weekEnd.After(end) {
+			weekEnd = end
+		}
+		week := q
+		week.Start, week.End, week.Granularity = weekStart, weekEnd, types.GranularityMonthly
+		costs, err := ct.GetCosts(ctx, week)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the breakdown for the week of %s: %w", weekStart.Format(AWSDateFormat), err)
+		}
+		breakdown, _ := totalsByService(costs)
+		shares := serviceShares(breakdown)
+		groupKeys := make(map[string][]string) // Keys of groups with several group definitions
+		for _, period := range costs {
+			for _, group := range period.Groups {
+				if group.Keys != nil {
+					groupKeys[group.Key] = group.Keys
+				}
+			}
+		}
+
+		for day := weekStart; day.Before(weekEnd); day = day.AddDate(0, 0, 1) {
+			period := CostByTime{Start: day.Format(AWSDateFormat), End: day.AddDate(0, 0, 1).Format(AWSDateFormat), Approximate: true}
+			total := totals[period.Start]
+			if len(shares) == 0 && total != 0 {
+				shares = []serviceShare{{service: UnattributedLabel, share: 1}}
+			}
+			for _, s := range shares {
+				// Round away float noise so amounts print like Cost Explorer's own
+				amount := math.Round(total*s.share*1e8) / 1e8
+				period.Groups = append(period.Groups, GroupedCost{
+					Key:    s.service,
+					Keys:   groupKeys[s.service],
+					Amount: strconv.FormatFloat(amount, 'f', -1, 64),
+					Unit:   unit,
+				})
+			}
+			daily = append(daily, period)
+		}
+		weekStart = weekEnd
+	}
+
+	if q.Granularity != GranularityWeekly {
+		return daily, nil
+	}
+	weeks, err := aggregateWeeks(daily)
+	if err != nil {
+		return nil, err
+	}
+	for i := range weeks {
+		weeks[i].Approximate = true
+	}
+	return weeks, nil
+}
+
+// serviceShare is one service's fraction of a breakdown's total.
+type serviceShare struct {
+	service string
+	share   float64
+}
+
+// serviceShares returns each service's share of the positive costs in totals, ordered by name as
+// Cost Explorer orders groups. Services with zero or negative cost, such as credits, are left out.
+func serviceShares(totals map[string]float64) []serviceShare {
+	sum := 0.0
+	for _, amount := range totals {
+		if amount > 0 {
+			sum += amount
+		}
+	}
+	var shares []serviceShare
+	for service, amount := range totals {
+		if amount > 0 {
+			shares = append(shares, serviceShare{service: service, share: amount / sum})
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].service < shares[j].service })
+	return shares
+}
@@ -0,0 +1,124 @@
+// File: approximate_test.go
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGetCostsApproximate(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	dailyTotals := map[string]string{"2024-01-06": "10", "2024-01-07": "20", "2024-01-08": "8", "2024-01-09": "4"}
+	// Each ISO week's breakdown; the first week is Saturday and Sunday only
+	breakdowns := map[string][]types.Group{
+		"2024-01-06": {
+			{Keys: []string{"AWS Lambda"}, Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("7.5"), Unit: aws.String("USD")}}},
+			{Keys: []string{"Amazon Simple Storage Service"}, Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("22.5"), Unit: aws.String("USD")}}},
+		},
+		"2024-01-08": {
+			{Keys: []string{"AWS Lambda"}, Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("12"), Unit: aws.String("USD")}}},
+			{Keys: []string{"Tax"}, Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("-1"), Unit: aws.String("USD")}}},
+		},
+	}
+
+	var requests int
+	mockClient := &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			requests++
+			if params.GroupBy == nil {
+				output := &costexplorer.GetCostAndUsageOutput{}
+				for _, day := range []string{"2024-01-06", "2024-01-07", "2024-01-08", "2024-01-09"} {
+					output.ResultsByTime = append(output.ResultsByTime, types.ResultByTime{
+						TimePeriod: &types.DateInterval{Start: aws.String(day)},
+						Total:      map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String(dailyTotals[day]), Unit: aws.String("USD")}},
+					})
+				}
+				return output, nil
+			}
+			start := aws.ToString(params.TimePeriod.Start)
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{{
+				TimePeriod: params.TimePeriod,
+				Groups:     breakdowns[start],
+			}}}, nil
+		},
+	}
+	tracker := &CostTracker{client: mockClient}
+	q := CostQuery{
+		Start:       time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		Granularity: types.GranularityDaily,
+	}
+
+	costs, err := tracker.GetCostsApproximate(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests (daily totals and two weeks), got %d", requests)
+	}
+	want := []CostByTime{
+		{Start: "2024-01-06", End: "2024-01-07", Approximate: true, Groups: []GroupedCost{
+			{Key: "AWS Lambda", Amount: "2.5", Unit: "USD"},
+			{Key: "Amazon Simple Storage Service", Amount: "7.5", Unit: "USD"},
+		}},
+		{Start: "2024-01-07", End: "2024-01-08", Approximate: true, Groups: []GroupedCost{
+			{Key: "AWS Lambda", Amount: "5", Unit: "USD"},
+			{Key: "Amazon Simple Storage Service", Amount: "15", Unit: "USD"},
+		}},
+		// Negative costs such as credits are not used to split the total
+		{Start: "2024-01-08", End: "2024-01-09", Approximate: true, Groups: []GroupedCost{{Key: "AWS Lambda", Amount: "8", Unit: "USD"}}},
+		{Start: "2024-01-09", End: "2024-01-10", Approximate: true, Groups: []GroupedCost{{Key: "AWS Lambda", Amount: "4", Unit: "USD"}}},
+	}
+	if len(costs) != len(want) {
+		t.Fatalf("expected %d periods, got %d: %+v", len(want), len(costs), costs)
+	}
+	for i := range want {
+		if costs[i].Start != want[i].Start || costs[i].End != want[i].End || !costs[i].Approximate || len(costs[i].Groups) != len(want[i].Groups) {
+			t.Fatalf("period %d: got %+v, want %+v", i, costs[i], want[i])
+		}
+		for j := range want[i].Groups {
+			if !reflect.DeepEqual(costs[i].Groups[j], want[i].Groups[j]) {
+				t.Errorf("period %d row %d: got %+v, want %+v", i, j, costs[i].Groups[j], want[i].Groups[j])
+			}
+		}
+	}
+
+	q.Granularity = types.GranularityMonthly
+	if _, err := tracker.GetCostsApproximate(context.Background(), q); err == nil {
+		t.Errorf("expected an error for monthly granularity, but got nil")
+	}
+}
+
+func TestDailyTotalsFollowsPages(t *testing.T) {
+	total := func(day, amount string) types.ResultByTime {
+		return types.ResultByTime{
+			TimePeriod: &types.DateInterval{Start: aws.String(day)},
+			Total:      map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String(amount), Unit: aws.String("USD")}},
+		}
+	}
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			if params.NextPageToken == nil {
+				return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{total("2024-01-06", "10")}, NextPageToken: aws.String("page-2")}, nil
+			}
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{total("2024-01-07", "20")}}, nil
+		},
+	}}
+	q := CostQuery{Start: time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)}
+
+	totals, unit, err := tracker.dailyTotals(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if want := map[string]float64{"2024-01-06": 10, "2024-01-07": 20}; !reflect.DeepEqual(totals, want) || unit != "USD" {
+		t.Errorf("expected totals from both pages, got %v %s", totals, unit)
+	}
+}
@@ -0,0 +1,48 @@
+// File: artifact.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ArtifactPathData is what an output path template can use, e.g. reports/{{.Command}}/{{.Date}}.csv.
+type ArtifactPathData struct {
+	Command string // The command writing the file, e.g. export
+	Date    string // Day of the run, YYYY-MM-DD in UTC
+	Start   string // First day of the report, YYYY-MM-DD
+	End     string // Last day of the report, YYYY-MM-DD
+}
+
+// artifactPath expands the output path template pattern for a file written by command about q's
+// range, and creates the directory it lands in, so scheduled runs write to predictable paths.
+func artifactPath(pattern, command string, q CostQuery, now time.Time) (string, error) {
+	if strings.Contains(pattern, "://") {
+		return "", fmt.Errorf("output path %q is not a local path; only local files can be written", pattern)
+	}
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid output path template %q: %w", pattern, err)
+	}
+	var path strings.Builder
+	err = tmpl.Execute(&path, ArtifactPathData{
+		Command: command,
+		Date:    now.UTC().Format(AWSDateFormat),
+		Start:   q.Start.Format(AWSDateFormat),
+		End:     q.End.AddDate(0, 0, -1).Format(AWSDateFormat),
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid output path template %q: %w", pattern, err)
+	}
+	if path.Len() == 0 {
+		return "", fmt.Errorf("output path template %q expands to an empty path", pattern)
+	}
+	if err := os.MkdirAll(filepath.Dir(path.String()), 0o755); err != nil {
+		return "", err
+	}
+	return path.String(), nil
+}
@@ -0,0 +1,38 @@
+// File: artifact_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArtifactPath(t *testing.T) {
+	dir := t.TempDir()
+	q := CostQuery{
+		Start: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+	now := time.Date(2024, 6, 2, 6, 0, 0, 0, time.UTC)
+
+	path, err := artifactPath(filepath.Join(dir, "{{.Command}}", "{{.Start}}_{{.End}}-{{.Date}}.csv"), "export", q, now)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if want := filepath.Join(dir, "export", "2024-05-01_2024-05-31-2024-06-02.csv"); path != want {
+		t.Errorf("expected %s, got %s", want, path)
+	}
+	if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+		t.Errorf("expected the directory to be created, got %v", err)
+	}
+
+	if path, err := artifactPath("costs.csv", "export", q, now); err != nil || path != "costs.csv" {
+		t.Errorf("expected a plain path to be kept, got %q, %v", path, err)
+	}
+	for _, pattern := range []string{"{{.Profile}}.csv", "{{.Date", "s3://bucket/{{.Date}}.csv"} {
+		if _, err := artifactPath(pattern, "export", q, now); err == nil {
+			t.Errorf("expected an error for %q", pattern)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// File: blending.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+)
+
+// BlendingLine is the blended and unblended cost of one account, or of one service in one account.
+type BlendingLine struct {
+	Account   string
+	Service   string // Empty for an account's total
+	Blended   float64
+	Unblended float64
+}
+
+// Difference returns how much more the line costs at the organization's blended rates than at its own.
+func (l BlendingLine) Difference() float64 {
+	return l.Blended - l.Unblended
+}
+
+// BlendingReport is the result of GetBlendingDiscrepancy.
+type BlendingReport struct {
+	Days     float64
+	Unit     string
+	Accounts []BlendingLine // One per account, largest absolute difference first
+	Services []BlendingLine // One per account and service with a difference, largest absolute difference first
+}
+
+// GetBlendingDiscrepancy fetches blended and unblended cost by linked account and service over the
+// query range. Blended rates average the reserved instance and volume tier discounts of the whole
+// consolidated billing family, so the difference shows which accounts give up discounts they earned and
+// which receive discounts earned elsewhere. The GroupBy of q is ignored.
+func (ct *CostTracker) GetBlendingDiscrepancy(ctx context.Context, q CostQuery) (*BlendingReport, error) {
+	if !q.Start.Before(q.End) {
+		return nil, fmt.Errorf("start date %s must be before end date %s", q.Start.Format(AWSDateFormat), q.End.Format(AWSDateFormat))
+	}
+	input := &costexplorer.GetCostAndUsageInput{
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(q.Start.Format(AWSDateFormat)),
+			End:   aws.String(q.End.Format(AWSDateFormat)),
+		},
+		Filter:      q.Filter,
+		Granularity: GranularityMonthly,
+		Metrics:     []string{MetricBlendedCost, MetricUnblendedCost},
+		GroupBy: []types.GroupDefinition{
+			{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionLinkedAccount))},
+			{Type: GroupByTypeDimension, Key: aws.String(GroupByServiceKey)},
+		},
+	}
+
+	report := &BlendingReport{Days: q.End.Sub(q.Start).Hours() / 24}
+	accounts := make(map[string]*BlendingLine)
+	services := make(map[[2]string]*BlendingLine)
+	for {
+		result, err := ct.client.GetCostAndUsage(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cost data from AWS Cost Explorer: %w", err)
+		}
+		for _, resultByTime := range result.ResultsByTime {
+			for _, group := range resultByTime.Groups {
+				if len(group.Keys) < 2 {
+					continue
+				}
+				account, service := group.Keys[0], group.Keys[1]
+				blended, blendedOK := metricAmount(group.Metrics, MetricBlendedCost)
+				unblended, unblendedOK := metricAmount(group.Metrics, MetricUnblendedCost)
+				if !blendedOK || !unblendedOK {
+					logger.Warnw("Blended or unblended cost not found for service",
+						"account", account,
+						"service", service,
+						"periodStart", aws.ToString(resultByTime.TimePeriod.Start))
+					continue
+				}
+				report.Unit = aws.ToString(group.Metrics[MetricBlendedCost].Unit)
+
+				if accounts[account] == nil {
+					accounts[account] = &BlendingLine{Account: account}
+				}
+				accounts[account].Blended += blended
+				accounts[account].Unblended += unblended
+				key := [2]string{account, service}
+				if services[key] == nil {
+					services[key] = &BlendingLine{Account: account, Service: service}
+				}
+				services[key].Blended += blended
+				services[key].Unblended += unblended
+			}
+		}
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			break
+		}
+		input.NextPageToken = result.NextPageToken
+	}
+
+	for _, line := range accounts {
+		report.Accounts = append(report.Accounts, *line)
+	}
+	for _, line := range services {
+		if math.Abs(line.Difference()) >= 0.005 { // Differences that round to 0.00 explain nothing
+			report.Services = append(report.Services, *line)
+		}
+	}
+	byDifference := func(lines []BlendingLine) {
+		sort.Slice(lines, func(i, j int) bool {
+			di, dj := math.Abs(lines[i].Difference()), math.Abs(lines[j].Difference())
+			if di != dj {
+				return di > dj
+			}
+			return lines[i].Account+lines[i].Service < lines[j].Account+lines[j].Service
+		})
+	}
+	byDifference(report.Accounts)
+	byDifference(report.Services)
+	return report, nil
+}
+
+// displayBlendingReport writes the per-account differences, then the services behind them.
+func displayBlendingReport(w io.Writer, report *BlendingReport) {
+	fmt.Fprintf(w, "Blended vs unblended cost for the last %.0f days (%s):\n", report.Days, report.Unit)
+	fmt.Fprintln(w, "=====================================")
+	if len(report.Accounts) == 0 {
+		fmt.Fprintln(w, "No cost data found for the specified period.")
+		return
+	}
+
+	fmt.Fprintf(w, "%-30s %14s %14s %14s\n", "Account", "Blended", "Unblended", "Difference")
+	var blended, unblended float64
+	for _, l := range report.Accounts {
+		fmt.Fprintf(w, "%-30s %14.2f %14.2f %+14.2f\n", truncateName(l.Account, 30), l.Blended, l.Unblended, l.Difference())
+		blended += l.Blended
+		unblended += l.Unblended
+	}
+	fmt.Fprintf(w, "%-30s %14.2f %14.2f %+14.2f\n", "Total", blended, unblended, blended-unblended)
+
+	fmt.Fprintln(w)
+	if len(report.Services) == 0 {
+		fmt.Fprintln(w, "No service is priced differently at blended rates.")
+	} else {
+		fmt.Fprintln(w, "Services with a difference:")
+		fmt.Fprintf(w, "  %-14s %-30s %14s %14s %14s\n", "Account", "Service", "Blended", "Unblended", "Difference")
+		shown, hidden := rowLimit(len(report.Services))
+		for _, l := range report.Services[:shown] {
+			fmt.Fprintf(w, "  %-14s %-30s %14.2f %14.2f %+14.2f\n",
+				truncateName(l.Account, 14), truncateName(l.Service, 30), l.Blended, l.Unblended, l.Difference())
+		}
+		writeHiddenRows(w, hidden)
+	}
+	fmt.Fprintln(w, "A positive difference is a discount the account earned with its own reservations or volume but shared with")
+	fmt.Fprintln(w, "the organization; a negative one is a discount it received from reservations or volume elsewhere.")
+}
+
+var blendingCmd = &cobra.Command{
+	Use:   "blending",
+	Short: "Explain the difference between blended and unblended cost per account.",
+	Long: `Compares blended cost, which prices usage at the average rates of the whole consolidated billing family, with
+unblended cost, each account's own rates, per linked account and per service. Reserved instance and volume tier
+discounts are shared through blended rates, so an account that owns reservations pays more blended than
+unblended, while accounts using the same services without reservations pay less:
+
+  cost-tracker blending --period last-month`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		tracker, query, _ := setupReport(ctx)
+		report, err := tracker.GetBlendingDiscrepancy(ctx, query)
+		if err != nil {
+			errMsg := fmt.Sprintf("Error comparing blended and unblended costs: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error comparing blended and unblended costs", "error", err)
+		}
+
+		logger.Info("Displaying blended vs unblended costs to console.")
+		out, done := consoleWriter()
+		displayBlendingReport(out, report)
+		done()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(blendingCmd)
+}
@@ -0,0 +1,64 @@
+// File: blending_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGetBlendingDiscrepancy(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	group := func(account, service, blended, unblended string) types.Group {
+		return types.Group{
+			Keys: []string{account, service},
+			Metrics: map[string]types.MetricValue{
+				MetricBlendedCost:   {Amount: aws.String(blended), Unit: aws.String("USD")},
+				MetricUnblendedCost: {Amount: aws.String(unblended), Unit: aws.String("USD")},
+			},
+		}
+	}
+	var requested []string
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			requested = params.Metrics
+			// The reserving account pays 20 more blended; the other account 20 less.
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{{
+				TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-02-01")},
+				Groups: []types.Group{
+					group("111111111111", "Amazon EC2", "100", "80"),
+					group("111111111111", "Amazon S3", "5", "5"),
+					group("222222222222", "Amazon EC2", "100", "120"),
+				},
+			}}}, nil
+		},
+	}}
+	q := CostQuery{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}
+
+	report, err := tracker.GetBlendingDiscrepancy(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if strings.Join(requested, ",") != "BlendedCost,UnblendedCost" {
+		t.Errorf("expected both metrics in one request, got %v", requested)
+	}
+	if len(report.Accounts) != 2 || report.Accounts[0].Account != "111111111111" || report.Accounts[0].Difference() != 20 || report.Accounts[1].Difference() != -20 {
+		t.Errorf("unexpected account lines: %+v", report.Accounts)
+	}
+	if len(report.Services) != 2 {
+		t.Errorf("expected only the EC2 lines, which differ, got %+v", report.Services)
+	}
+
+	var buf bytes.Buffer
+	displayBlendingReport(&buf, report)
+	if !strings.Contains(buf.String(), "Total                                  205.00         205.00          +0.00") {
+		t.Errorf("expected the differences to cancel out across the organization, got:\n%s", buf.String())
+	}
+}
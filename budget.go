@@ -0,0 +1,492 @@
+// File: budget.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/budgets"
+	budgetstypes "github.com/aws/aws-sdk-go-v2/service/budgets/types"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// BudgetsAPI is the part of the AWS Budgets API the budget commands use. Like AnomalyMonitorAPI it has
+// no mock or explain client, since budget create changes the account.
+type BudgetsAPI interface {
+	DescribeBudgets(ctx context.Context, params *budgets.DescribeBudgetsInput, optFns ...func(*budgets.Options)) (*budgets.DescribeBudgetsOutput, error)
+	CreateBudget(ctx context.Context, params *budgets.CreateBudgetInput, optFns ...func(*budgets.Options)) (*budgets.CreateBudgetOutput, error)
+}
+
+// budgetFilterKeys maps Cost Explorer dimensions to the AWS Budgets cost filter keys they correspond to.
+var budgetFilterKeys = map[types.Dimension]string{
+	types.DimensionAz:              "AZ",
+	types.DimensionBillingEntity:   "BillingEntity",
+	types.DimensionInstanceType:    "InstanceType",
+	types.DimensionInvoicingEntity: "InvoicingEntity",
+	types.DimensionLegalEntityName: "LegalEntityName",
+	types.DimensionLinkedAccount:   "LinkedAccount",
+	types.DimensionOperatingSystem: "OperatingSystem",
+	types.DimensionOperation:       "Operation",
+	types.DimensionPurchaseType:    "PurchaseType",
+	types.DimensionRecordType:      "RecordType",
+	types.DimensionRegion:          "Region",
+	types.DimensionService:         "Service",
+	types.DimensionUsageType:       "UsageType",
+	types.DimensionUsageTypeGroup:  "UsageTypeGroup",
+}
+
+// budgetCostFilters converts a parsed --filter expression into AWS Budgets cost filters. Budgets match
+// any of a key's values and all of the keys, so only an AND of positive comparisons on different keys
+// can be converted. A nil expression is all spend.
+func budgetCostFilters(expr *types.Expression) (map[string][]string, error) {
+	filters := make(map[string][]string)
+	var add func(e *types.Expression) error
+	add = func(e *types.Expression) error {
+		var key string
+		var values []string
+		switch {
+		case e.And != nil:
+			for i := range e.And {
+				if err := add(&e.And[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		case e.Or != nil || e.Not != nil:
+			return fmt.Errorf("AWS Budgets cannot filter with OR, NOT or !=; use = or IN joined by AND")
+		case e.Dimensions != nil:
+			k, ok := budgetFilterKeys[e.Dimensions.Key]
+			if !ok {
+				return fmt.Errorf("AWS Budgets cannot filter by %s", strings.ToLower(string(e.Dimensions.Key)))
+			}
+			key, values = k, e.Dimensions.Values
+		case e.Tags != nil:
+			key = "TagKeyValue"
+			for _, v := range e.Tags.Values {
+				values = append(values, "user:"+aws.ToString(e.Tags.Key)+"$"+v)
+			}
+		case e.CostCategories != nil:
+			key = "CostCategory"
+			for _, v := range e.CostCategories.Values {
+				values = append(values, aws.ToString(e.CostCategories.Key)+"$"+v)
+			}
+		default:
+			return fmt.Errorf("unsupported filter expression")
+		}
+		if _, ok := filters[key]; ok {
+			return fmt.Errorf("AWS Budgets can filter by %s only once; list the values with IN instead", key)
+		}
+		filters[key] = values
+		return nil
+	}
+	if expr == nil {
+		return nil, nil
+	}
+	if err := add(expr); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// awsBudget returns the monthly AWS cost budget to create for b, limited to b.Monthly in unit.
+func (b Budget) awsBudget(unit string) (*budgetstypes.Budget, error) {
+	if b.Monthly <= 0 {
+		return nil, fmt.Errorf("budget %q has no monthly amount", b.Name)
+	}
+	var filters map[string][]string
+	if b.Filter != "" {
+		expr, err := ParseFilter(b.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("budget %q has an invalid filter: %w", b.Name, err)
+		}
+		if filters, err = budgetCostFilters(expr); err != nil {
+			return nil, fmt.Errorf("budget %q: %w", b.Name, err)
+		}
+	}
+	return &budgetstypes.Budget{
+		BudgetName:  aws.String(b.Name),
+		BudgetType:  budgetstypes.BudgetTypeCost,
+		TimeUnit:    budgetstypes.TimeUnitMonthly,
+		BudgetLimit: &budgetstypes.Spend{Amount: aws.String(strconv.FormatFloat(b.Monthly, 'f', 2, 64)), Unit: aws.String(unit)},
+		CostFilters: filters,
+	}, nil
+}
+
+// budgetNotifications returns the alert to create with each budget: an email to each of emails once
+// actual spend passes alertPercent of the budget. It returns nil without emails.
+func budgetNotifications(emails []string, alertPercent float64) []budgetstypes.NotificationWithSubscribers {
+	if len(emails) == 0 {
+		return nil
+	}
+	var subscribers []budgetstypes.Subscriber
+	for _, email := range emails {
+		subscribers = append(subscribers, budgetstypes.Subscriber{SubscriptionType: budgetstypes.SubscriptionTypeEmail, Address: aws.String(email)})
+	}
+	return []budgetstypes.NotificationWithSubscribers{{
+		Notification: &budgetstypes.Notification{
+			NotificationType:   budgetstypes.NotificationTypeActual,
+			ComparisonOperator: budgetstypes.ComparisonOperatorGreaterThan,
+			Threshold:          alertPercent,
+			ThresholdType:      budgetstypes.ThresholdTypePercentage,
+		},
+		Subscribers: subscribers,
+	}}
+}
+
+// listAWSBudgets returns every budget of the account, by name.
+func listAWSBudgets(ctx context.Context, api BudgetsAPI, accountID string) ([]budgetstypes.Budget, error) {
+	var list []budgetstypes.Budget
+	input := &budgets.DescribeBudgetsInput{AccountId: aws.String(accountID)}
+	for {
+		result, err := api.DescribeBudgets(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list AWS budgets: %w", err)
+		}
+		list = append(list, result.Budgets...)
+		if result.NextToken == nil || *result.NextToken == "" {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+	sort.Slice(list, func(i, j int) bool { return aws.ToString(list[i].BudgetName) < aws.ToString(list[j].BudgetName) })
+	return list, nil
+}
+
+// createAWSBudgets creates an AWS budget for each configured budget that does not exist yet, by name,
+// writing what it does to w. With dryRun it only writes what it would do. Existing budgets are not
+// updated to match their configuration.
+func createAWSBudgets(ctx context.Context, api BudgetsAPI, w io.Writer, accountID string, configs []Budget, dryRun bool) error {
+	existing, err := listAWSBudgets(ctx, api, accountID)
+	if err != nil {
+		return err
+	}
+	unit := viper.GetString("budget.unit")
+	alertPercent := viper.GetFloat64("budget.alert_percent")
+	notifications := budgetNotifications(configStringSlice("budget.emails"), alertPercent)
+
+	verb := "Created"
+	if dryRun {
+		verb = "Would create"
+	}
+	for _, c := range configs {
+		if slices.ContainsFunc(existing, func(b budgetstypes.Budget) bool { return aws.ToString(b.BudgetName) == c.Name }) {
+			fmt.Fprintf(w, "Budget %s already exists\n", c.Name)
+			continue
+		}
+		budget, err := c.awsBudget(unit)
+		if err != nil {
+			return err
+		}
+		if !dryRun {
+			input := &budgets.CreateBudgetInput{AccountId: aws.String(accountID), Budget: budget, NotificationsWithSubscribers: notifications}
+			if _, err := api.CreateBudget(ctx, input); err != nil {
+				return fmt.Errorf("failed to create AWS budget %q: %w", c.Name, err)
+			}
+		}
+		fmt.Fprintf(w, "%s monthly budget %s of %s %s", verb, c.Name, aws.ToString(budget.BudgetLimit.Amount), unit)
+		if len(notifications) > 0 {
+			fmt.Fprintf(w, ", emailing %d subscriber(s) past %g%%", len(notifications[0].Subscribers), alertPercent)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// BudgetStatus is the actual and forecasted spend of an AWS budget in its current period.
+type BudgetStatus struct {
+	Name       string
+	TimeUnit   string
+	Unit       string
+	Budgeted   float64
+	Actual     float64
+	Forecasted float64 // 0 when AWS has no forecast yet
+}
+
+// Consumed returns the actual spend as a percentage of the budget, or 0 for a zero budget.
+func (s BudgetStatus) Consumed() float64 {
+	if s.Budgeted == 0 {
+		return 0
+	}
+	return s.Actual / s.Budgeted * 100
+}
+
+// ForecastConsumed returns the forecasted spend as a percentage of the budget, or 0 for a zero budget.
+func (s BudgetStatus) ForecastConsumed() float64 {
+	if s.Budgeted == 0 {
+		return 0
+	}
+	return s.Forecasted / s.Budgeted * 100
+}
+
+// budgetStatuses returns the status of each cost and usage budget in list, or only of those named.
+// Utilization and coverage budgets track percentages rather than spend, so they are left out.
+func budgetStatuses(list []budgetstypes.Budget, names []string) ([]BudgetStatus, error) {
+	amount := func(s *budgetstypes.Spend) (float64, error) {
+		if s == nil || s.Amount == nil {
+			return 0, nil
+		}
+		return strconv.ParseFloat(aws.ToString(s.Amount), 64)
+	}
+	var statuses []BudgetStatus
+	for _, b := range list {
+		name := aws.ToString(b.BudgetName)
+		if len(names) > 0 && !slices.Contains(names, name) {
+			continue
+		}
+		if b.BudgetType != budgetstypes.BudgetTypeCost && b.BudgetType != budgetstypes.BudgetTypeUsage {
+			continue
+		}
+		status := BudgetStatus{Name: name, TimeUnit: strings.ToLower(string(b.TimeUnit))}
+		var err error
+		if b.BudgetLimit != nil {
+			status.Unit = aws.ToString(b.BudgetLimit.Unit)
+			status.Budgeted, err = amount(b.BudgetLimit)
+		}
+		if err == nil && b.CalculatedSpend != nil {
+			if status.Actual, err = amount(b.CalculatedSpend.ActualSpend); err == nil {
+				status.Forecasted, err = amount(b.CalculatedSpend.ForecastedSpend)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unparseable amount in AWS budget %q: %w", name, err)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, name := range names {
+		if !slices.ContainsFunc(statuses, func(s BudgetStatus) bool { return s.Name == name }) {
+			return nil, fmt.Errorf("no cost or usage budget named %q", name)
+		}
+	}
+	return statuses, nil
+}
+
+// budgetAlerts returns one line per budget that has consumed at least alertPercent, in order.
+func budgetAlerts(statuses []BudgetStatus, alertPercent float64) []string {
+	var alerts []string
+	for _, s := range statuses {
+		if s.Consumed() >= alertPercent {
+			alerts = append(alerts, fmt.Sprintf("%s has used %.0f%% of its %s budget: %.2f of %.2f %s",
+				s.Name, s.Consumed(), s.TimeUnit, s.Actual, s.Budgeted, s.Unit))
+		}
+	}
+	return alerts
+}
+
+// displayBudgetStatus writes actual and forecasted spend against each budget, marking budgets that have
+// consumed at least alertPercent.
+func displayBudgetStatus(w io.Writer, statuses []BudgetStatus, alertPercent float64) {
+	fmt.Fprintln(w, "AWS budgets, current period:")
+	fmt.Fprintln(w, "=====================================")
+	if len(statuses) == 0 {
+		fmt.Fprintln(w, "No cost or usage budgets found.")
+		return
+	}
+	fmt.Fprintf(w, "  %-30s %-10s %12s %12s %9s %12s %9s %s\n", "Name", "Period", "Budgeted", "Actual", "Consumed", "Forecast", "Forecast%", "Unit")
+	for _, s := range statuses {
+		marker := " "
+		if s.Consumed() >= alertPercent {
+			marker = "!"
+		}
+		forecast, forecastPercent := "", ""
+		if s.Forecasted > 0 {
+			forecast, forecastPercent = fmt.Sprintf("%.2f", s.Forecasted), fmt.Sprintf("%.0f%%", s.ForecastConsumed())
+		}
+		fmt.Fprintf(w, "%s %-30s %-10s %12.2f %12.2f %9s %12s %9s %s\n", marker, truncateName(s.Name, 30), s.TimeUnit,
+			s.Budgeted, s.Actual, fmt.Sprintf("%.0f%%", s.Consumed()), forecast, forecastPercent, s.Unit)
+	}
+	fmt.Fprintf(w, "! marks budgets that have used at least %.0f%%.\n", alertPercent)
+}
+
+// displayAWSBudgets writes each AWS budget with its limit and filters and whether it is in the budgets
+// configuration key, followed by configured budgets that have not been created.
+func displayAWSBudgets(w io.Writer, list []budgetstypes.Budget, configs []Budget) {
+	fmt.Fprintln(w, "AWS budgets:")
+	fmt.Fprintln(w, "=====================================")
+	if len(list) == 0 {
+		fmt.Fprintln(w, "No AWS budgets found.")
+	} else {
+		fmt.Fprintf(w, "%-30s %-10s %-10s %18s %-10s %s\n", "Name", "Type", "Period", "Limit", "Configured", "Filters")
+	}
+	created := make(map[string]bool, len(list))
+	for _, b := range list {
+		name := aws.ToString(b.BudgetName)
+		created[name] = true
+		limit := ""
+		if b.BudgetLimit != nil {
+			limit = aws.ToString(b.BudgetLimit.Amount) + " " + aws.ToString(b.BudgetLimit.Unit)
+		}
+		var filters []string
+		for key, values := range b.CostFilters {
+			filters = append(filters, key+"="+strings.Join(values, ","))
+		}
+		sort.Strings(filters)
+		isConfigured := "no"
+		if slices.ContainsFunc(configs, func(c Budget) bool { return c.Name == name }) {
+			isConfigured = "yes"
+		}
+		row := fmt.Sprintf("%-30s %-10s %-10s %18s %-10s %s", truncateName(name, 30), strings.ToLower(string(b.BudgetType)),
+			strings.ToLower(string(b.TimeUnit)), limit, isConfigured, strings.Join(filters, "; "))
+		fmt.Fprintln(w, strings.TrimRight(row, " "))
+	}
+	for _, c := range configs {
+		if !created[c.Name] && c.Monthly > 0 {
+			fmt.Fprintf(w, "Configured but not created: %s (run budget create)\n", c.Name)
+		}
+	}
+}
+
+// newBudgetsClient creates an AWS Budgets client and returns it with the ID of the account whose budgets
+// it manages: budget.account_id, or the account of the caller.
+func newBudgetsClient(ctx context.Context) (BudgetsAPI, string, error) {
+	if provider := viper.GetString("provider"); provider != ProviderAWS && provider != "" {
+		return nil, "", fmt.Errorf("budgets are only available in AWS, not with the %s provider", provider)
+	}
+	role, err := AssumeRoleConfigFromViper()
+	if err != nil {
+		return nil, "", err
+	}
+	cfg, err := loadAWSConfig(ctx, role)
+	if err != nil {
+		return nil, "", err
+	}
+	accountID := viper.GetString("budget.account_id")
+	if accountID == "" {
+		identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to look up the account ID; set budget.account_id: %w", err)
+		}
+		accountID = aws.ToString(identity.Account)
+	}
+	return budgets.NewFromConfig(cfg), accountID, nil
+}
+
+// setupBudgets reads the configured budgets and creates a client for a budget subcommand, exiting on failure.
+func setupBudgets(ctx context.Context) (BudgetsAPI, string, []Budget) {
+	configs, err := BudgetsFromViper()
+	if err != nil {
+		logger.Fatalw("Invalid budgets", "error", err)
+	}
+	api, accountID, err := newBudgetsClient(ctx)
+	if err != nil {
+		logger.Fatalw("Failed to create AWS Budgets client", "error", err)
+	}
+	return api, accountID, configs
+}
+
+var budgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "List, check and create AWS Budgets.",
+	Long: `Works with AWS Budgets, which tracks spend against a limit in AWS itself, unlike the budgets configuration key
+that burn and variance check against Cost Explorer. budget create turns the configured budgets into AWS budgets,
+which needs write permissions the read-only reporting role does not have; with --explain it only prints what it
+would create.`,
+}
+
+var budgetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List AWS budgets with their limits and filters.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		api, accountID, configs := setupBudgets(ctx)
+		list, err := listAWSBudgets(ctx, api, accountID)
+		if err != nil {
+			logger.Fatalw("Error listing AWS budgets", "error", err)
+		}
+		out, done := consoleWriter()
+		displayAWSBudgets(out, list, configs)
+		done()
+	},
+}
+
+var budgetStatusCmd = &cobra.Command{
+	Use:   "status [name...]",
+	Short: "Show actual and forecasted spend against each AWS budget.",
+	Long: `Shows the actual and forecasted spend of each AWS cost and usage budget, or only those named, in its current
+period, with the percentage of the budget consumed. Budgets that have used at least budget.alert_percent are marked;
+--notify sends them to Slack:
+
+  cost-tracker budget status --notify`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		api, accountID, _ := setupBudgets(ctx)
+		list, err := listAWSBudgets(ctx, api, accountID)
+		var statuses []BudgetStatus
+		if err == nil {
+			statuses, err = budgetStatuses(list, args)
+		}
+		if err != nil {
+			errMsg := fmt.Sprintf("Error getting AWS budget status: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error getting AWS budget status", "error", err)
+		}
+
+		alertPercent := viper.GetFloat64("budget.alert_percent")
+		logger.Info("Displaying AWS budget status to console.")
+		out, done := consoleWriter()
+		displayBudgetStatus(out, statuses, alertPercent)
+		done()
+
+		if notify, _ := cmd.Flags().GetBool("notify"); notify {
+			if alerts := budgetAlerts(statuses, alertPercent); len(alerts) > 0 {
+				sendSlackNotification(fmt.Sprintf("AWS budgets at or over %.0f%%:\n%s", alertPercent, strings.Join(alerts, "\n")))
+			}
+		}
+	},
+}
+
+var budgetCreateCmd = &cobra.Command{
+	Use:   "create [name...]",
+	Short: "Create AWS budgets for the configured budgets that do not exist yet.",
+	Long: `Creates a monthly AWS cost budget for each budget in the budgets configuration key, or only those named, that
+does not exist yet, limited to its monthly amount in budget.unit. Budgets are matched by name, so running it again
+creates only what is missing. A budget's filter must be = or IN comparisons joined by AND, which is all AWS Budgets
+can express. With budget.emails set, each new budget emails them once actual spend passes budget.alert_percent:
+
+  cost-tracker budget create --explain`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		api, accountID, configs := setupBudgets(ctx)
+		if len(args) > 0 {
+			for _, name := range args {
+				if !slices.ContainsFunc(configs, func(c Budget) bool { return c.Name == name }) {
+					logger.Fatalw("Budget is not configured in budgets", "name", name)
+				}
+			}
+			configs = slices.DeleteFunc(configs, func(c Budget) bool { return !slices.Contains(args, c.Name) })
+		}
+		if len(configs) == 0 {
+			logger.Fatalw("No budgets configured in budgets")
+		}
+		if err := createAWSBudgets(ctx, api, cmd.OutOrStdout(), accountID, configs, explaining()); err != nil {
+			logger.Fatalw("Error creating AWS budgets", "error", err)
+		}
+	},
+}
+
+func init() {
+	viper.SetDefault("budget.alert_percent", 80.0) // Budgets that have used at least this percentage are alerted
+	viper.SetDefault("budget.unit", "USD")         // Currency of created budgets, which must be the account's
+	viper.SetDefault("budget.emails", []string{})  // Emailed by created budgets past budget.alert_percent
+	viper.SetDefault("budget.account_id", "")      // Account whose budgets are managed; empty is the caller's
+
+	budgetStatusCmd.Flags().Bool("notify", false, "Send budgets that have used at least budget.alert_percent to Slack")
+	budgetCmd.AddCommand(budgetListCmd, budgetStatusCmd, budgetCreateCmd)
+	rootCmd.AddCommand(budgetCmd)
+}
@@ -0,0 +1,148 @@
+// File: budget_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/budgets"
+	budgetstypes "github.com/aws/aws-sdk-go-v2/service/budgets/types"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zaptest"
+)
+
+// fakeBudgetsAPI keeps budgets in memory and returns them one per page.
+type fakeBudgetsAPI struct {
+	budgets       []budgetstypes.Budget
+	notifications map[string][]budgetstypes.NotificationWithSubscribers
+}
+
+func (f *fakeBudgetsAPI) DescribeBudgets(ctx context.Context, params *budgets.DescribeBudgetsInput, optFns ...func(*budgets.Options)) (*budgets.DescribeBudgetsOutput, error) {
+	i := 0
+	if params.NextToken != nil {
+		i = len(aws.ToString(params.NextToken))
+	}
+	if i >= len(f.budgets) {
+		return &budgets.DescribeBudgetsOutput{}, nil
+	}
+	out := &budgets.DescribeBudgetsOutput{Budgets: f.budgets[i : i+1]}
+	if i+1 < len(f.budgets) {
+		out.NextToken = aws.String(strings.Repeat("x", i+1))
+	}
+	return out, nil
+}
+
+func (f *fakeBudgetsAPI) CreateBudget(ctx context.Context, params *budgets.CreateBudgetInput, optFns ...func(*budgets.Options)) (*budgets.CreateBudgetOutput, error) {
+	f.budgets = append(f.budgets, *params.Budget)
+	if f.notifications == nil {
+		f.notifications = make(map[string][]budgetstypes.NotificationWithSubscribers)
+	}
+	f.notifications[aws.ToString(params.Budget.BudgetName)] = params.NotificationsWithSubscribers
+	return &budgets.CreateBudgetOutput{}, nil
+}
+
+func TestBudgetCostFilters(t *testing.T) {
+	expr, err := ParseFilter(`service in (AmazonEC2, AmazonRDS) and tag:team = data and account = 111111111111`)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	filters, err := budgetCostFilters(expr)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	want := map[string][]string{
+		"Service":       {"AmazonEC2", "AmazonRDS"},
+		"TagKeyValue":   {"user:team$data"},
+		"LinkedAccount": {"111111111111"},
+	}
+	if !reflect.DeepEqual(filters, want) {
+		t.Errorf("expected %v, got %v", want, filters)
+	}
+
+	for _, filter := range []string{`service = a or region = b`, `region != us-east-1`, `region = a and region = b`} {
+		expr, err := ParseFilter(filter)
+		if err != nil {
+			t.Fatalf("did not expect an error, but got: %v", err)
+		}
+		if _, err := budgetCostFilters(expr); err == nil {
+			t.Errorf("%s: expected an error", filter)
+		}
+	}
+}
+
+func TestCreateAWSBudgets(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	viper.Set("budget.emails", []string{"finops@example.com"})
+	t.Cleanup(func() { viper.Set("budget.emails", nil) })
+	api := &fakeBudgetsAPI{budgets: []budgetstypes.Budget{{BudgetName: aws.String("platform")}}}
+	configs := []Budget{
+		{Name: "platform", Monthly: 500},
+		{Name: "data", Filter: "tag:team = data", Monthly: 1200},
+	}
+
+	var buf bytes.Buffer
+	if err := createAWSBudgets(context.Background(), api, &buf, "111111111111", configs, true); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(api.budgets) != 1 || !strings.Contains(buf.String(), "Would create monthly budget data of 1200.00 USD") {
+		t.Fatalf("expected a dry run to change nothing, got %d budgets and output:\n%s", len(api.budgets), buf.String())
+	}
+
+	buf.Reset()
+	if err := createAWSBudgets(context.Background(), api, &buf, "111111111111", configs, false); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(api.budgets) != 2 || api.budgets[1].CostFilters["TagKeyValue"][0] != "user:team$data" {
+		t.Fatalf("expected only data to be created, got %+v", api.budgets)
+	}
+	if n := api.notifications["data"]; len(n) != 1 || n[0].Notification.Threshold != 80 || aws.ToString(n[0].Subscribers[0].Address) != "finops@example.com" {
+		t.Errorf("expected an 80%% email alert, got %+v", n)
+	}
+
+	if err := createAWSBudgets(context.Background(), api, &buf, "111111111111", []Budget{{Name: "all"}}, false); err == nil {
+		t.Error("expected an error for a budget without a monthly amount")
+	}
+}
+
+func TestBudgetStatus(t *testing.T) {
+	spend := func(amount string) *budgetstypes.Spend {
+		return &budgetstypes.Spend{Amount: aws.String(amount), Unit: aws.String("USD")}
+	}
+	api := &fakeBudgetsAPI{budgets: []budgetstypes.Budget{
+		{BudgetName: aws.String("platform"), BudgetType: budgetstypes.BudgetTypeCost, TimeUnit: budgetstypes.TimeUnitMonthly, BudgetLimit: spend("1000"),
+			CalculatedSpend: &budgetstypes.CalculatedSpend{ActualSpend: spend("850"), ForecastedSpend: spend("1200")}},
+		{BudgetName: aws.String("coverage"), BudgetType: budgetstypes.BudgetTypeRICoverage, TimeUnit: budgetstypes.TimeUnitMonthly},
+		{BudgetName: aws.String("data"), BudgetType: budgetstypes.BudgetTypeCost, TimeUnit: budgetstypes.TimeUnitQuarterly, BudgetLimit: spend("3000"),
+			CalculatedSpend: &budgetstypes.CalculatedSpend{ActualSpend: spend("300")}},
+	}}
+
+	list, err := listAWSBudgets(context.Background(), api, "111111111111")
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	statuses, err := budgetStatuses(list, nil)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].Name != "data" || statuses[1].Consumed() != 85 || statuses[1].ForecastConsumed() != 120 {
+		t.Fatalf("expected cost budgets in name order with 85%% consumed, got %+v", statuses)
+	}
+	alerts := budgetAlerts(statuses, 80)
+	if len(alerts) != 1 || alerts[0] != "platform has used 85% of its monthly budget: 850.00 of 1000.00 USD" {
+		t.Errorf("unexpected alerts: %v", alerts)
+	}
+
+	var buf bytes.Buffer
+	displayBudgetStatus(&buf, statuses, 80)
+	if !strings.Contains(buf.String(), "! platform") || strings.Contains(buf.String(), "! data") {
+		t.Errorf("expected only platform to be marked, got:\n%s", buf.String())
+	}
+
+	if _, err := budgetStatuses(list, []string{"coverage"}); err == nil {
+		t.Error("expected an error for a budget that does not track spend")
+	}
+}
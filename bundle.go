@@ -0,0 +1,343 @@
+// File: bundle.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// BundleVersion is the version of the data bundle format written by 'fetch'.
+const BundleVersion = 2
+
+// BundleData is the cost data carried from the 'fetch' host to the 'render' host.
+type BundleData struct {
+	Version   int          `json:"version"`
+	CreatedAt time.Time    `json:"created_at"`
+	Start     string       `json:"start"` // Query range, end exclusive
+	End       string       `json:"end"`
+	Days      int          `json:"days"`
+	Redaction string       `json:"redaction,omitempty"` // Redaction profile applied to Costs, if any
+	Costs     []CostByTime `json:"costs"`
+}
+
+// CostBundle is the file written by 'fetch'. Payload holds the encoded BundleData and SHA256 its
+// checksum, so a bundle damaged in transfer is rejected instead of rendered. Signature, when
+// bundle.signing_key is configured, is the base64 ed25519 signature of Payload.
+type CostBundle struct {
+	Payload   json.RawMessage `json:"payload"`
+	SHA256    string          `json:"sha256"`
+	Signature string          `json:"signature,omitempty"`
+}
+
+// readPEMBlock reads the single PEM block in the file at path.
+func readPEMBlock(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s contains no PEM data", path)
+	}
+	return block, nil
+}
+
+// LoadSigningKey reads a PKCS #8 PEM ed25519 private key, as written by
+// 'openssl genpkey -algorithm ed25519'.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an ed25519 key", path)
+	}
+	return private, nil
+}
+
+// LoadVerifyKey reads a PKIX PEM ed25519 public key, as written by 'openssl pkey -pubout'.
+func LoadVerifyKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an ed25519 key", path)
+	}
+	return public, nil
+}
+
+// Sign signs the bundle's payload with key.
+func (b *CostBundle) Sign(key ed25519.PrivateKey) {
+	b.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, b.Payload))
+}
+
+// Verify checks the bundle's signature against key. Unsigned bundles fail verification.
+func (b *CostBundle) Verify(key ed25519.PublicKey) error {
+	if b.Signature == "" {
+		return errors.New("bundle is not signed")
+	}
+	signature, err := base64.StdEncoding.DecodeString(b.Signature)
+	if err != nil {
+		return fmt.Errorf("bundle signature is not valid base64: %w", err)
+	}
+	if !ed25519.Verify(key, b.Payload, signature) {
+		return errors.New("bundle signature does not match its data: the bundle was modified or signed with a different key")
+	}
+	return nil
+}
+
+// verifyConfiguredKey verifies the bundle against bundle.public_key, if one is configured.
+func (b *CostBundle) verifyConfiguredKey() error {
+	path := viper.GetString("bundle.public_key")
+	if path == "" {
+		return nil
+	}
+	key, err := LoadVerifyKey(path)
+	if err != nil {
+		return err
+	}
+	return b.Verify(key)
+}
+
+// checksum returns the hex SHA-256 of payload.
+func checksum(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewCostBundle encodes the costs of a query into a bundle, recording the name of the redaction
+// profile already applied to them, if any.
+func NewCostBundle(q CostQuery, days int, costs []CostByTime, redaction string) (*CostBundle, error) {
+	payload, err := json.Marshal(BundleData{
+		Version:   BundleVersion,
+		CreatedAt: time.Now().UTC(),
+		Start:     q.Start.Format(AWSDateFormat),
+		End:       q.End.Format(AWSDateFormat),
+		Days:      days,
+		Redaction: redaction,
+		Costs:     costs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle data: %w", err)
+	}
+	return &CostBundle{Payload: payload, SHA256: checksum(payload)}, nil
+}
+
+// Write writes the bundle as indented JSON to path.
+func (b *CostBundle) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// ReadCostBundle reads a bundle from path, verifies its checksum and decodes its data.
+func ReadCostBundle(path string) (*CostBundle, *BundleData, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	var bundle CostBundle
+	if err := json.Unmarshal(raw, &bundle); err != nil {
+		return nil, nil, fmt.Errorf("bundle %s is not valid JSON: %w", path, err)
+	}
+	// Indented output re-indents the payload, so hash its compact form
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, bundle.Payload); err != nil {
+		return nil, nil, fmt.Errorf("bundle %s has an invalid payload: %w", path, err)
+	}
+	bundle.Payload = compact.Bytes()
+	if got := checksum(bundle.Payload); got != bundle.SHA256 {
+		return nil, nil, fmt.Errorf("bundle %s failed its checksum: payload is %s, expected %s", path, got, bundle.SHA256)
+	}
+
+	var data BundleData
+	if err := json.Unmarshal(bundle.Payload, &data); err != nil {
+		return nil, nil, fmt.Errorf("bundle %s has an invalid payload: %w", path, err)
+	}
+	if data.Version != BundleVersion {
+		return nil, nil, fmt.Errorf("bundle %s has version %d, this build reads version %d", path, data.Version, BundleVersion)
+	}
+	return &bundle, &data, nil
+}
+
+// redactionProfileFlag looks up the profile named by a --redact flag. An empty name is no redaction.
+func redactionProfileFlag(name string) (RedactionProfile, error) {
+	if name == "" {
+		return RedactionProfile{}, nil
+	}
+	return RedactionProfileByName(name)
+}
+
+var fetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "Fetch AWS costs into a data bundle for rendering on another host.",
+	Long: `Retrieves costs from Cost Explorer like 'get' and writes them to a bundle file instead of the console, so a host
+with AWS credentials but no route to Slack can hand the data to one that has:
+
+  cost-tracker fetch --out costs.bundle.json      # on the credentials host
+  cost-tracker render costs.bundle.json --notify  # on a host with egress`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		path, _ := cmd.Flags().GetString("out")
+		redaction, _ := cmd.Flags().GetString("redact")
+		profile, err := redactionProfileFlag(redaction)
+		if err != nil {
+			logger.Fatalw("Invalid redaction profile", "error", err)
+		}
+		tracker, query, days := setupReport(ctx)
+		costs, err := tracker.GetCosts(ctx, query)
+		if err != nil {
+			errMsg := fmt.Sprintf("Error getting costs: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error getting costs", "error", err)
+		}
+
+		if explaining() {
+			logger.Infow("Explaining the query plan. Skipping the bundle.", "path", path)
+			return
+		}
+		if path, err = artifactPath(path, cmd.Name(), query, time.Now()); err != nil {
+			logger.Fatalw("Invalid --out", "error", err)
+		}
+		if redaction != "" {
+			costs = profile.Apply(costs)
+		}
+		bundle, err := NewCostBundle(query, days, costs, redaction)
+		if err == nil && viper.GetString("bundle.signing_key") != "" {
+			var key ed25519.PrivateKey
+			if key, err = LoadSigningKey(viper.GetString("bundle.signing_key")); err == nil {
+				bundle.Sign(key)
+			}
+		}
+		if err == nil {
+			err = bundle.Write(path)
+		}
+		if err != nil {
+			logger.Fatalw("Failed to write bundle", "path", path, "error", err)
+		}
+		if activeManifest != nil {
+			activeManifest.Artifacts = []string{path}
+		}
+		logger.Infow("Wrote cost bundle", "path", path, "periods", len(costs))
+	},
+}
+
+var renderCmd = &cobra.Command{
+	Use:   "render <bundle>",
+	Short: "Display, and optionally send, the costs in a bundle written by 'fetch'.",
+	Long:  `Verifies a bundle written by 'fetch', including its signature if bundle.public_key is configured, and displays its costs in the 'get' format. With --notify the report is also sent to every configured notification channel. No AWS credentials are needed.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		bundle, data, err := ReadCostBundle(args[0])
+		if err == nil {
+			err = bundle.verifyConfiguredKey()
+		}
+		if err != nil {
+			logger.Fatalw("Invalid bundle", "error", err)
+		}
+		if redaction, _ := cmd.Flags().GetString("redact"); redaction != "" {
+			profile, err := redactionProfileFlag(redaction)
+			if err != nil {
+				logger.Fatalw("Invalid redaction profile", "error", err)
+			}
+			data.Costs = profile.Apply(data.Costs)
+		}
+
+		logger.Info("Displaying bundled costs to console.")
+		out, done := consoleWriter()
+		displayCosts(out, data.Costs, data.Days)
+		done()
+
+		if notify, _ := cmd.Flags().GetBool("notify"); !notify {
+			return
+		}
+		notifiers := configuredNotifiers()
+		if len(notifiers) == 0 {
+			logger.Fatal("No notification channels configured. Set COSTTRACKER_SLACK_WEBHOOK_URL or configure slack.webhook_url in cost-tracker-config.json.")
+		}
+		var report bytes.Buffer
+		displayCosts(&report, data.Costs, data.Days)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		for _, result := range notifyAll(ctx, notifiers, withRunID(report.String())) {
+			if result.Err != nil {
+				logger.Fatalw("Failed to send bundled report", "channel", result.Channel, "error", result.Err)
+			}
+		}
+		logger.Infow("Sent bundled report", "channels", len(notifiers))
+	},
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <bundle>",
+	Short: "Verify the checksum and signature of a bundle written by 'fetch'.",
+	Long:  `Checks that a bundle is intact and was signed by the holder of the private key matching --public-key (default bundle.public_key), so consumers of published cost data can detect tampering.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, _ := cmd.Flags().GetString("public-key")
+		if path == "" {
+			path = viper.GetString("bundle.public_key")
+		}
+		if path == "" {
+			logger.Fatal("No public key configured. Pass --public-key or configure bundle.public_key in cost-tracker-config.json.")
+		}
+		key, err := LoadVerifyKey(path)
+		if err != nil {
+			logger.Fatalw("Invalid public key", "error", err)
+		}
+		bundle, data, err := ReadCostBundle(args[0])
+		if err == nil {
+			err = bundle.Verify(key)
+		}
+		if err != nil {
+			logger.Fatalw("Bundle failed verification", "bundle", args[0], "error", err)
+		}
+		fmt.Printf("%s: OK (signed, %s to %s, created %s)\n", args[0], data.Start, data.End, data.CreatedAt.Format(time.RFC3339))
+	},
+}
+
+func init() {
+	viper.SetDefault("bundle.signing_key", "") // PEM ed25519 private key; empty writes unsigned bundles
+	viper.SetDefault("bundle.public_key", "")  // PEM ed25519 public key; empty skips signature checks in render
+
+	verifyCmd.Flags().String("public-key", "", "PEM ed25519 public key to verify against (default bundle.public_key)")
+	fetchCmd.Flags().String("out", "costs.bundle.json", "Path of the bundle file to write; may use {{.Command}}, {{.Date}}, {{.Start}} and {{.End}}")
+	fetchCmd.Flags().String("redact", "", "Redaction profile to apply before writing, e.g. vendor or public")
+	renderCmd.Flags().Bool("notify", false, "Also send the report to every configured notification channel")
+	renderCmd.Flags().String("redact", "", "Redaction profile to apply before displaying, e.g. vendor or public")
+	rootCmd.AddCommand(fetchCmd)
+	rootCmd.AddCommand(renderCmd)
+	rootCmd.AddCommand(verifyCmd)
+}
@@ -0,0 +1,134 @@
+// File: bundle_test.go
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCostBundleRoundTrip(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	costs := []CostByTime{{Start: "2024-01-01", End: "2024-01-31", Groups: []GroupedCost{
+		{Key: "Amazon Elastic Compute Cloud - Compute", Amount: "123.45", Unit: "USD"},
+		{Key: "<unescaped & service>", Amount: "1.00", Unit: "USD"},
+	}}}
+	bundle, err := NewCostBundle(q, 30, costs, "")
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "costs.bundle.json")
+	if err := bundle.Write(path); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+
+	_, data, err := ReadCostBundle(path)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if data.Start != "2024-01-01" || data.End != "2024-01-31" || data.Days != 30 {
+		t.Errorf("unexpected range: %+v", data)
+	}
+	if len(data.Costs) != 1 || len(data.Costs[0].Groups) != 2 || data.Costs[0].Groups[1].Key != "<unescaped & service>" {
+		t.Errorf("unexpected costs: %+v", data.Costs)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(raw), "123.45", "1.45", 1)
+	if err := os.WriteFile(path, []byte(tampered), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadCostBundle(path); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("expected a checksum error for a modified bundle, got: %v", err)
+	}
+}
+
+func TestCostBundleSignature(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePath, publicPath := filepath.Join(dir, "signing.pem"), filepath.Join(dir, "public.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	signingKey, err := LoadSigningKey(privatePath)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	verifyKey, err := LoadVerifyKey(publicPath)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if _, err := LoadVerifyKey(privatePath); err == nil {
+		t.Errorf("expected an error loading a private key as a public key, but got nil")
+	}
+
+	q := CostQuery{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)}
+	costs := []CostByTime{{Start: "2024-01-01", End: "2024-01-08", Groups: []GroupedCost{{Key: "AWS Lambda", Amount: "9.61", Unit: "USD"}}}}
+	bundle, err := NewCostBundle(q, 7, costs, "")
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if err := bundle.Verify(verifyKey); err == nil {
+		t.Errorf("expected an unsigned bundle to fail verification, but got nil")
+	}
+	bundle.Sign(signingKey)
+	path := filepath.Join(dir, "costs.bundle.json")
+	if err := bundle.Write(path); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+
+	read, _, err := ReadCostBundle(path)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if err := read.Verify(verifyKey); err != nil {
+		t.Errorf("expected the signed bundle to verify, but got: %v", err)
+	}
+
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := read.Verify(otherPublic); err == nil {
+		t.Errorf("expected verification with a different key to fail, but got nil")
+	}
+
+	// Recomputing the checksum after editing the data does not get past the signature
+	read.Payload = []byte(strings.Replace(string(read.Payload), "9.61", "0.61", 1))
+	read.SHA256 = checksum(read.Payload)
+	if err := read.Verify(verifyKey); err == nil {
+		t.Errorf("expected verification of modified data to fail, but got nil")
+	}
+}
@@ -0,0 +1,204 @@
+// File: burn.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// AllSpendLabel names the projection of all spend matching --filter in the burn report.
+const AllSpendLabel = "All spend"
+
+// simulateMonthEnd returns runs simulated month-end totals, sorted ascending. Each run adds spent to
+// remaining days of spend, each drawn at random from samples.
+func simulateMonthEnd(samples []float64, spent float64, remaining, runs int, rng *rand.Rand) []float64 {
+	totals := make([]float64, runs)
+	for i := range totals {
+		total := spent
+		for day := 0; day < remaining; day++ {
+			total += samples[rng.Intn(len(samples))]
+		}
+		totals[i] = total
+	}
+	sort.Float64s(totals)
+	return totals
+}
+
+// percentile returns the p-th percentile (0 to 1) of sorted values by the nearest-rank method.
+func percentile(sorted []float64, p float64) float64 {
+	i := int(p*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+// MonthEndProjection is the distribution of one line's month-end spend.
+type MonthEndProjection struct {
+	Name          string
+	Spent         float64 // Month to date
+	P50, P80, P95 float64
+	Budget        float64 // 0 for no budget
+	ExceedChance  float64 // Fraction of runs ending above the budget
+}
+
+// BurnReport is the result of ProjectMonthEnd.
+type BurnReport struct {
+	Month       time.Time
+	Elapsed     int // Days of the month before today
+	DaysInMonth int
+	Unit        string
+	Lines       []MonthEndProjection
+	AlertChance float64 // Budgets at least this likely to be exceeded are alerted
+}
+
+// Alerts returns one line per budget that is likely to be exceeded, in report order.
+func (r *BurnReport) Alerts() []string {
+	var alerts []string
+	for _, l := range r.Lines {
+		if l.Budget > 0 && l.ExceedChance >= r.AlertChance {
+			alerts = append(alerts, fmt.Sprintf("%s is likely to exceed its budget of %.2f %s: %.0f%% chance, P80 month-end spend %.2f %s",
+				l.Name, l.Budget, r.Unit, l.ExceedChance*100, l.P80, r.Unit))
+		}
+	}
+	return alerts
+}
+
+// ProjectMonthEnd projects the month-end spend of all spend matching filter and of each budget. The
+// rest of the month, from today, is simulated runs times by drawing each day's spend from the daily
+// spend of the sampleDays before today; today is drawn too, since its data is incomplete.
+func (ct *CostTracker) ProjectMonthEnd(ctx context.Context, filter *types.Expression, budgets []Budget, now time.Time, sampleDays, runs int, rng *rand.Rand) (*BurnReport, error) {
+	if sampleDays <= 0 || runs <= 0 {
+		return nil, fmt.Errorf("sample days and runs must be positive, got %d and %d", sampleDays, runs)
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	report := &BurnReport{
+		Month:       month,
+		Elapsed:     today.Day() - 1,
+		DaysInMonth: month.AddDate(0, 1, -1).Day(),
+		AlertChance: viper.GetFloat64("burn.alert_probability"),
+	}
+	start := today.AddDate(0, 0, -sampleDays)
+	if month.Before(start) {
+		start = month
+	}
+
+	lines := append([]Budget{{Name: AllSpendLabel}}, budgets...)
+	for _, b := range lines {
+		lineFilter := filter
+		if b.Filter != "" {
+			expr, err := ParseFilter(b.Filter)
+			if err != nil {
+				return nil, fmt.Errorf("budget %q has an invalid filter: %w", b.Name, err)
+			}
+			lineFilter = andExpression(filter, expr)
+		}
+		history, err := ct.GetDailyHistory(ctx, lineFilter, start, today)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get daily history for %s: %w", b.Name, err)
+		}
+		if history.Unit != "" {
+			report.Unit = history.Unit
+		}
+
+		days := history.Amounts
+		var spent float64
+		for _, amount := range days[len(days)-report.Elapsed:] {
+			spent += amount
+		}
+		totals := simulateMonthEnd(days[len(days)-sampleDays:], spent, report.DaysInMonth-report.Elapsed, runs, rng)
+		line := MonthEndProjection{
+			Name:   b.Name,
+			Spent:  spent,
+			P50:    percentile(totals, 0.50),
+			P80:    percentile(totals, 0.80),
+			P95:    percentile(totals, 0.95),
+			Budget: b.Monthly,
+		}
+		if b.Monthly > 0 {
+			within := sort.Search(len(totals), func(i int) bool { return totals[i] > b.Monthly })
+			line.ExceedChance = float64(len(totals)-within) / float64(runs)
+		}
+		report.Lines = append(report.Lines, line)
+	}
+	return report, nil
+}
+
+// displayBurnReport writes the month-end projection of each line, marking budgets likely to be exceeded.
+func displayBurnReport(w io.Writer, r *BurnReport) {
+	fmt.Fprintf(w, "Month-end projection for %s, %d of %d days elapsed (%s):\n", r.Month.Format(monthLayout), r.Elapsed, r.DaysInMonth, r.Unit)
+	fmt.Fprintln(w, "=====================================")
+	fmt.Fprintf(w, "  %-30s %12s %12s %12s %12s %12s %9s\n", "Name", "Spent", "P50", "P80", "P95", "Budget", "P(exceed)")
+	for _, l := range r.Lines {
+		marker, budget, chance := " ", "", ""
+		if l.Budget > 0 {
+			budget, chance = fmt.Sprintf("%.2f", l.Budget), fmt.Sprintf("%.0f%%", l.ExceedChance*100)
+			if l.ExceedChance >= r.AlertChance {
+				marker = "!"
+			}
+		}
+		row := fmt.Sprintf("%s %-30s %12.2f %12.2f %12.2f %12.2f %12s %9s", marker, truncateName(l.Name, 30), l.Spent, l.P50, l.P80, l.P95, budget, chance)
+		fmt.Fprintln(w, strings.TrimRight(row, " "))
+	}
+	fmt.Fprintf(w, "! marks budgets with at least a %.0f%% chance of being exceeded.\n", r.AlertChance*100)
+}
+
+var burnCmd = &cobra.Command{
+	Use:   "burn",
+	Short: "Project this month's spend to month end as P50/P80/P95 ranges.",
+	Long: `Projects the month-end spend of all spend and of each budget in the budgets configuration key. The rest of the
+month is simulated --runs times, drawing each day's spend at random from the daily spend of the last --days, so
+the projection is a range rather than a single point: half of the runs end below P50, 80% below P80 and 95% below
+P95. Budgets exceeded in at least burn.alert_probability of the runs are marked; --notify sends them to Slack:
+
+  cost-tracker burn --days 28 --notify`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		budgets, err := BudgetsFromViper()
+		if err != nil {
+			logger.Fatalw("Invalid budgets", "error", err)
+		}
+		runs, _ := cmd.Flags().GetInt("runs")
+		seed, _ := cmd.Flags().GetInt64("seed")
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+
+		tracker, query, days := setupReport(ctx)
+		report, err := tracker.ProjectMonthEnd(ctx, query.Filter, budgets, time.Now(), days, runs, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			errMsg := fmt.Sprintf("Error projecting month-end spend: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error projecting month-end spend", "error", err)
+		}
+
+		logger.Info("Displaying month-end projection to console.")
+		out, done := consoleWriter()
+		displayBurnReport(out, report)
+		done()
+
+		if notify, _ := cmd.Flags().GetBool("notify"); notify {
+			if alerts := report.Alerts(); len(alerts) > 0 {
+				sendSlackNotification("Budgets likely to be exceeded this month:\n" + strings.Join(alerts, "\n"))
+			}
+		}
+	},
+}
+
+func init() {
+	viper.SetDefault("burn.alert_probability", 0.5) // Budgets at least this likely to be exceeded are alerted
+
+	burnCmd.Flags().Int("runs", 10000, "Number of simulated months")
+	burnCmd.Flags().Int64("seed", 0, "Random seed, for a reproducible projection (default: random)")
+	burnCmd.Flags().Bool("notify", false, "Send budgets likely to be exceeded to Slack")
+	rootCmd.AddCommand(burnCmd)
+}
@@ -0,0 +1,74 @@
+// File: burn_test.go
+package main
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSimulateMonthEnd(t *testing.T) {
+	totals := simulateMonthEnd([]float64{10, 20}, 100, 10, 1000, rand.New(rand.NewSource(1)))
+	if totals[0] < 200 || totals[len(totals)-1] > 300 {
+		t.Errorf("expected totals between 200 and 300, got %.2f to %.2f", totals[0], totals[len(totals)-1])
+	}
+	p50, p95 := percentile(totals, 0.5), percentile(totals, 0.95)
+	if p50 < 240 || p50 > 260 || p95 <= p50 {
+		t.Errorf("unexpected percentiles P50 %.2f, P95 %.2f", p50, p95)
+	}
+}
+
+func TestProjectMonthEnd(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			// Every day costs 10, or 50 for the budget's filter.
+			amount := "10"
+			if params.Filter != nil {
+				amount = "50"
+			}
+			start, _ := time.Parse(AWSDateFormat, *params.TimePeriod.Start)
+			end, _ := time.Parse(AWSDateFormat, *params.TimePeriod.End)
+			var results []types.ResultByTime
+			for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+				results = append(results, types.ResultByTime{
+					TimePeriod: &types.DateInterval{Start: aws.String(day.Format(AWSDateFormat)), End: aws.String(day.AddDate(0, 0, 1).Format(AWSDateFormat))},
+					Groups: []types.Group{{
+						Keys:    []string{"Amazon EC2"},
+						Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String(amount), Unit: aws.String("USD")}},
+					}},
+				})
+			}
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: results}, nil
+		},
+	}}
+	budgets := []Budget{{Name: "Platform", Filter: "tag:team = platform", Monthly: 1000}}
+
+	// On April 11th, 10 of 30 days have passed.
+	now := time.Date(2024, 4, 11, 15, 0, 0, 0, time.UTC)
+	report, err := tracker.ProjectMonthEnd(context.Background(), nil, budgets, now, 14, 100, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if report.Elapsed != 10 || report.DaysInMonth != 30 {
+		t.Errorf("expected 10 of 30 days elapsed, got %d of %d", report.Elapsed, report.DaysInMonth)
+	}
+	all, platform := report.Lines[0], report.Lines[1]
+	if all.Name != AllSpendLabel || all.Spent != 100 || all.P50 != 300 || all.P95 != 300 {
+		t.Errorf("unexpected projection of all spend: %+v", all)
+	}
+	if platform.Spent != 500 || platform.P80 != 1500 || platform.ExceedChance != 1 {
+		t.Errorf("unexpected projection of the budget: %+v", platform)
+	}
+	report.AlertChance = 0.5
+	if alerts := report.Alerts(); len(alerts) != 1 || !strings.Contains(alerts[0], "Platform") {
+		t.Errorf("expected an alert for the platform budget, got %v", alerts)
+	}
+}
@@ -0,0 +1,156 @@
+// File: canary.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// CostCanary compares a workload's spend in the hours after a deployment marker with the same number
+// of hours before it.
+type CostCanary struct {
+	Marker        time.Time
+	Window        time.Duration
+	Before, After float64
+	Unit          string
+	Threshold     float64 // Largest allowed relative increase, e.g. 0.2 for 20%
+	Failed        bool
+}
+
+// Change returns the relative change in spend from before to after the marker, or +Inf if there was
+// no spend before it.
+func (c *CostCanary) Change() float64 {
+	if c.Before == 0 {
+		if c.After == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return (c.After - c.Before) / c.Before
+}
+
+// RunCostCanary sums hourly spend matching filter over the window before and after marker, which is
+// truncated to the hour. The canary fails when spend grew by more than threshold. Both windows must
+// have ended by now; Cost Explorer's hourly data, which has to be enabled in its settings, can lag by
+// several hours, so recent hours may still be incomplete.
+func (ct *CostTracker) RunCostCanary(ctx context.Context, filter *types.Expression, marker time.Time, window time.Duration, threshold float64, now time.Time) (*CostCanary, error) {
+	if window < time.Hour || window%time.Hour != 0 {
+		return nil, fmt.Errorf("window must be a positive whole number of hours, got %s", window)
+	}
+	marker = marker.UTC().Truncate(time.Hour)
+	if end := marker.Add(window); end.After(now) {
+		return nil, fmt.Errorf("the window after the marker ends at %s, which is in the future", end.Format(time.RFC3339))
+	}
+
+	costs, err := ct.GetCosts(ctx, CostQuery{
+		Start:       marker.Add(-window),
+		End:         marker.Add(window),
+		Filter:      filter,
+		Granularity: types.GranularityHourly,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	canary := &CostCanary{Marker: marker, Window: window, Threshold: threshold}
+	for _, period := range costs {
+		start, err := time.Parse(AWSHourFormat, period.Start)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected hourly period start %q: %w", period.Start, err)
+		}
+		for _, serviceCost := range period.Groups {
+			amount, err := strconv.ParseFloat(serviceCost.Amount, 64)
+			if err != nil {
+				logger.Warnw("Skipping unparseable cost amount", "service", serviceCost.Key, "periodStart", period.Start, "amount", serviceCost.Amount)
+				continue
+			}
+			if start.Before(marker) {
+				canary.Before += amount
+			} else {
+				canary.After += amount
+			}
+			canary.Unit = serviceCost.Unit
+		}
+	}
+	canary.Failed = canary.Change() > threshold
+	return canary, nil
+}
+
+// displayCostCanary writes the canary result to w.
+func displayCostCanary(w io.Writer, c *CostCanary) {
+	hours := int(c.Window.Hours())
+	fmt.Fprintf(w, "Cost canary around %s (%d hours either side):\n", c.Marker.Format(time.RFC3339), hours)
+	fmt.Fprintln(w, "=====================================")
+	fmt.Fprintf(w, "  %-20s %14.2f %s\n", "Before marker", c.Before, c.Unit)
+	fmt.Fprintf(w, "  %-20s %14.2f %s\n", "After marker", c.After, c.Unit)
+	change := "new spend"
+	if !math.IsInf(c.Change(), 1) {
+		change = fmt.Sprintf("%+.1f%%", c.Change()*100)
+	}
+	status := "PASS"
+	if c.Failed {
+		status = "FAIL"
+	}
+	fmt.Fprintf(w, "  %-20s %14s (limit %+.1f%%) %s\n", "Change", change, c.Threshold*100, status)
+}
+
+var canaryCmd = &cobra.Command{
+	Use:   "canary",
+	Short: "Fail if a workload's spend jumped after a deployment.",
+	Long: `Compares hourly spend matching --filter in the --hours after a deployment marker with the same number of hours
+before it, and exits non-zero if it grew by more than --threshold. Intended as a post-deploy check:
+
+  cost-tracker canary --marker 2024-05-01T12:00:00Z --hours 6 --filter 'tag:app = checkout'
+
+Requires hourly granularity to be enabled in the Cost Explorer settings.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		markerArg, _ := cmd.Flags().GetString("marker")
+		marker, err := time.Parse(time.RFC3339, markerArg)
+		if err != nil {
+			logger.Fatalw("Invalid --marker, expected an RFC 3339 timestamp such as 2024-05-01T12:00:00Z", "marker", markerArg, "error", err)
+		}
+		hours, _ := cmd.Flags().GetInt("hours")
+		threshold := viper.GetFloat64("canary.threshold")
+		if cmd.Flags().Changed("threshold") {
+			threshold, _ = cmd.Flags().GetFloat64("threshold")
+		}
+
+		tracker, query, _ := setupReport(ctx)
+		canary, err := tracker.RunCostCanary(ctx, query.Filter, marker, time.Duration(hours)*time.Hour, threshold, time.Now())
+		if err != nil {
+			errMsg := fmt.Sprintf("Error running cost canary: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error running cost canary", "error", err)
+		}
+
+		logger.Info("Displaying cost canary to console.")
+		out, done := consoleWriter()
+		displayCostCanary(out, canary)
+		done()
+		if canary.Failed {
+			sendSlackNotification(fmt.Sprintf("Cost canary failed: spend matching %q rose from %.2f to %.2f %s in the %d hours after %s.",
+				viper.GetString("filter"), canary.Before, canary.After, canary.Unit, hours, canary.Marker.Format(time.RFC3339)))
+			logger.Fatalw("Cost canary failed", "before", canary.Before, "after", canary.After, "threshold", canary.Threshold)
+		}
+	},
+}
+
+func init() {
+	viper.SetDefault("canary.threshold", 0.2)
+
+	canaryCmd.Flags().String("marker", "", "Deployment time as an RFC 3339 timestamp")
+	canaryCmd.Flags().Int("hours", 6, "Hours to compare on either side of the marker")
+	canaryCmd.Flags().Float64("threshold", 0.2, "Largest allowed relative increase, e.g. 0.2 for 20%; overrides canary.threshold")
+	rootCmd.AddCommand(canaryCmd)
+}
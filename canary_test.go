@@ -0,0 +1,95 @@
+// File: canary_test.go
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+// hourlyClient returns one service cost per hour from before before the marker and after from then on.
+func hourlyClient(t *testing.T, marker time.Time, before, after string) *mockCostExplorerClient {
+	return &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			if params.Granularity != types.GranularityHourly {
+				t.Errorf("expected hourly granularity, got %s", params.Granularity)
+			}
+			start, err := time.Parse(AWSHourFormat, aws.ToString(params.TimePeriod.Start))
+			if err != nil {
+				return nil, err
+			}
+			end, err := time.Parse(AWSHourFormat, aws.ToString(params.TimePeriod.End))
+			if err != nil {
+				return nil, err
+			}
+			output := &costexplorer.GetCostAndUsageOutput{}
+			for hour := start; hour.Before(end); hour = hour.Add(time.Hour) {
+				amount := before
+				if !hour.Before(marker) {
+					amount = after
+				}
+				output.ResultsByTime = append(output.ResultsByTime, types.ResultByTime{
+					TimePeriod: &types.DateInterval{Start: aws.String(hour.Format(AWSHourFormat)), End: aws.String(hour.Add(time.Hour).Format(AWSHourFormat))},
+					Groups: []types.Group{{
+						Keys:    []string{"Amazon Elastic Compute Cloud - Compute"},
+						Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String(amount), Unit: aws.String("USD")}},
+					}},
+				})
+			}
+			return output, nil
+		},
+	}
+}
+
+func TestRunCostCanary(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	marker := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now := marker.Add(24 * time.Hour)
+
+	tests := []struct {
+		name          string
+		before, after string
+		wantBefore    float64
+		wantAfter     float64
+		wantFailed    bool
+	}{
+		{name: "steady", before: "1.00", after: "1.10", wantBefore: 6, wantAfter: 6.6, wantFailed: false},
+		{name: "jump", before: "1.00", after: "1.50", wantBefore: 6, wantAfter: 9, wantFailed: true},
+		{name: "new spend", before: "0", after: "0.50", wantBefore: 0, wantAfter: 3, wantFailed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &CostTracker{client: hourlyClient(t, marker, tt.before, tt.after)}
+			// A marker mid-hour is truncated to the hour
+			canary, err := tracker.RunCostCanary(context.Background(), nil, marker.Add(20*time.Minute), 6*time.Hour, 0.2, now)
+			if err != nil {
+				t.Fatalf("did not expect an error, but got: %v", err)
+			}
+			if math.Abs(canary.Before-tt.wantBefore) > 1e-9 || math.Abs(canary.After-tt.wantAfter) > 1e-9 {
+				t.Errorf("got before %.2f and after %.2f, want %.2f and %.2f", canary.Before, canary.After, tt.wantBefore, tt.wantAfter)
+			}
+			if canary.Failed != tt.wantFailed {
+				t.Errorf("got failed %t (change %g), want %t", canary.Failed, canary.Change(), tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestRunCostCanaryErrors(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	marker := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracker := &CostTracker{client: hourlyClient(t, marker, "1", "1")}
+
+	if _, err := tracker.RunCostCanary(context.Background(), nil, marker, 90*time.Minute, 0.2, marker.Add(24*time.Hour)); err == nil {
+		t.Errorf("expected an error for a window that is not whole hours, but got nil")
+	}
+	if _, err := tracker.RunCostCanary(context.Background(), nil, marker, 6*time.Hour, 0.2, marker.Add(3*time.Hour)); err == nil {
+		t.Errorf("expected an error for a window ending in the future, but got nil")
+	}
+}
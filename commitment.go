@@ -0,0 +1,308 @@
+// File: commitment.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+)
+
+const (
+	RecordTypeSavingsPlanCoveredUsage = "SavingsPlanCoveredUsage" // Usage covered by a Savings Plan
+	RecordTypeDiscountedUsage         = "DiscountedUsage"         // Usage covered by a reserved instance
+)
+
+// CommitmentBenefit is what the usage one account ran on Savings Plans and reserved instances would
+// have cost on demand, and what it cost with the commitments' fees amortized over it.
+type CommitmentBenefit struct {
+	Account       string
+	OnDemand      float64 // On-demand equivalent of the covered usage
+	Effective     float64 // Amortized cost of the covered usage
+	UnpricedHours float64 // Reserved hours without an on-demand rate to price them at, left out of OnDemand
+}
+
+// Benefit returns how much less the account's covered usage cost than it would have on demand.
+func (b CommitmentBenefit) Benefit() float64 {
+	return b.OnDemand - b.Effective
+}
+
+// CommitmentReport is the result of GetCommitmentBenefits.
+type CommitmentReport struct {
+	Days     float64
+	Unit     string
+	Accounts []CommitmentBenefit // Largest benefit first
+}
+
+// Benefits returns the benefit of each account in the report.
+func (r *CommitmentReport) Benefits() map[string]float64 {
+	benefits := make(map[string]float64, len(r.Accounts))
+	for _, b := range r.Accounts {
+		benefits[b.Account] = b.Benefit()
+	}
+	return benefits
+}
+
+// GetCommitmentBenefits attributes the benefit of Savings Plans and reserved instances, wherever they
+// were bought, to the linked accounts whose usage they covered. Savings Plan covered usage carries its
+// on-demand cost as unblended cost and its Savings Plan rate as amortized cost, so its benefit is exact.
+// Reserved instance usage has no unblended cost, so its on-demand equivalent is estimated from
+// reservation coverage: the reserved hours of each instance type in an account are priced at the
+// account's on-demand rate for that type, or at the rate across all accounts if the account ran none
+// on demand. The GroupBy of q is ignored.
+func (ct *CostTracker) GetCommitmentBenefits(ctx context.Context, q CostQuery) (*CommitmentReport, error) {
+	if !q.Start.Before(q.End) {
+		return nil, fmt.Errorf("start date %s must be before end date %s", q.Start.Format(AWSDateFormat), q.End.Format(AWSDateFormat))
+	}
+	input := &costexplorer.GetCostAndUsageInput{
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(q.Start.Format(AWSDateFormat)),
+			End:   aws.String(q.End.Format(AWSDateFormat)),
+		},
+		Filter: andExpression(q.Filter, &types.Expression{Dimensions: &types.DimensionValues{
+			Key:    types.DimensionRecordType,
+			Values: []string{RecordTypeSavingsPlanCoveredUsage, RecordTypeDiscountedUsage},
+		}}),
+		Granularity: GranularityMonthly,
+		Metrics:     []string{MetricUnblendedCost, MetricAmortizedCost},
+		GroupBy: []types.GroupDefinition{
+			{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionLinkedAccount))},
+			{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionRecordType))},
+		},
+	}
+
+	report := &CommitmentReport{Days: q.End.Sub(q.Start).Hours() / 24}
+	accounts := make(map[string]*CommitmentBenefit)
+	account := func(id string) *CommitmentBenefit {
+		if accounts[id] == nil {
+			accounts[id] = &CommitmentBenefit{Account: id}
+		}
+		return accounts[id]
+	}
+	var reserved bool
+	for {
+		result, err := ct.client.GetCostAndUsage(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cost data from AWS Cost Explorer: %w", err)
+		}
+		for _, resultByTime := range result.ResultsByTime {
+			for _, group := range resultByTime.Groups {
+				if len(group.Keys) < 2 {
+					continue
+				}
+				unblended, unblendedOK := metricAmount(group.Metrics, MetricUnblendedCost)
+				amortized, amortizedOK := metricAmount(group.Metrics, MetricAmortizedCost)
+				if !unblendedOK || !amortizedOK {
+					logger.Warnw("Unblended or amortized cost not found for covered usage",
+						"account", group.Keys[0],
+						"record_type", group.Keys[1],
+						"periodStart", aws.ToString(resultByTime.TimePeriod.Start))
+					continue
+				}
+				report.Unit = aws.ToString(group.Metrics[MetricAmortizedCost].Unit)
+
+				line := account(group.Keys[0])
+				line.Effective += amortized
+				switch group.Keys[1] {
+				case RecordTypeSavingsPlanCoveredUsage:
+					line.OnDemand += unblended
+				case RecordTypeDiscountedUsage:
+					reserved = true
+				}
+			}
+		}
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			break
+		}
+		input.NextPageToken = result.NextPageToken
+	}
+
+	if reserved { // Coverage is a separate paid request, only needed to price reserved hours
+		onDemand, unpriced, err := ct.reservedOnDemandEquivalent(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for id, amount := range onDemand {
+			account(id).OnDemand += amount
+		}
+		for id, hours := range unpriced {
+			account(id).UnpricedHours += hours
+		}
+	}
+
+	for _, line := range accounts {
+		report.Accounts = append(report.Accounts, *line)
+	}
+	sort.Slice(report.Accounts, func(i, j int) bool {
+		bi, bj := report.Accounts[i].Benefit(), report.Accounts[j].Benefit()
+		if bi != bj {
+			return bi > bj
+		}
+		return report.Accounts[i].Account < report.Accounts[j].Account
+	})
+	return report, nil
+}
+
+// instanceCoverage is the reserved hours and on-demand hours and cost of one instance type.
+type instanceCoverage struct {
+	ReservedHours float64
+	OnDemandHours float64
+	OnDemandCost  float64
+}
+
+// add returns the sum of c and other.
+func (c instanceCoverage) add(other instanceCoverage) instanceCoverage {
+	return instanceCoverage{
+		ReservedHours: c.ReservedHours + other.ReservedHours,
+		OnDemandHours: c.OnDemandHours + other.OnDemandHours,
+		OnDemandCost:  c.OnDemandCost + other.OnDemandCost,
+	}
+}
+
+// rate returns the on-demand cost of an hour, or false if nothing ran on demand.
+func (c instanceCoverage) rate() (float64, bool) {
+	if c.OnDemandHours == 0 {
+		return 0, false
+	}
+	return c.OnDemandCost / c.OnDemandHours, true
+}
+
+// reservedOnDemandEquivalent prices the reserved hours of each account at on-demand rates, returning
+// the on-demand equivalent and the hours no rate was found for, by account.
+func (ct *CostTracker) reservedOnDemandEquivalent(ctx context.Context, q CostQuery) (map[string]float64, map[string]float64, error) {
+	byAccount := make(map[[2]string]instanceCoverage) // By account and instance type
+	byType := make(map[string]instanceCoverage)
+	var nextPageToken *string
+	for {
+		result, err := ct.client.GetReservationCoverage(ctx, &costexplorer.GetReservationCoverageInput{
+			TimePeriod: &types.DateInterval{
+				Start: aws.String(q.Start.Format(AWSDateFormat)),
+				End:   aws.String(q.End.Format(AWSDateFormat)),
+			},
+			Filter:      q.Filter,
+			Granularity: GranularityMonthly,
+			GroupBy: []types.GroupDefinition{
+				{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionLinkedAccount))},
+				{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionInstanceType))},
+			},
+			NextPageToken: nextPageToken,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get reservation coverage from AWS Cost Explorer: %w", err)
+		}
+		for _, coverageByTime := range result.CoveragesByTime {
+			for _, group := range coverageByTime.Groups {
+				if group.Coverage == nil || group.Coverage.CoverageHours == nil {
+					continue
+				}
+				// Attribute names vary by service, so the account is told apart by its format.
+				account, instanceType := UnknownLabel, UnknownLabel
+				for _, value := range group.Attributes {
+					if isAccountID(value) {
+						account = value
+					} else if value != "" {
+						instanceType = value
+					}
+				}
+				c := instanceCoverage{
+					ReservedHours: parseHours(group.Coverage.CoverageHours.ReservedHours),
+					OnDemandHours: parseHours(group.Coverage.CoverageHours.OnDemandHours),
+				}
+				if group.Coverage.CoverageCost != nil {
+					c.OnDemandCost = parseHours(group.Coverage.CoverageCost.OnDemandCost)
+				}
+				key := [2]string{account, instanceType}
+				byAccount[key] = byAccount[key].add(c)
+				byType[instanceType] = byType[instanceType].add(c)
+			}
+		}
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			break
+		}
+		nextPageToken = result.NextPageToken
+	}
+
+	onDemand := make(map[string]float64)
+	unpriced := make(map[string]float64)
+	for key, c := range byAccount {
+		if c.ReservedHours == 0 {
+			continue
+		}
+		rate, ok := c.rate()
+		if !ok {
+			rate, ok = byType[key[1]].rate()
+		}
+		if !ok {
+			unpriced[key[0]] += c.ReservedHours
+			continue
+		}
+		onDemand[key[0]] += c.ReservedHours * rate
+	}
+	return onDemand, unpriced, nil
+}
+
+// displayCommitmentReport writes the benefit of each account, largest first.
+func displayCommitmentReport(w io.Writer, report *CommitmentReport) {
+	fmt.Fprintf(w, "Commitment benefit by account for the last %.0f days (%s):\n", report.Days, report.Unit)
+	fmt.Fprintln(w, "=====================================")
+	if len(report.Accounts) == 0 {
+		fmt.Fprintln(w, "No usage covered by Savings Plans or reserved instances found.")
+		return
+	}
+
+	fmt.Fprintf(w, "%-30s %14s %14s %14s\n", "Account", "On-demand", "Effective", "Benefit")
+	var onDemand, effective, unpriced float64
+	for _, b := range report.Accounts {
+		fmt.Fprintf(w, "%-30s %14.2f %14.2f %14.2f\n", truncateName(b.Account, 30), b.OnDemand, b.Effective, b.Benefit())
+		onDemand += b.OnDemand
+		effective += b.Effective
+		unpriced += b.UnpricedHours
+	}
+	fmt.Fprintf(w, "%-30s %14.2f %14.2f %14.2f\n", "Total", onDemand, effective, onDemand-effective)
+	fmt.Fprintln(w, "On-demand is what the covered usage would have cost without commitments; reserved instance usage is")
+	fmt.Fprintln(w, "priced at the on-demand rates the same instance types ran at. Effective is its amortized cost.")
+	if math.Round(unpriced) > 0 {
+		fmt.Fprintf(w, "%.0f reserved hours of instance types that never ran on demand could not be priced; their effective cost is\n", unpriced)
+		fmt.Fprintln(w, "still counted, so the benefit is understated.")
+	}
+}
+
+var commitmentsCmd = &cobra.Command{
+	Use:   "commitments",
+	Short: "Attribute the benefit of Savings Plans and reserved instances to the accounts that used them.",
+	Long: `Compares what the usage covered by Savings Plans and reserved instances would have cost on demand with its
+amortized cost, per linked account, so the saving of centrally purchased commitments is credited to the accounts
+whose usage consumed them rather than to the account that bought them. Reserved instance usage is priced at the
+on-demand rates of the same instance types, from reservation coverage, which covers EC2 unless --filter selects
+another service. margin --commitment-benefit adds the same benefit to each reseller customer:
+
+  cost-tracker commitments --period last-month`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		tracker, query, _ := setupReport(ctx)
+		report, err := tracker.GetCommitmentBenefits(ctx, query)
+		if err != nil {
+			errMsg := fmt.Sprintf("Error attributing commitment benefits: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error attributing commitment benefits", "error", err)
+		}
+
+		logger.Info("Displaying commitment benefits to console.")
+		out, done := consoleWriter()
+		displayCommitmentReport(out, report)
+		done()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(commitmentsCmd)
+}
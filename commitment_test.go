@@ -0,0 +1,119 @@
+// File: commitment_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGetCommitmentBenefits(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	group := func(account, recordType, unblended, amortized string) types.Group {
+		return types.Group{Keys: []string{account, recordType}, Metrics: map[string]types.MetricValue{
+			MetricUnblendedCost: {Amount: aws.String(unblended), Unit: aws.String("USD")},
+			MetricAmortizedCost: {Amount: aws.String(amortized), Unit: aws.String("USD")},
+		}}
+	}
+	coverage := func(account, instanceType, reserved, onDemandHours, onDemandCost string) types.ReservationCoverageGroup {
+		return types.ReservationCoverageGroup{
+			Attributes: map[string]string{"linkedAccount": account, "instanceType": instanceType},
+			Coverage: &types.Coverage{
+				CoverageHours: &types.CoverageHours{ReservedHours: aws.String(reserved), OnDemandHours: aws.String(onDemandHours)},
+				CoverageCost:  &types.CoverageCost{OnDemandCost: aws.String(onDemandCost)},
+			},
+		}
+	}
+	mockClient := &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			if params.Filter == nil || params.Filter.Dimensions == nil || params.Filter.Dimensions.Key != types.DimensionRecordType {
+				t.Errorf("expected a filter on covered record types, got %+v", params.Filter)
+			}
+			return &costexplorer.GetCostAndUsageOutput{
+				ResultsByTime: []types.ResultByTime{{
+					TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-01-31")},
+					Groups: []types.Group{
+						group("111111111111", RecordTypeSavingsPlanCoveredUsage, "100", "70"),
+						group("222222222222", RecordTypeDiscountedUsage, "0", "30"),
+						group("333333333333", RecordTypeDiscountedUsage, "0", "12"),
+					},
+				}},
+			}, nil
+		},
+		GetReservationCoverageFunc: func(ctx context.Context, params *costexplorer.GetReservationCoverageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error) {
+			return &costexplorer.GetReservationCoverageOutput{
+				CoveragesByTime: []types.CoverageByTime{{Groups: []types.ReservationCoverageGroup{
+					// 222222222222 ran m5.large at 0.1 an hour on demand; 333333333333 ran none on demand,
+					// so its reserved m5.large hours are priced at the rate across accounts, and its
+					// c5.large hours at none.
+					coverage("222222222222", "m5.large", "400", "100", "10"),
+					coverage("444444444444", "m5.large", "0", "100", "20"),
+					coverage("333333333333", "m5.large", "100", "0", "0"),
+					coverage("333333333333", "c5.large", "50", "0", "0"),
+				}}},
+			}, nil
+		},
+	}
+	tracker := &CostTracker{client: mockClient}
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	report, err := tracker.GetCommitmentBenefits(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	benefits := report.Benefits()
+	if len(report.Accounts) != 3 || report.Accounts[0].Account != "111111111111" {
+		t.Fatalf("expected three accounts, 111111111111 with the largest benefit first: %+v", report.Accounts)
+	}
+	if benefits["111111111111"] != 30 {
+		t.Errorf("expected a Savings Plan benefit of 30, got %.2f", benefits["111111111111"])
+	}
+	if math.Abs(benefits["222222222222"]-10) > 1e-9 {
+		t.Errorf("expected 400 reserved hours at 0.1 less 30 to be a benefit of 10, got %.2f", benefits["222222222222"])
+	}
+	if b := report.Accounts[2]; math.Abs(b.OnDemand-15) > 1e-9 || b.UnpricedHours != 50 {
+		t.Errorf("expected 100 hours at the overall rate of 0.15 and 50 unpriced hours, got %+v", b)
+	}
+
+	var buf bytes.Buffer
+	displayCommitmentReport(&buf, report)
+	if !strings.Contains(buf.String(), "50 reserved hours") {
+		t.Errorf("expected the unpriced hours to be reported, got:\n%s", buf.String())
+	}
+}
+
+func TestGetCommitmentBenefitsWithoutReservations(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			return &costexplorer.GetCostAndUsageOutput{}, nil
+		},
+	}}
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Without reserved instance usage, coverage is not requested, which the mock would fail.
+	report, err := tracker.GetCommitmentBenefits(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	var buf bytes.Buffer
+	displayCommitmentReport(&buf, report)
+	if !strings.Contains(buf.String(), "No usage covered") {
+		t.Errorf("expected an empty report, got:\n%s", buf.String())
+	}
+}
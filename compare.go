@@ -0,0 +1,257 @@
+// File: compare.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Scope is a named slice of spend, such as one account or one environment, written "key:value".
+// The key is any --filter key, including tag:<name>, so "tag:env:staging" selects env=staging.
+type Scope struct {
+	Name   string
+	Filter *types.Expression
+}
+
+// ParseScope parses a "key:value" scope. The value is split off at the last colon.
+func ParseScope(input string) (Scope, error) {
+	i := strings.LastIndex(input, ":")
+	if i <= 0 || i == len(input)-1 {
+		return Scope{}, fmt.Errorf("invalid scope %q: expected key:value, e.g. account:123456789012 or tag:env:prod", input)
+	}
+	expr, err := comparisonExpression(filterToken{kind: tokenIdent, text: input[:i], column: 1}, []string{input[i+1:]})
+	if err != nil {
+		return Scope{}, fmt.Errorf("invalid scope %q: %w", input, err)
+	}
+	return Scope{Name: input, Filter: expr}, nil
+}
+
+// isAccountID reports whether value looks like a 12-digit AWS account ID.
+func isAccountID(value string) bool {
+	if len(value) != 12 {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveAccountNames replaces account names in a LINKED_ACCOUNT scope with their account IDs,
+// using the account descriptions Cost Explorer returns for the query range.
+func (ct *CostTracker) resolveAccountNames(ctx context.Context, q CostQuery, scope Scope) (Scope, error) {
+	dims := scope.Filter.Dimensions
+	if dims == nil || dims.Key != types.DimensionLinkedAccount || isAccountID(dims.Values[0]) {
+		return scope, nil
+	}
+
+	name := dims.Values[0]
+	var nextPageToken *string
+	for {
+		result, err := ct.client.GetDimensionValues(ctx, &costexplorer.GetDimensionValuesInput{
+			TimePeriod: &types.DateInterval{
+				Start: aws.String(q.Start.Format(AWSDateFormat)),
+				End:   aws.String(q.End.Format(AWSDateFormat)),
+			},
+			Dimension:     types.DimensionLinkedAccount,
+			NextPageToken: nextPageToken,
+		})
+		if err != nil {
+			return Scope{}, fmt.Errorf("failed to list accounts from AWS Cost Explorer: %w", err)
+		}
+		for _, value := range result.DimensionValues {
+			if strings.EqualFold(value.Attributes["description"], name) {
+				return Scope{Name: scope.Name, Filter: &types.Expression{
+					Dimensions: &types.DimensionValues{Key: types.DimensionLinkedAccount, Values: []string{aws.ToString(value.Value)}},
+				}}, nil
+			}
+		}
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			break
+		}
+		nextPageToken = result.NextPageToken
+	}
+	return Scope{}, fmt.Errorf("no account named %q has cost data in the query range", name)
+}
+
+// ServiceComparison is one service's cost and share of spend in both scopes.
+type ServiceComparison struct {
+	Service            string
+	BaseCost, CompCost float64
+	BaseShare          float64 // Fraction of the base scope's total, 0-1
+	CompShare          float64 // Fraction of the compared scope's total, 0-1
+	Flagged            bool    // The compared scope spends disproportionately more on this service
+}
+
+// ShareRatio returns how many times larger the service's share is in the compared scope, or +Inf if
+// the base scope does not use it at all.
+func (c ServiceComparison) ShareRatio() float64 {
+	if c.BaseShare == 0 {
+		return math.Inf(1)
+	}
+	return c.CompShare / c.BaseShare
+}
+
+// ScopeComparison is the result of CompareScopes.
+type ScopeComparison struct {
+	Base, Compared       Scope
+	BaseTotal, CompTotal float64
+	Unit                 string
+	Days                 float64
+	FlagRatio            float64
+	Services             []ServiceComparison
+}
+
+// CompareScopes normalizes the per-service spend of two scopes to shares of their own totals, so cost
+// structure can be compared regardless of size. A service is flagged when its share in compared is at
+// least flagRatio times its share in base and it is at least minShare of compared's spend.
+func (ct *CostTracker) CompareScopes(ctx context.Context, q CostQuery, base, compared Scope, flagRatio, minShare float64) (*ScopeComparison, error) {
+	totals := make([]map[string]float64, 2)
+	result := &ScopeComparison{FlagRatio: flagRatio, Days: q.End.Sub(q.Start).Hours() / 24}
+	for i, scope := range []Scope{base, compared} {
+		scope, err := ct.resolveAccountNames(ctx, q, scope)
+		if err != nil {
+			return nil, err
+		}
+		scoped := q
+		scoped.Filter = andExpression(q.Filter, scope.Filter)
+		costs, err := ct.GetCosts(ctx, scoped)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get costs for scope %s: %w", scope.Name, err)
+		}
+		var unit string
+		totals[i], unit = totalsByService(costs)
+		if unit != "" {
+			result.Unit = unit
+		}
+		if i == 0 {
+			result.Base = scope
+		} else {
+			result.Compared = scope
+		}
+	}
+
+	for _, amount := range totals[0] {
+		result.BaseTotal += amount
+	}
+	for _, amount := range totals[1] {
+		result.CompTotal += amount
+	}
+
+	services := make(map[string]bool)
+	for service := range totals[0] {
+		services[service] = true
+	}
+	for service := range totals[1] {
+		services[service] = true
+	}
+	for service := range services {
+		c := ServiceComparison{Service: service, BaseCost: totals[0][service], CompCost: totals[1][service]}
+		if result.BaseTotal > 0 {
+			c.BaseShare = c.BaseCost / result.BaseTotal
+		}
+		if result.CompTotal > 0 {
+			c.CompShare = c.CompCost / result.CompTotal
+		}
+		c.Flagged = c.CompShare >= minShare && c.ShareRatio() >= flagRatio
+		result.Services = append(result.Services, c)
+	}
+	sort.Slice(result.Services, func(i, j int) bool {
+		a, b := result.Services[i], result.Services[j]
+		if a.CompShare != b.CompShare {
+			return a.CompShare > b.CompShare
+		}
+		return a.Service < b.Service
+	})
+	return result, nil
+}
+
+// displayScopeComparison writes the per-service comparison to w, marking flagged services with "!".
+func displayScopeComparison(w io.Writer, c *ScopeComparison) {
+	fmt.Fprintf(w, "Cost structure of %s compared to %s for the last %.0f days:\n", c.Compared.Name, c.Base.Name, c.Days)
+	fmt.Fprintln(w, "=====================================")
+	if len(c.Services) == 0 {
+		fmt.Fprintln(w, "No costs found for either scope in the specified period.")
+		return
+	}
+
+	fmt.Fprintf(w, "  %-40s %14s %8s %14s %8s %8s\n", "Service", "Base cost", "Share", "Compared cost", "Share", "Ratio")
+	shown, hidden := rowLimit(len(c.Services))
+	for _, s := range c.Services[:shown] {
+		marker := " "
+		if s.Flagged {
+			marker = "!"
+		}
+		ratio := "new"
+		if !math.IsInf(s.ShareRatio(), 1) {
+			ratio = fmt.Sprintf("%.2fx", s.ShareRatio())
+		}
+		fmt.Fprintf(w, "%s %-40s %14.2f %7.1f%% %14.2f %7.1f%% %8s\n",
+			marker, truncateName(s.Service, 40), s.BaseCost, s.BaseShare*100, s.CompCost, s.CompShare*100, ratio)
+	}
+	writeHiddenRows(w, hidden)
+	fmt.Fprintf(w, "  %-40s %14.2f %8s %14.2f\n", "Total", c.BaseTotal, "", c.CompTotal)
+	fmt.Fprintf(w, "Amounts in %s. Ratio is the compared share divided by the base share; ! marks ratios of %.1fx or more.\n", c.Unit, c.FlagRatio)
+}
+
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare the cost structure of two accounts or environments.",
+	Long: `Normalizes the per-service spend of two scopes to shares of their own totals and flags services where the
+second scope spends disproportionately more than the first. Scopes are key:value, where key is any --filter key:
+
+  cost-tracker compare --scope account:prod --scope account:staging
+  cost-tracker compare --scope tag:env:prod --scope tag:env:staging`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		scopeArgs, _ := cmd.Flags().GetStringArray("scope")
+		if len(scopeArgs) != 2 {
+			logger.Fatalw("compare needs exactly two --scope flags", "scopes", scopeArgs)
+		}
+		var scopes []Scope
+		for _, arg := range scopeArgs {
+			scope, err := ParseScope(arg)
+			if err != nil {
+				logger.Fatalw("Invalid scope", "error", err)
+			}
+			scopes = append(scopes, scope)
+		}
+
+		tracker, query, _ := setupReport(ctx)
+		comparison, err := tracker.CompareScopes(ctx, query, scopes[0], scopes[1],
+			viper.GetFloat64("compare.flag_ratio"), viper.GetFloat64("compare.min_share"))
+		if err != nil {
+			errMsg := fmt.Sprintf("Error comparing scopes: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error comparing scopes", "error", err)
+		}
+
+		logger.Info("Displaying scope comparison to console.")
+		out, done := consoleWriter()
+		displayScopeComparison(out, comparison)
+		done()
+	},
+}
+
+func init() {
+	viper.SetDefault("compare.flag_ratio", 1.5)
+	viper.SetDefault("compare.min_share", 0.01)
+
+	compareCmd.Flags().StringArray("scope", nil, "Scope to compare as key:value; give exactly two, the base scope first")
+	rootCmd.AddCommand(compareCmd)
+}
@@ -0,0 +1,152 @@
+// File: compare_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestParseScope(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedKey   string
+		expectedValue string
+		expectedError bool
+	}{
+		{input: "account:123456789012", expectedKey: string(types.DimensionLinkedAccount), expectedValue: "123456789012"},
+		{input: "region:eu-west-1", expectedKey: string(types.DimensionRegion), expectedValue: "eu-west-1"},
+		{input: "tag:env:staging", expectedKey: "tag:env", expectedValue: "staging"},
+		{input: "account", expectedError: true},
+		{input: "account:", expectedError: true},
+		{input: "colour:blue", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			scope, err := ParseScope(tc.input)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("expected an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error, but got: %v", err)
+			}
+			var key string
+			var values []string
+			switch {
+			case scope.Filter.Dimensions != nil:
+				key, values = string(scope.Filter.Dimensions.Key), scope.Filter.Dimensions.Values
+			case scope.Filter.Tags != nil:
+				key, values = "tag:"+aws.ToString(scope.Filter.Tags.Key), scope.Filter.Tags.Values
+			}
+			if key != tc.expectedKey || len(values) != 1 || values[0] != tc.expectedValue {
+				t.Errorf("expected %s = %s, got %s = %v", tc.expectedKey, tc.expectedValue, key, values)
+			}
+		})
+	}
+}
+
+// serviceOutput builds a single-period GetCostAndUsage response with the given service costs.
+func serviceOutput(costs map[string]string) *costexplorer.GetCostAndUsageOutput {
+	var groups []types.Group
+	for service, amount := range costs {
+		groups = append(groups, types.Group{
+			Keys:    []string{service},
+			Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String(amount), Unit: aws.String("USD")}},
+		})
+	}
+	return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{{
+		TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-01-15")},
+		Groups:     groups,
+	}}}
+}
+
+func TestCompareScopes(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	mockClient := &mockCostExplorerClient{
+		GetDimensionValuesFunc: func(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+			return &costexplorer.GetDimensionValuesOutput{DimensionValues: []types.DimensionValuesWithAttributes{
+				{Value: aws.String("111111111111"), Attributes: map[string]string{"description": "prod"}},
+				{Value: aws.String("222222222222"), Attributes: map[string]string{"description": "staging"}},
+			}}, nil
+		},
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			switch params.Filter.Dimensions.Values[0] {
+			case "111111111111":
+				return serviceOutput(map[string]string{"Amazon EC2": "800", "Amazon RDS": "150", "AWS Lambda": "50"}), nil
+			case "222222222222":
+				return serviceOutput(map[string]string{"Amazon EC2": "60", "Amazon RDS": "30", "Amazon OpenSearch": "10"}), nil
+			}
+			t.Errorf("unexpected scope filter %+v", params.Filter)
+			return serviceOutput(nil), nil
+		},
+	}
+	tracker := &CostTracker{client: mockClient}
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+	prod, _ := ParseScope("account:prod")
+	staging, _ := ParseScope("account:staging")
+
+	comparison, err := tracker.CompareScopes(context.Background(), q, prod, staging, 1.5, 0.01)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if comparison.BaseTotal != 1000 || comparison.CompTotal != 100 {
+		t.Errorf("expected totals 1000 and 100, got %.2f and %.2f", comparison.BaseTotal, comparison.CompTotal)
+	}
+
+	flagged := make(map[string]bool)
+	for _, s := range comparison.Services {
+		flagged[s.Service] = s.Flagged
+	}
+	// EC2 is 80% of prod and 60% of staging; RDS is 15% and 30%; OpenSearch only runs in staging.
+	expected := map[string]bool{"Amazon EC2": false, "Amazon RDS": true, "Amazon OpenSearch": true, "AWS Lambda": false}
+	for service, want := range expected {
+		if flagged[service] != want {
+			t.Errorf("expected %s flagged=%t, got %t", service, want, flagged[service])
+		}
+	}
+	if comparison.Services[0].Service != "Amazon EC2" || math.Abs(comparison.Services[1].ShareRatio()-2) > 1e-9 {
+		t.Errorf("unexpected ordering or ratio: %+v", comparison.Services)
+	}
+
+	var buf bytes.Buffer
+	displayScopeComparison(&buf, comparison)
+	if !strings.Contains(buf.String(), "! Amazon RDS") || !strings.Contains(buf.String(), "new") {
+		t.Errorf("expected flagged RDS and a new OpenSearch line, got:\n%s", buf.String())
+	}
+}
+
+func TestCompareScopesUnknownAccountName(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	mockClient := &mockCostExplorerClient{
+		GetDimensionValuesFunc: func(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+			return &costexplorer.GetDimensionValuesOutput{}, nil
+		},
+	}
+	tracker := &CostTracker{client: mockClient}
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+	prod, _ := ParseScope("account:prod")
+	staging, _ := ParseScope("account:staging")
+	if _, err := tracker.CompareScopes(context.Background(), q, prod, staging, 1.5, 0.01); err == nil {
+		t.Errorf("expected an error for an unknown account name, but got nil")
+	}
+}
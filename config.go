@@ -0,0 +1,336 @@
+// File: config.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is prepended to every configuration key to form its environment variable, e.g.
+// COSTTRACKER_SLACK_WEBHOOK_URL for slack.webhook_url.
+const EnvPrefix = "COSTTRACKER"
+
+// ConfigSchemaVersion is the highest config schema_version this build reads. A config sets schema_version
+// when it relies on settings added in that version, so older builds warn instead of silently ignoring them.
+const ConfigSchemaVersion = 1
+
+// envName returns the environment variable that sets a configuration key.
+func envName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// envJSON returns the value of key if it is a JSON list or object given as a string, which is how
+// structured settings are passed through environment variables.
+func envJSON(key string) (string, bool) {
+	s, ok := viper.Get(key).(string)
+	s = strings.TrimSpace(s)
+	return s, ok && (strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{"))
+}
+
+// unmarshalConfigKey is viper.UnmarshalKey that also accepts a JSON list or object from the environment.
+func unmarshalConfigKey(key string, out interface{}) error {
+	s, ok := envJSON(key)
+	if !ok {
+		return viper.UnmarshalKey(key, out)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(s), &value); err != nil {
+		return fmt.Errorf("%s is not valid JSON: %w", envName(key), err)
+	}
+	// Decode through Viper so mapstructure tags and weak typing apply as they do to the config file
+	v := viper.New()
+	v.Set(key, value)
+	return v.UnmarshalKey(key, out)
+}
+
+// configStringSlice is viper.GetStringSlice that also accepts a JSON list from the environment.
+// Plain strings are split on whitespace, as Viper does.
+func configStringSlice(key string) []string {
+	var values []string
+	if s, ok := envJSON(key); ok && json.Unmarshal([]byte(s), &values) == nil {
+		return values
+	}
+	return viper.GetStringSlice(key)
+}
+
+// configFileType returns the Viper config type for a file path, defaulting to JSON.
+func configFileType(path string) string {
+	if ext := strings.TrimPrefix(filepath.Ext(path), "."); ext != "" {
+		if ext == "yml" {
+			return "yaml"
+		}
+		return ext
+	}
+	return "json"
+}
+
+// structuredConfigKeys are the settings read as a whole list or object, with unmarshalConfigKey or as
+// raw JSON. Viper flattens the objects in them into one key per field, which it does not read back from
+// the environment, so each is passed as a single JSON variable instead.
+var structuredConfigKeys = []string{
+	"anomalies.monitors",
+	"aws.session_tags",
+	"budgets",
+	"currency",
+	"filter_json",
+	"mock.anomalies",
+	"mock.services",
+	"mock.tags",
+	"payers",
+	"redaction.profiles",
+	"reseller.customers",
+	"saas.subscriptions",
+}
+
+// envKey returns the key whose environment variable sets key: the structured setting key is part of,
+// or key itself.
+func envKey(key string) string {
+	for _, structured := range structuredConfigKeys {
+		if key == structured || strings.HasPrefix(key, structured+".") {
+			return structured
+		}
+	}
+	return key
+}
+
+// renderEnv writes the environment variables equivalent to the config file at path, one KEY=value per
+// line in key order. Lists and objects are written as JSON, and structured settings as one JSON
+// variable each.
+func renderEnv(w io.Writer, path string) error {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType(configFileType(path))
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	var keys []string
+	for _, key := range v.AllKeys() {
+		if key = envKey(key); !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var value string
+		switch raw := v.Get(key).(type) {
+		case nil:
+			continue
+		case string:
+			value = raw
+		case bool, int, int64, float64:
+			value = fmt.Sprint(raw)
+		default:
+			encoded, err := json.Marshal(raw)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s: %w", key, err)
+			}
+			value = string(encoded)
+		}
+		if strings.ContainsAny(value, "\n") {
+			return fmt.Errorf("%s contains a newline, which cannot be written as KEY=value", key)
+		}
+		fmt.Fprintf(w, "%s=%s\n", envName(key), value)
+	}
+	return nil
+}
+
+// roleARNPattern matches IAM role ARNs in any partition.
+var roleARNPattern = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:role/.+`)
+
+// ConfigWarning is a likely misconfiguration found by lintConfig, with a suggested fix.
+type ConfigWarning struct {
+	Key     string
+	Problem string // A sentence naming the key
+	Fix     string
+}
+
+// lintConfig checks the loaded configuration for settings that are valid but probably wrong, or that
+// would only fail later, e.g. when the first notification is sent.
+func lintConfig() []ConfigWarning {
+	var warnings []ConfigWarning
+	warn := func(key, problem, fix string) {
+		warnings = append(warnings, ConfigWarning{Key: key, Problem: problem, Fix: fix})
+	}
+	fileMissing := func(key string) {
+		if path := viper.GetString(key); path != "" {
+			if _, err := os.Stat(path); err != nil {
+				warn(key, fmt.Sprintf("%s %s cannot be read: %v", key, path, err), "check the path, which is relative to the working directory")
+			}
+		}
+	}
+
+	if v := viper.GetInt("schema_version"); v > ConfigSchemaVersion {
+		warn("schema_version", fmt.Sprintf("schema_version is %d, but this build only reads up to %d, so newer settings are ignored", v, ConfigSchemaVersion),
+			"upgrade cost-tracker; 'cost-tracker version' shows the supported schema version")
+	}
+	if webhookURL := viper.GetString("slack.webhook_url"); webhookURL != "" {
+		if u, err := url.Parse(webhookURL); err != nil || u.Scheme != "https" || u.Host != "hooks.slack.com" {
+			warn("slack.webhook_url", "slack.webhook_url is not a Slack incoming webhook URL",
+				"copy the https://hooks.slack.com/services/... URL from the Incoming Webhooks page of your Slack app")
+		}
+	}
+	if days := viper.GetInt("days"); days <= 0 {
+		warn("days", fmt.Sprintf("days is %d, so reports without --period will fail", days), "set a positive number of days, e.g. 30")
+	}
+	if _, err := FiscalCalendarFromViper(); err != nil {
+		warn("fiscal", err.Error(), "use a start_month of 1-12 and a pattern such as 4-4-5")
+	}
+	if _, err := rawFilterFromConfig(); err != nil {
+		warn("filter_json", fmt.Sprintf("filter_json is not a valid Cost Explorer expression: %v", err),
+			`use the AWS CLI's form, e.g. {"Dimensions": {"Key": "SERVICE", "Values": ["Amazon Simple Storage Service"]}}`)
+	}
+
+	if proxy := viper.GetString("http.proxy_url"); proxy != "" {
+		if u, err := url.Parse(proxy); err != nil || u.Host == "" {
+			warn("http.proxy_url", fmt.Sprintf("http.proxy_url %q has no host", proxy), "include the scheme, e.g. http://proxy.example.com:3128")
+		}
+	}
+	fileMissing("http.ca_bundle")
+	fileMissing("bundle.signing_key")
+	fileMissing("bundle.public_key")
+
+	if role := viper.GetString("aws.role_arn"); role != "" && !roleARNPattern.MatchString(role) {
+		warn("aws.role_arn", fmt.Sprintf("aws.role_arn %q is not an IAM role ARN", role), "use the form arn:aws:iam::123456789012:role/Name")
+	}
+	if payers, err := PayersFromViper(); err != nil {
+		warn("payers", err.Error(), `give a list of {"name": ..., "role_arn": ...} objects with unique names`)
+	} else {
+		for _, payer := range payers {
+			if !roleARNPattern.MatchString(payer.RoleARN) {
+				warn("payers", fmt.Sprintf("payer %s has role_arn %q, which is not an IAM role ARN", payer.Name, payer.RoleARN), "use the form arn:aws:iam::123456789012:role/Name")
+			}
+		}
+	}
+	if role, err := AssumeRoleConfigFromViper(); err != nil {
+		warn("aws.session_tags", err.Error(), `give a list of {"key": ..., "value": ...} objects`)
+	} else {
+		seen := make(map[string]bool)
+		for _, tag := range role.Tags {
+			if tag.Key == "" {
+				warn("aws.session_tags", "aws.session_tags contains a tag without a key", "remove the tag or give it a key")
+			}
+			if seen[strings.ToLower(tag.Key)] {
+				warn("aws.session_tags", fmt.Sprintf("aws.session_tags sets %q more than once; STS compares tag keys case-insensitively and rejects the request", tag.Key), "keep one tag per key")
+			}
+			seen[strings.ToLower(tag.Key)] = true
+		}
+	}
+
+	var profiles map[string]RedactionProfile
+	if err := unmarshalConfigKey("redaction.profiles", &profiles); err != nil {
+		warn("redaction.profiles", fmt.Sprintf("redaction.profiles is invalid: %v", err), "give a map of profile names to {accounts, tag_values, round_to}")
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if profiles[name].hashes() && viper.GetString("redaction.salt") == "" {
+			warn("redaction.salt", fmt.Sprintf("redaction.salt is empty, so the %s profile, which hashes account IDs or tag values, cannot be used", name),
+				"set redaction.salt to a long random string and keep it private")
+		}
+	}
+
+	if ratio := viper.GetFloat64("compare.flag_ratio"); ratio <= 1 {
+		warn("compare.flag_ratio", fmt.Sprintf("compare.flag_ratio is %.2f, so compare flags services that are not over-represented", ratio), "use a ratio above 1, e.g. 1.5")
+	}
+	if threshold := viper.GetFloat64("canary.threshold"); threshold < 0 {
+		warn("canary.threshold", fmt.Sprintf("canary.threshold is %.2f, so the canary fails even when spend falls", threshold), "use a positive increase, e.g. 0.2 for 20%")
+	}
+	if p := viper.GetFloat64("burn.alert_probability"); p <= 0 || p > 1 {
+		warn("burn.alert_probability", fmt.Sprintf("burn.alert_probability is %.2f, so burn alerts on every budget or none", p), "use a probability between 0 and 1, e.g. 0.5")
+	}
+	if _, err := ResellerCustomersFromViper(); err != nil {
+		warn("reseller.customers", err.Error(), "give a list of {name, accounts, markup} objects, with each account under one customer")
+	}
+	if _, err := SubscriptionsFromViper(); err != nil {
+		warn("saas", err.Error(), "give each subscription a name, non-negative amounts, seats for active_users and renews as YYYY-MM-DD")
+	}
+	if _, err := CurrencyConversionFromViper(); err != nil {
+		warn("currency", err.Error(), `set a target such as "USD" and positive rates, e.g. {"target": "USD", "rates": {"EUR": 1.08}}`)
+	}
+	if _, err := BudgetsFromViper(); err != nil {
+		warn("budgets", err.Error(), "give a list of {name, filter, monthly, comments} objects with filters in --filter syntax")
+	}
+	if p := viper.GetFloat64("budget.alert_percent"); p <= 0 {
+		warn("budget.alert_percent", fmt.Sprintf("budget.alert_percent is %.0f, so budget status alerts on every budget", p), "use a percentage of the budget, e.g. 80")
+	}
+	if _, err := AnomalyMonitorsFromViper(); err != nil {
+		warn("anomalies.monitors", err.Error(), "give a list of {name, dimension or filter, subscription} objects; see Cost Anomalies in the README")
+	}
+	if err := OffHoursConfigFromViper().Validate(); err != nil {
+		warn("offhours", err.Error(), "see Off-Hours Savings in the README")
+	}
+	return warnings
+}
+
+// warnConfig logs every lintConfig warning. It runs before each command.
+func warnConfig(cmd *cobra.Command, args []string) {
+	for _, w := range lintConfig() {
+		logger.Warnw("Configuration problem: "+w.Problem, "key", w.Key, "fix", w.Fix)
+	}
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and convert configuration.",
+}
+
+var configRenderEnvCmd = &cobra.Command{
+	Use:   "render-env [file]",
+	Short: "Print the environment variables equivalent to a config file.",
+	Long: `Converts a config file (default: the one in use) into KEY=value lines, suitable for an env file or a
+Kubernetes ConfigMap, so the tool can run configured by environment variables alone. Lists and objects are
+written as JSON, which is also how to set them by hand.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := viper.ConfigFileUsed()
+		if len(args) == 1 {
+			path = args[0]
+		}
+		if path == "" {
+			logger.Fatal("No configuration file found. Pass the file to convert, e.g. 'config render-env cost-tracker-config.json'.")
+		}
+		if err := renderEnv(cmd.OutOrStdout(), path); err != nil {
+			logger.Fatalw("Failed to render environment", "error", err)
+		}
+	},
+}
+
+var configLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check the configuration for likely mistakes.",
+	Long:  `Prints the configuration problems that are otherwise logged as warnings before every command, with a suggested fix for each, and exits non-zero if there are any.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		warnings := lintConfig()
+		for _, w := range warnings {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\n  fix: %s\n", w.Problem, w.Fix)
+		}
+		if len(warnings) > 0 {
+			logger.Fatalw("Configuration has problems", "count", len(warnings))
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "No configuration problems found.")
+	},
+}
+
+func init() {
+	viper.SetDefault("schema_version", ConfigSchemaVersion) // Config schema the settings are written for
+
+	configCmd.AddCommand(configLintCmd)
+	configCmd.AddCommand(configRenderEnvCmd)
+	rootCmd.AddCommand(configCmd)
+}
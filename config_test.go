@@ -0,0 +1,175 @@
+// File: config_test.go
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestRenderEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cost-tracker.yaml")
+	config := `days: 15
+per_region: true
+slack:
+  webhook_url: https://hooks.slack.com/services/T000/B000/XXXX
+mock:
+  regions: [us-east-1, eu-west-1]
+aws:
+  session_tags:
+    - key: tool
+      value: cost-tracker
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := renderEnv(&out, path); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	want := `COSTTRACKER_AWS_SESSION_TAGS=[{"key":"tool","value":"cost-tracker"}]
+COSTTRACKER_DAYS=15
+COSTTRACKER_MOCK_REGIONS=["us-east-1","eu-west-1"]
+COSTTRACKER_PER_REGION=true
+COSTTRACKER_SLACK_WEBHOOK_URL=https://hooks.slack.com/services/T000/B000/XXXX
+`
+	if out.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestRenderEnvRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cost-tracker.json")
+	config := `{
+  "currency": {"target": "USD", "rates": {"EUR": 1.08}},
+  "redaction": {"salt": "s3cret", "profiles": {"partner": {"accounts": "hash", "round_to": 10}}}
+}`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := renderEnv(&out, path); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		name, value, _ := strings.Cut(line, "=")
+		if strings.HasPrefix(name, "COSTTRACKER_CURRENCY_") || strings.HasPrefix(name, "COSTTRACKER_REDACTION_PROFILES_") {
+			t.Errorf("expected structured settings as one variable, got %s", line)
+		}
+		t.Setenv(name, value)
+	}
+
+	currency, err := CurrencyConversionFromViper()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if want := (CurrencyConversion{Target: "USD", Rates: map[string]float64{"EUR": 1.08}}); !reflect.DeepEqual(currency, want) {
+		t.Errorf("got currency %+v, want %+v", currency, want)
+	}
+	var profiles map[string]RedactionProfile
+	if err := unmarshalConfigKey("redaction.profiles", &profiles); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if want := map[string]RedactionProfile{"partner": {Accounts: RedactHash, RoundTo: 10}}; !reflect.DeepEqual(profiles, want) {
+		t.Errorf("got redaction profiles %+v, want %+v", profiles, want)
+	}
+	if got := viper.GetString("redaction.salt"); got != "s3cret" {
+		t.Errorf("got redaction.salt %q, want s3cret", got)
+	}
+}
+
+func TestStructuredConfigFromEnv(t *testing.T) {
+	t.Setenv("COSTTRACKER_AWS_SESSION_TAGS", `[{"key":"tool","value":"cost-tracker"}]`)
+	t.Setenv("COSTTRACKER_MOCK_REGIONS", `["us-east-1","eu-west-1"]`)
+	t.Setenv("COSTTRACKER_OFFHOURS_SERVICES", "ec2 rds")
+
+	var tags []SessionTag
+	if err := unmarshalConfigKey("aws.session_tags", &tags); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if want := []SessionTag{{Key: "tool", Value: "cost-tracker"}}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("got session tags %+v, want %+v", tags, want)
+	}
+	if got, want := configStringSlice("mock.regions"), []string{"us-east-1", "eu-west-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got regions %v, want %v", got, want)
+	}
+	if got, want := configStringSlice("offhours.services"), []string{"ec2", "rds"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got services %v, want %v", got, want)
+	}
+
+	t.Setenv("COSTTRACKER_AWS_SESSION_TAGS", `[{"key":`)
+	if err := unmarshalConfigKey("aws.session_tags", &tags); err == nil {
+		t.Errorf("expected an error for invalid JSON, but got nil")
+	}
+}
+
+func TestLintConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		wantKeys []string
+	}{
+		{name: "defaults", wantKeys: nil},
+		{
+			name:     "webhook that is not Slack",
+			config:   map[string]interface{}{"slack.webhook_url": "https://example.com/webhook"},
+			wantKeys: []string{"slack.webhook_url"},
+		},
+		{
+			name:     "Slack webhook",
+			config:   map[string]interface{}{"slack.webhook_url": "https://hooks.slack.com/services/T000/B000/XXXX"},
+			wantKeys: nil,
+		},
+		{
+			name:     "newer config schema",
+			config:   map[string]interface{}{"schema_version": ConfigSchemaVersion + 1},
+			wantKeys: []string{"schema_version"},
+		},
+		{
+			name:     "zero days",
+			config:   map[string]interface{}{"days": 0},
+			wantKeys: []string{"days"},
+		},
+		{
+			name: "role and duplicate session tags",
+			config: map[string]interface{}{
+				"aws.role_arn":     "CostTrackerRead",
+				"aws.session_tags": []map[string]interface{}{{"key": "team", "value": "a"}, {"key": "Team", "value": "b"}},
+			},
+			wantKeys: []string{"aws.role_arn", "aws.session_tags"},
+		},
+		{
+			name:     "missing CA bundle",
+			config:   map[string]interface{}{"http.ca_bundle": filepath.Join(t.TempDir(), "missing.pem")},
+			wantKeys: []string{"http.ca_bundle"},
+		},
+		{
+			name:     "hashing profile without salt",
+			config:   map[string]interface{}{"redaction.profiles": map[string]interface{}{"auditor": map[string]interface{}{"accounts": "hash"}}},
+			wantKeys: []string{"redaction.salt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.config {
+				previous := viper.Get(key)
+				viper.Set(key, value)
+				t.Cleanup(func() { viper.Set(key, previous) })
+			}
+			var gotKeys []string
+			for _, w := range lintConfig() {
+				gotKeys = append(gotKeys, w.Key)
+			}
+			if !reflect.DeepEqual(gotKeys, tt.wantKeys) {
+				t.Errorf("got warnings for %v, want %v", gotKeys, tt.wantKeys)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// File: credentials.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/spf13/viper"
+)
+
+// DefaultRoleSessionName is the session name used when assuming aws.role_arn.
+const DefaultRoleSessionName = "cost-tracker"
+
+// sourceIdentityInvalid matches characters STS does not accept in a source identity.
+var sourceIdentityInvalid = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// SessionTag is a session tag passed to STS when assuming aws.role_arn.
+type SessionTag struct {
+	Key   string `mapstructure:"key"`
+	Value string `mapstructure:"value"`
+}
+
+// AssumeRoleConfig describes the role to assume for Cost Explorer calls and how the session is attributed
+// in CloudTrail. The role's trust policy must allow sts:TagSession and sts:SetSourceIdentity.
+type AssumeRoleConfig struct {
+	RoleARN           string
+	SessionName       string
+	SourceIdentity    string // Usually the operator's username; empty leaves it unset
+	Tags              []SessionTag
+	TransitiveTagKeys []string
+}
+
+// AssumeRoleConfigFromViper reads the aws.* configuration keys. The source identity defaults to the
+// operator's username.
+func AssumeRoleConfigFromViper() (AssumeRoleConfig, error) {
+	cfg := AssumeRoleConfig{
+		RoleARN:           viper.GetString("aws.role_arn"),
+		SessionName:       viper.GetString("aws.role_session_name"),
+		SourceIdentity:    viper.GetString("aws.source_identity"),
+		TransitiveTagKeys: configStringSlice("aws.transitive_tag_keys"),
+	}
+	if err := unmarshalConfigKey("aws.session_tags", &cfg.Tags); err != nil {
+		return AssumeRoleConfig{}, fmt.Errorf("invalid aws.session_tags: %w", err)
+	}
+	if cfg.SourceIdentity == "" {
+		cfg.SourceIdentity = operatorName()
+	}
+	return cfg, nil
+}
+
+// operatorName returns the name of the user running the tool, or "" if it cannot be determined.
+func operatorName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// sanitizeSourceIdentity makes name acceptable to STS: 2-64 characters from [A-Za-z0-9_+=,.@-].
+// Domain-qualified Windows names such as CORP\alice lose the backslash.
+func sanitizeSourceIdentity(name string) string {
+	name = sourceIdentityInvalid.ReplaceAllString(name, "")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	if len(name) < 2 {
+		return ""
+	}
+	return name
+}
+
+// options returns the stscreds options applying the session name, source identity and session tags.
+func (c AssumeRoleConfig) options() func(*stscreds.AssumeRoleOptions) {
+	return func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = c.SessionName
+		if o.RoleSessionName == "" {
+			o.RoleSessionName = DefaultRoleSessionName
+		}
+		if identity := sanitizeSourceIdentity(c.SourceIdentity); identity != "" {
+			o.SourceIdentity = aws.String(identity)
+		}
+		for _, tag := range c.Tags {
+			o.Tags = append(o.Tags, ststypes.Tag{Key: aws.String(tag.Key), Value: aws.String(tag.Value)})
+		}
+		o.TransitiveTagKeys = c.TransitiveTagKeys
+	}
+}
+
+// applyAssumeRole replaces the credentials in cfg with ones from assuming the configured role, if any.
+func applyAssumeRole(cfg *aws.Config, role AssumeRoleConfig) {
+	if role.RoleARN == "" {
+		return
+	}
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(*cfg), role.RoleARN, role.options())
+	cfg.Credentials = aws.NewCredentialsCache(provider)
+}
+
+func init() {
+	viper.SetDefault("aws.role_arn", "")
+	viper.SetDefault("aws.role_session_name", DefaultRoleSessionName)
+	viper.SetDefault("aws.source_identity", "")
+	viper.SetDefault("aws.transitive_tag_keys", []string{})
+}
@@ -0,0 +1,60 @@
+// File: credentials_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+)
+
+func TestSanitizeSourceIdentity(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain username", in: "alice", want: "alice"},
+		{name: "email", in: "alice@example.com", want: "alice@example.com"},
+		{name: "domain-qualified", in: `CORP\alice`, want: "CORPalice"},
+		{name: "too short", in: "a", want: ""},
+		{name: "empty", in: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeSourceIdentity(tt.in); got != tt.want {
+				t.Errorf("sanitizeSourceIdentity(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssumeRoleOptions(t *testing.T) {
+	role := AssumeRoleConfig{
+		RoleARN:           "arn:aws:iam::123456789012:role/CostTrackerRead",
+		SourceIdentity:    "alice",
+		Tags:              []SessionTag{{Key: "tool", Value: "cost-tracker"}, {Key: "team", Value: "finops"}},
+		TransitiveTagKeys: []string{"team"},
+	}
+	var o stscreds.AssumeRoleOptions
+	role.options()(&o)
+
+	if o.RoleSessionName != DefaultRoleSessionName {
+		t.Errorf("expected the default session name, got %q", o.RoleSessionName)
+	}
+	if aws.ToString(o.SourceIdentity) != "alice" {
+		t.Errorf("expected source identity alice, got %q", aws.ToString(o.SourceIdentity))
+	}
+	if len(o.Tags) != 2 || aws.ToString(o.Tags[1].Key) != "team" || aws.ToString(o.Tags[1].Value) != "finops" {
+		t.Errorf("unexpected session tags: %+v", o.Tags)
+	}
+	if len(o.TransitiveTagKeys) != 1 || o.TransitiveTagKeys[0] != "team" {
+		t.Errorf("unexpected transitive tag keys: %v", o.TransitiveTagKeys)
+	}
+
+	var unset stscreds.AssumeRoleOptions
+	AssumeRoleConfig{SessionName: "nightly", SourceIdentity: "x"}.options()(&unset)
+	if unset.RoleSessionName != "nightly" || unset.SourceIdentity != nil {
+		t.Errorf("expected the configured session name and no source identity, got %q and %v", unset.RoleSessionName, unset.SourceIdentity)
+	}
+}
@@ -0,0 +1,69 @@
+// File: currency.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CurrencyConversion converts costs to one currency where payers reporting in different currencies
+// are consolidated. Amounts keep their original currency until then.
+type CurrencyConversion struct {
+	Target string             `mapstructure:"target"` // Empty means costs in different currencies are not consolidated
+	Rates  map[string]float64 `mapstructure:"rates"`  // Units of Target per unit of each other currency
+}
+
+// CurrencyConversionFromViper reads the currency configuration key. Currency codes are upper-cased,
+// since Viper lower-cases map keys.
+func CurrencyConversionFromViper() (CurrencyConversion, error) {
+	var c CurrencyConversion
+	if err := unmarshalConfigKey("currency", &c); err != nil {
+		return CurrencyConversion{}, fmt.Errorf("invalid currency: %w", err)
+	}
+	c.Target = strings.ToUpper(c.Target)
+	rates := make(map[string]float64, len(c.Rates))
+	for unit, rate := range c.Rates {
+		if rate <= 0 {
+			return CurrencyConversion{}, fmt.Errorf("currency.rates has a rate of %g for %s, rates must be positive", rate, strings.ToUpper(unit))
+		}
+		rates[strings.ToUpper(unit)] = rate
+	}
+	c.Rates = rates
+	if len(c.Rates) > 0 && c.Target == "" {
+		return CurrencyConversion{}, fmt.Errorf("currency.rates is set without currency.target")
+	}
+	return c, nil
+}
+
+// Convert returns amount, in unit, in the target currency. Without a target, amount is returned as is.
+func (c CurrencyConversion) Convert(amount float64, unit string) (float64, error) {
+	if c.Target == "" || unit == c.Target {
+		return amount, nil
+	}
+	rate, ok := c.Rates[unit]
+	if !ok {
+		return 0, fmt.Errorf("no rate in currency.rates to convert %s to %s", unit, c.Target)
+	}
+	return amount * rate, nil
+}
+
+// currencyBreakdown returns one line per original currency with its total and, when it was converted,
+// the total in unit, in currency order.
+func currencyBreakdown(totals map[string]float64, unit string, c CurrencyConversion) []string {
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	lines := make([]string, len(currencies))
+	for i, currency := range currencies {
+		lines[i] = fmt.Sprintf("%s %.2f", currency, totals[currency])
+		if currency != unit {
+			if converted, err := c.Convert(totals[currency], currency); err == nil {
+				lines[i] += fmt.Sprintf(" (%.2f %s at %g)", converted, unit, c.Rates[currency])
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,41 @@
+// File: currency_test.go
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestCurrencyConversionFromViper(t *testing.T) {
+	viper.Set("currency", map[string]interface{}{"target": "usd", "rates": map[string]interface{}{"eur": 1.08}})
+	t.Cleanup(func() { viper.Set("currency", nil) })
+	c, err := CurrencyConversionFromViper()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if c.Target != "USD" || c.Rates["EUR"] != 1.08 {
+		t.Errorf("expected upper-case currency codes, got %+v", c)
+	}
+
+	viper.Set("currency", map[string]interface{}{"rates": map[string]interface{}{"eur": 1.08}})
+	if _, err := CurrencyConversionFromViper(); err == nil {
+		t.Error("expected an error for rates without a target")
+	}
+}
+
+func TestCurrencyConversionConvert(t *testing.T) {
+	c := CurrencyConversion{Target: "USD", Rates: map[string]float64{"JPY": 0.0067}}
+	if got, err := c.Convert(1000, "JPY"); err != nil || got != 6.7 {
+		t.Errorf("expected 1000 JPY to be 6.70 USD, got %v, %v", got, err)
+	}
+	if got, err := c.Convert(5, "USD"); err != nil || got != 5 {
+		t.Errorf("expected the target currency unchanged, got %v, %v", got, err)
+	}
+	if _, err := c.Convert(5, "EUR"); err == nil {
+		t.Error("expected an error for a currency without a rate")
+	}
+	if got, err := (CurrencyConversion{}).Convert(5, "EUR"); err != nil || got != 5 {
+		t.Errorf("expected no conversion without a target, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,56 @@
+// File: ebs_test.go
+package main
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEBSDefinition(t *testing.T) {
+	testCases := map[string]string{
+		"EBS:VolumeUsage.gp2":          "Volumes: gp2",
+		"APS2-EBS:VolumeUsage.gp3":     "Volumes: gp3",
+		"USW2-EBS:VolumeP-IOPS.gp3":    "Volumes: gp3 provisioned IOPS/throughput",
+		"EBS:VolumeUsage.piops":        "Volumes: io1",
+		"EUC1-EBS:VolumeP-IOPS.piops":  "Volumes: io1 provisioned IOPS",
+		"EBS:VolumeP-IOPS.io2.tier2":   "Volumes: io2 provisioned IOPS",
+		"EBS:VolumeUsage.st1":          "Volumes: st1/sc1",
+		"EBS:VolumeUsage":              "Volumes: magnetic",
+		"APS2-EBS:SnapshotUsage":       "Snapshots",
+		"EBS:SnapshotArchiveStorage":   "Snapshots",
+		"USE1-EBS:FastSnapshotRestore": "Fast snapshot restore",
+		"EBS:directAPI.snapshot.Put":   "EBS direct APIs",
+		"EBS:SomethingNew":             "Other EBS usage",
+		"APS2-NatGateway-Bytes":        "",
+	}
+	for usageType, expected := range testCases {
+		lines := classifyUsage([]UsageTypeCost{{UsageType: usageType, Cost: 1}}, ebsDefinition)
+		got := ""
+		if len(lines) == 1 {
+			got = lines[0].Category
+		}
+		if got != expected {
+			t.Errorf("usage type %q classified as %q, expected %q", usageType, got, expected)
+		}
+	}
+}
+
+func TestAnnotateGP3Savings(t *testing.T) {
+	// Relies on the default ebs.gp3_savings_rate of 0.2
+	report := &SpotlightReport{
+		Days:  15,
+		Unit:  "USD",
+		Lines: []SpotlightLine{{Category: EBSCategoryGP2, Cost: 50}},
+	}
+	if err := annotateGP3Savings(context.Background(), nil, CostQuery{}, report); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(report.Notes) != 1 || !strings.Contains(report.Notes[0], "estimated savings 10.00 USD") || !strings.Contains(report.Notes[0], "~20.00 per month") {
+		t.Errorf("unexpected savings note: %v", report.Notes)
+	}
+	if math.Abs(report.Total()-50) > 1e-9 {
+		t.Errorf("annotation must not change the report total, got %.2f", report.Total())
+	}
+}
@@ -0,0 +1,197 @@
+// File: explain.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// CostExplorerRequestPrice is what AWS charges per Cost Explorer API request, in USD.
+const CostExplorerRequestPrice = 0.01
+
+// PlannedCall is a Cost Explorer request a report would make.
+type PlannedCall struct {
+	Operation string
+	Params    []string // Non-empty request parameters as name=value
+}
+
+// QueryPlan collects the requests a report would make under --explain.
+type QueryPlan struct {
+	mu    sync.Mutex
+	Calls []PlannedCall
+}
+
+// activePlan is the plan of the current run, or nil when --explain is not set.
+var activePlan *QueryPlan
+
+func (p *QueryPlan) add(operation string, params ...string) {
+	var set []string
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] != "" {
+			set = append(set, params[i]+"="+params[i+1])
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Calls = append(p.Calls, PlannedCall{Operation: operation, Params: set})
+}
+
+// describeInterval formats a request's time period.
+func describeInterval(period *types.DateInterval) string {
+	if period == nil {
+		return ""
+	}
+	return aws.ToString(period.Start) + ".." + aws.ToString(period.End)
+}
+
+// describeGroupBy formats a request's grouping, e.g. DIMENSION:SERVICE.
+func describeGroupBy(groups []types.GroupDefinition) string {
+	var parts []string
+	for _, group := range groups {
+		parts = append(parts, string(group.Type)+":"+aws.ToString(group.Key))
+	}
+	return strings.Join(parts, ",")
+}
+
+// describeFilter formats a filter expression as compact JSON, as it is sent to Cost Explorer.
+func describeFilter(expr *types.Expression) string {
+	if expr == nil {
+		return ""
+	}
+	var value interface{}
+	data, err := json.Marshal(expr)
+	if err == nil {
+		err = json.Unmarshal(data, &value)
+	}
+	if err == nil {
+		data, err = json.Marshal(dropNulls(value))
+	}
+	if err != nil {
+		return "(unprintable)"
+	}
+	return string(data)
+}
+
+// dropNulls removes null fields from decoded JSON, which the SDK's types include for every unset
+// member.
+func dropNulls(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, member := range v {
+			if member == nil {
+				delete(v, key)
+			} else {
+				v[key] = dropNulls(member)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = dropNulls(v[i])
+		}
+	}
+	return value
+}
+
+// explainClient records requests in a QueryPlan instead of sending them, answering each with an empty
+// result. Requests that depend on earlier results are therefore not planned.
+type explainClient struct {
+	plan *QueryPlan
+}
+
+// GetCostAndUsage satisfies the CostExplorerAPI interface.
+func (c *explainClient) GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+	c.plan.add("GetCostAndUsage",
+		"period", describeInterval(params.TimePeriod),
+		"granularity", string(params.Granularity),
+		"metrics", strings.Join(params.Metrics, ","),
+		"group_by", describeGroupBy(params.GroupBy),
+		"filter", describeFilter(params.Filter))
+	return &costexplorer.GetCostAndUsageOutput{}, nil
+}
+
+// GetDimensionValues satisfies the CostExplorerAPI interface.
+func (c *explainClient) GetDimensionValues(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+	c.plan.add("GetDimensionValues",
+		"period", describeInterval(params.TimePeriod),
+		"dimension", string(params.Dimension),
+		"filter", describeFilter(params.Filter))
+	return &costexplorer.GetDimensionValuesOutput{}, nil
+}
+
+// GetReservationCoverage satisfies the CostExplorerAPI interface.
+func (c *explainClient) GetReservationCoverage(ctx context.Context, params *costexplorer.GetReservationCoverageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error) {
+	c.plan.add("GetReservationCoverage",
+		"period", describeInterval(params.TimePeriod),
+		"group_by", describeGroupBy(params.GroupBy),
+		"filter", describeFilter(params.Filter))
+	return &costexplorer.GetReservationCoverageOutput{}, nil
+}
+
+// GetCostForecast satisfies the CostExplorerAPI interface.
+func (c *explainClient) GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+	c.plan.add("GetCostForecast",
+		"period", describeInterval(params.TimePeriod),
+		"granularity", string(params.Granularity),
+		"metric", string(params.Metric),
+		"prediction_interval", fmt.Sprintf("%d%%", aws.ToInt32(params.PredictionIntervalLevel)),
+		"filter", describeFilter(params.Filter))
+	return &costexplorer.GetCostForecastOutput{}, nil
+}
+
+// GetAnomalies satisfies the CostExplorerAPI interface.
+func (c *explainClient) GetAnomalies(ctx context.Context, params *costexplorer.GetAnomaliesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomaliesOutput, error) {
+	var minImpact string
+	if params.TotalImpact != nil {
+		minImpact = fmt.Sprintf("%.2f", params.TotalImpact.StartValue)
+	}
+	c.plan.add("GetAnomalies",
+		"period", describeInterval(anomalyInterval(params.DateInterval)),
+		"monitor", aws.ToString(params.MonitorArn),
+		"min_impact", minImpact)
+	return &costexplorer.GetAnomaliesOutput{}, nil
+}
+
+// displayQueryPlan writes the planned requests and their estimated price to w.
+func displayQueryPlan(w io.Writer, plan *QueryPlan, provider string) {
+	plan.mu.Lock()
+	defer plan.mu.Unlock()
+
+	fmt.Fprintf(w, "Query plan (provider %s):\n", provider)
+	fmt.Fprintln(w, "=====================================")
+	for i, call := range plan.Calls {
+		fmt.Fprintf(w, "%2d. %s\n", i+1, call.Operation)
+		for _, param := range call.Params {
+			fmt.Fprintf(w, "      %s\n", param)
+		}
+	}
+	price := 0.0
+	if provider == ProviderAWS {
+		price = float64(len(plan.Calls)) * CostExplorerRequestPrice
+	}
+	fmt.Fprintf(w, "%d request(s), estimated API cost %.2f USD.\n", len(plan.Calls), price)
+	fmt.Fprintln(w, "Each further page of a paginated result is another request. Requests that depend on earlier results are not shown. Responses are not cached, so every request goes to the provider.")
+}
+
+// explaining reports whether --explain is set, in which case reports plan their requests instead of
+// running them.
+func explaining() bool {
+	return viper.GetBool("explain")
+}
+
+// showQueryPlan prints the active query plan after a command completes under --explain.
+func showQueryPlan(cmd *cobra.Command, args []string) {
+	if activePlan == nil {
+		return
+	}
+	displayQueryPlan(cmd.OutOrStdout(), activePlan, viper.GetString("provider"))
+}
@@ -0,0 +1,79 @@
+// File: explain_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestExplainClientPlansRequests(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	filter, err := ParseFilter(`service = "Amazon Simple Storage Service"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := &QueryPlan{}
+	tracker := &CostTracker{client: &explainClient{plan: plan}}
+	costs, err := tracker.GetCostsApproximate(context.Background(), CostQuery{
+		Start:       time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		Filter:      filter,
+		Granularity: "DAILY",
+	})
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(costs) != 4 {
+		t.Errorf("expected 4 empty daily periods, got %d", len(costs))
+	}
+
+	// Daily totals, then one breakdown per ISO week
+	expected := [][]string{
+		{"period=2024-01-06..2024-01-10", "granularity=DAILY", "metrics=BlendedCost", `filter={"Dimensions":{"Key":"SERVICE","Values":["Amazon Simple Storage Service"]}}`},
+		{"period=2024-01-06..2024-01-08", "granularity=MONTHLY", "metrics=BlendedCost", "group_by=DIMENSION:SERVICE", `filter={"Dimensions":{"Key":"SERVICE","Values":["Amazon Simple Storage Service"]}}`},
+		{"period=2024-01-08..2024-01-10", "granularity=MONTHLY", "metrics=BlendedCost", "group_by=DIMENSION:SERVICE", `filter={"Dimensions":{"Key":"SERVICE","Values":["Amazon Simple Storage Service"]}}`},
+	}
+	if len(plan.Calls) != len(expected) {
+		t.Fatalf("expected %d planned calls, got %d: %+v", len(expected), len(plan.Calls), plan.Calls)
+	}
+	for i, call := range plan.Calls {
+		if call.Operation != "GetCostAndUsage" {
+			t.Errorf("call %d: expected GetCostAndUsage, got %s", i, call.Operation)
+		}
+		if strings.Join(call.Params, "\n") != strings.Join(expected[i], "\n") {
+			t.Errorf("call %d: expected params %q, got %q", i, expected[i], call.Params)
+		}
+	}
+}
+
+func TestDisplayQueryPlanCost(t *testing.T) {
+	plan := &QueryPlan{}
+	plan.add("GetCostAndUsage", "period", "2024-01-01..2024-02-01")
+	plan.add("GetDimensionValues", "dimension", "REGION", "filter", "")
+
+	tests := []struct {
+		provider string
+		expected string
+	}{
+		{provider: ProviderAWS, expected: "2 request(s), estimated API cost 0.02 USD."},
+		{provider: ProviderMock, expected: "2 request(s), estimated API cost 0.00 USD."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			var buf bytes.Buffer
+			displayQueryPlan(&buf, plan, tt.provider)
+			if !strings.Contains(buf.String(), tt.expected) {
+				t.Errorf("expected %q in:\n%s", tt.expected, buf.String())
+			}
+			if strings.Contains(buf.String(), "filter=") {
+				t.Errorf("expected empty parameters to be left out:\n%s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+// File: filter.go
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+// The --filter mini-language compiles to a Cost Explorer Expression. Grammar:
+//
+//	expr       := andExpr ( OR andExpr )*
+//	andExpr    := unary ( AND unary )*
+//	unary      := NOT unary | '(' expr ')' | comparison
+//	comparison := key ( '=' | '!=' ) value
+//	            | key [ NOT ] IN '(' value ( ',' value )* ')'
+//	key        := dimension | tag:<name> | cost_category:<name>
+//	value      := bare word | "double quoted string"
+//
+// Keywords are case-insensitive. Example:
+//
+//	service = "Amazon Simple Storage Service" and (region in (us-east-1, eu-west-1) or tag:env != prod)
+
+// filterKeyAliases maps friendly filter keys to Cost Explorer dimensions.
+var filterKeyAliases = map[string]types.Dimension{
+	"account":       types.DimensionLinkedAccount,
+	"az":            types.DimensionAz,
+	"instance_type": types.DimensionInstanceType,
+	"operation":     types.DimensionOperation,
+	"purchase_type": types.DimensionPurchaseType,
+	"record_type":   types.DimensionRecordType,
+	"region":        types.DimensionRegion,
+	"service":       types.DimensionService,
+	"usage_type":    types.DimensionUsageType,
+}
+
+// FilterParseError reports a syntax error in a filter expression at a 1-based column.
+type FilterParseError struct {
+	Column  int
+	Message string
+}
+
+func (e *FilterParseError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Message, e.Column)
+}
+
+type filterTokenKind int
+
+const (
+	tokenEOF filterTokenKind = iota
+	tokenIdent
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenEq
+	tokenNeq
+)
+
+// filterToken is a lexed token; column is the 1-based position of its first character.
+type filterToken struct {
+	kind   filterTokenKind
+	text   string
+	column int
+}
+
+// describe renders a token for error messages, e.g. "')'" or "end of input".
+func (t filterToken) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of input"
+	case tokenString:
+		return fmt.Sprintf("%q", t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+// isBareChar reports whether r may appear in an unquoted word.
+func isBareChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("_-.:/*@+", r)
+}
+
+// lexFilter splits a filter expression into tokens.
+func lexFilter(input string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		column := i + 1
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: tokenLParen, text: "(", column: column})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: tokenRParen, text: ")", column: column})
+			i++
+		case r == ',':
+			tokens = append(tokens, filterToken{kind: tokenComma, text: ",", column: column})
+			i++
+		case r == '=':
+			tokens = append(tokens, filterToken{kind: tokenEq, text: "=", column: column})
+			i++
+		case r == '!':
+			if i+1 >= len(runes) || runes[i+1] != '=' {
+				return nil, &FilterParseError{Column: column, Message: "unexpected '!' (did you mean '!='?)"}
+			}
+			tokens = append(tokens, filterToken{kind: tokenNeq, text: "!=", column: column})
+			i += 2
+		case r == '"':
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, &FilterParseError{Column: column, Message: "unterminated string"}
+			}
+			tokens = append(tokens, filterToken{kind: tokenString, text: sb.String(), column: column})
+		case isBareChar(r):
+			start := i
+			for i < len(runes) && isBareChar(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: tokenIdent, text: string(runes[start:i]), column: column})
+		default:
+			return nil, &FilterParseError{Column: column, Message: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	tokens = append(tokens, filterToken{kind: tokenEOF, column: len(runes) + 1})
+	return tokens, nil
+}
+
+// filterParser is a recursive-descent parser over lexed filter tokens.
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// ParseFilter parses a --filter expression into a Cost Explorer Expression.
+// Syntax errors are returned as *FilterParseError with the column of the offending token.
+func ParseFilter(input string) (*types.Expression, error) {
+	tokens, err := lexFilter(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, &FilterParseError{Column: 1, Message: "empty filter expression"}
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+	return expr, nil
+}
+
+func (p *filterParser) peek() filterToken { return p.tokens[p.pos] }
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isKeyword reports whether the token is the given case-insensitive keyword.
+func isKeyword(tok filterToken, keyword string) bool {
+	return tok.kind == tokenIdent && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *filterParser) unexpected(tok filterToken) error {
+	return &FilterParseError{Column: tok.column, Message: "unexpected " + tok.describe()}
+}
+
+func (p *filterParser) parseOr() (*types.Expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	operands := []types.Expression{*left}
+	for isKeyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, *right)
+	}
+	if len(operands) == 1 {
+		return left, nil
+	}
+	return &types.Expression{Or: operands}, nil
+}
+
+func (p *filterParser) parseAnd() (*types.Expression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	operands := []types.Expression{*left}
+	for isKeyword(p.peek(), "and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, *right)
+	}
+	if len(operands) == 1 {
+		return left, nil
+	}
+	return &types.Expression{And: operands}, nil
+}
+
+func (p *filterParser) parseUnary() (*types.Expression, error) {
+	tok := p.peek()
+	switch {
+	case isKeyword(tok, "not"):
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &types.Expression{Not: operand}, nil
+	case tok.kind == tokenLParen:
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &FilterParseError{Column: closing.column, Message: "expected ')' but found " + closing.describe()}
+		}
+		return expr, nil
+	case tok.kind == tokenIdent && !isKeyword(tok, "and") && !isKeyword(tok, "or") && !isKeyword(tok, "in"):
+		return p.parseComparison()
+	default:
+		return nil, p.unexpected(tok)
+	}
+}
+
+func (p *filterParser) parseComparison() (*types.Expression, error) {
+	keyTok := p.next()
+	opTok := p.next()
+
+	negate := false
+	var values []string
+	switch {
+	case opTok.kind == tokenEq || opTok.kind == tokenNeq:
+		negate = opTok.kind == tokenNeq
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = []string{value}
+	case isKeyword(opTok, "in") || (isKeyword(opTok, "not") && isKeyword(p.peek(), "in")):
+		if isKeyword(opTok, "not") {
+			negate = true
+			p.next()
+		}
+		list, err := p.parseValueList()
+		if err != nil {
+			return nil, err
+		}
+		values = list
+	default:
+		return nil, &FilterParseError{Column: opTok.column, Message: fmt.Sprintf("expected '=', '!=' or 'in' after '%s' but found %s", keyTok.text, opTok.describe())}
+	}
+
+	expr, err := comparisonExpression(keyTok, values)
+	if err != nil {
+		return nil, err
+	}
+	if negate {
+		return &types.Expression{Not: expr}, nil
+	}
+	return expr, nil
+}
+
+func (p *filterParser) parseValue() (string, error) {
+	tok := p.next()
+	if tok.kind != tokenIdent && tok.kind != tokenString {
+		return "", &FilterParseError{Column: tok.column, Message: "expected a value but found " + tok.describe()}
+	}
+	return tok.text, nil
+}
+
+func (p *filterParser) parseValueList() ([]string, error) {
+	if open := p.next(); open.kind != tokenLParen {
+		return nil, &FilterParseError{Column: open.column, Message: "expected '(' but found " + open.describe()}
+	}
+	var values []string
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		tok := p.next()
+		if tok.kind == tokenRParen {
+			return values, nil
+		}
+		if tok.kind != tokenComma {
+			return nil, &FilterParseError{Column: tok.column, Message: "expected ',' or ')' but found " + tok.describe()}
+		}
+	}
+}
+
+// comparisonExpression builds the leaf Expression for a key and its accepted values.
+func comparisonExpression(keyTok filterToken, values []string) (*types.Expression, error) {
+	key := keyTok.text
+	if prefix, name, ok := strings.Cut(key, ":"); ok {
+		if name == "" {
+			return nil, &FilterParseError{Column: keyTok.column, Message: fmt.Sprintf("missing name after '%s:'", prefix)}
+		}
+		switch strings.ToLower(prefix) {
+		case "tag":
+			return &types.Expression{Tags: &types.TagValues{Key: aws.String(name), Values: values}}, nil
+		case "cost_category":
+			return &types.Expression{CostCategories: &types.CostCategoryValues{Key: aws.String(name), Values: values}}, nil
+		}
+		return nil, &FilterParseError{Column: keyTok.column, Message: fmt.Sprintf("unknown key prefix '%s' (expected 'tag' or 'cost_category')", prefix)}
+	}
+
+	dimension, ok := lookupDimension(key)
+	if !ok {
+		return nil, &FilterParseError{Column: keyTok.column, Message: fmt.Sprintf("unknown filter key '%s'", key)}
+	}
+	return &types.Expression{Dimensions: &types.DimensionValues{Key: dimension, Values: values}}, nil
+}
+
+// lookupDimension resolves a filter key alias or a Cost Explorer dimension name, case-insensitively.
+func lookupDimension(key string) (types.Dimension, bool) {
+	if dimension, ok := filterKeyAliases[strings.ToLower(key)]; ok {
+		return dimension, true
+	}
+	var dimension types.Dimension
+	for _, candidate := range dimension.Values() {
+		if strings.EqualFold(string(candidate), key) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,175 @@
+// File: filter_test.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+// expressionJSON renders an Expression compactly so expected filters can be written inline.
+func expressionJSON(t *testing.T, expr *types.Expression) string {
+	t.Helper()
+	data, err := json.Marshal(expr)
+	if err != nil {
+		t.Fatalf("failed to marshal expression: %v", err)
+	}
+	return string(data)
+}
+
+func TestParseFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "single dimension",
+			input:    `service = "Amazon Simple Storage Service"`,
+			expected: `{"And":null,"CostCategories":null,"Dimensions":{"Key":"SERVICE","MatchOptions":null,"Values":["Amazon Simple Storage Service"]},"Not":null,"Or":null,"Tags":null}`,
+		},
+		{
+			name:     "not equal becomes NOT",
+			input:    `region != us-east-1`,
+			expected: `{"And":null,"CostCategories":null,"Dimensions":null,"Not":{"And":null,"CostCategories":null,"Dimensions":{"Key":"REGION","MatchOptions":null,"Values":["us-east-1"]},"Not":null,"Or":null,"Tags":null},"Or":null,"Tags":null}`,
+		},
+		{
+			name:     "in list with raw dimension name and tag",
+			input:    `LINKED_ACCOUNT in (111111111111, 222222222222) AND tag:env = prod`,
+			expected: `{"And":[{"And":null,"CostCategories":null,"Dimensions":{"Key":"LINKED_ACCOUNT","MatchOptions":null,"Values":["111111111111","222222222222"]},"Not":null,"Or":null,"Tags":null},{"And":null,"CostCategories":null,"Dimensions":null,"Not":null,"Or":null,"Tags":{"Key":"env","MatchOptions":null,"Values":["prod"]}}],"CostCategories":null,"Dimensions":null,"Not":null,"Or":null,"Tags":null}`,
+		},
+		{
+			name:     "and binds tighter than or",
+			input:    `region = a or region = b and service = c`,
+			expected: `{"And":null,"CostCategories":null,"Dimensions":null,"Not":null,"Or":[{"And":null,"CostCategories":null,"Dimensions":{"Key":"REGION","MatchOptions":null,"Values":["a"]},"Not":null,"Or":null,"Tags":null},{"And":[{"And":null,"CostCategories":null,"Dimensions":{"Key":"REGION","MatchOptions":null,"Values":["b"]},"Not":null,"Or":null,"Tags":null},{"And":null,"CostCategories":null,"Dimensions":{"Key":"SERVICE","MatchOptions":null,"Values":["c"]},"Not":null,"Or":null,"Tags":null}],"CostCategories":null,"Dimensions":null,"Not":null,"Or":null,"Tags":null}],"Tags":null}`,
+		},
+		{
+			name:     "parentheses and not in",
+			input:    `(cost_category:team = "Platform \"Core\"") and account not in (1)`,
+			expected: `{"And":[{"And":null,"CostCategories":{"Key":"team","MatchOptions":null,"Values":["Platform \"Core\""]},"Dimensions":null,"Not":null,"Or":null,"Tags":null},{"And":null,"CostCategories":null,"Dimensions":null,"Not":{"And":null,"CostCategories":null,"Dimensions":{"Key":"LINKED_ACCOUNT","MatchOptions":null,"Values":["1"]},"Not":null,"Or":null,"Tags":null},"Or":null,"Tags":null}],"CostCategories":null,"Dimensions":null,"Not":null,"Or":null,"Tags":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := ParseFilter(tc.input)
+			if err != nil {
+				t.Fatalf("did not expect an error, but got: %v", err)
+			}
+			if got := expressionJSON(t, expr); got != tc.expected {
+				t.Errorf("unexpected expression\n got: %s\nwant: %s", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{input: ``, expected: "empty filter expression at column 1"},
+		{input: `(region = a))`, expected: "unexpected ')' at column 13"},
+		{input: `region = us-east-1)`, expected: "unexpected ')' at column 19"},
+		{input: `(region = a`, expected: "expected ')' but found end of input at column 12"},
+		{input: `region us-east-1`, expected: "expected '=', '!=' or 'in' after 'region' but found 'us-east-1' at column 8"},
+		{input: `region in (a b)`, expected: "expected ',' or ')' but found 'b' at column 14"},
+		{input: `region = "a`, expected: "unterminated string at column 10"},
+		{input: `region ! a`, expected: "unexpected '!' (did you mean '!='?) at column 8"},
+		{input: `colour = red`, expected: "unknown filter key 'colour' at column 1"},
+		{input: `tag: = x`, expected: "missing name after 'tag:' at column 1"},
+		{input: `region = a and`, expected: "unexpected end of input at column 15"},
+		{input: `region = a; drop`, expected: "unexpected character ';' at column 11"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := ParseFilter(tc.input)
+			var parseErr *FilterParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a *FilterParseError, got %v", err)
+			}
+			if err.Error() != tc.expected {
+				t.Errorf("expected error %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
+
+// validExpression checks the structural rules Cost Explorer enforces on filter expressions.
+func validExpression(expr *types.Expression) bool {
+	set := 0
+	if len(expr.And) > 0 {
+		set++
+		if len(expr.And) < 2 {
+			return false
+		}
+		for i := range expr.And {
+			if !validExpression(&expr.And[i]) {
+				return false
+			}
+		}
+	}
+	if len(expr.Or) > 0 {
+		set++
+		if len(expr.Or) < 2 {
+			return false
+		}
+		for i := range expr.Or {
+			if !validExpression(&expr.Or[i]) {
+				return false
+			}
+		}
+	}
+	if expr.Not != nil {
+		set++
+		if !validExpression(expr.Not) {
+			return false
+		}
+	}
+	if expr.Dimensions != nil {
+		set++
+		if len(expr.Dimensions.Values) == 0 {
+			return false
+		}
+	}
+	if expr.Tags != nil {
+		set++
+	}
+	if expr.CostCategories != nil {
+		set++
+	}
+	return set == 1
+}
+
+func FuzzParseFilter(f *testing.F) {
+	seeds := []string{
+		`service = "Amazon EC2"`,
+		`region in (us-east-1, eu-west-1) or not tag:env = prod`,
+		`((account != 1 and usage_type = x) or cost_category:team in ("a", "b"))`,
+		`(region = a))`,
+		`"unterminated`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		expr, err := ParseFilter(input)
+		if err != nil {
+			var parseErr *FilterParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a *FilterParseError, got %T: %v", err, err)
+			}
+			if parseErr.Column < 1 || parseErr.Column > utf8.RuneCountInString(input)+1 {
+				t.Fatalf("error column %d out of range for input %q", parseErr.Column, input)
+			}
+			return
+		}
+		if expr == nil || !validExpression(expr) {
+			t.Fatalf("invalid expression for input %q: %+v", input, expr)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+// File: filtercheck.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+// filterDimensionValues returns the values each dimension is compared with in expr, in filter order.
+// Tag and cost category comparisons are not included.
+func filterDimensionValues(expr *types.Expression) ([]types.Dimension, map[types.Dimension][]string) {
+	var order []types.Dimension
+	values := make(map[types.Dimension][]string)
+	var walk func(e *types.Expression)
+	walk = func(e *types.Expression) {
+		if e == nil {
+			return
+		}
+		for i := range e.And {
+			walk(&e.And[i])
+		}
+		for i := range e.Or {
+			walk(&e.Or[i])
+		}
+		walk(e.Not)
+		if e.Dimensions != nil {
+			key := e.Dimensions.Key
+			if _, ok := values[key]; !ok {
+				order = append(order, key)
+			}
+			values[key] = append(values[key], e.Dimensions.Values...)
+		}
+	}
+	walk(expr)
+	return order, values
+}
+
+// editDistance returns the Levenshtein distance between a and b, case-insensitively.
+func editDistance(a, b string) int {
+	ra, rb := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// closestValue returns the candidate most like value, if any is close enough to be a likely typo: at
+// most a quarter of value's characters apart, and never more than 2 for short values.
+func closestValue(value string, candidates []string) (string, bool) {
+	best, bestDistance := "", max(2, len([]rune(value))/4)+1
+	for _, candidate := range candidates {
+		if d := editDistance(value, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best, best != ""
+}
+
+// dimensionValues lists the values of a dimension that have cost data in q's range.
+func (ct *CostTracker) dimensionValues(ctx context.Context, q CostQuery, dimension types.Dimension) ([]string, error) {
+	start := q.Start.UTC().Truncate(24 * time.Hour)
+	end := q.End.UTC().Truncate(24 * time.Hour)
+	if !end.After(start) { // Hourly ranges can start and end on the same day
+		end = start.AddDate(0, 0, 1)
+	}
+	var values []string
+	var nextPageToken *string
+	for {
+		result, err := ct.client.GetDimensionValues(ctx, &costexplorer.GetDimensionValuesInput{
+			TimePeriod: &types.DateInterval{
+				Start: aws.String(start.Format(AWSDateFormat)),
+				End:   aws.String(end.Format(AWSDateFormat)),
+			},
+			Dimension:     dimension,
+			NextPageToken: nextPageToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s values from AWS Cost Explorer: %w", dimension, err)
+		}
+		for _, value := range result.DimensionValues {
+			values = append(values, aws.ToString(value.Value))
+		}
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			return values, nil
+		}
+		nextPageToken = result.NextPageToken
+	}
+}
+
+// ValidateFilter checks that every dimension value compared in q's filter has cost data in q's range,
+// so a typo is reported, with the closest existing value, instead of silently matching nothing. It
+// makes one GetDimensionValues request per dimension in the filter.
+func (ct *CostTracker) ValidateFilter(ctx context.Context, q CostQuery) error {
+	order, compared := filterDimensionValues(q.Filter)
+	var problems []string
+	for _, dimension := range order {
+		existing, err := ct.dimensionValues(ctx, q, dimension)
+		if err != nil {
+			return err
+		}
+		known := make(map[string]bool, len(existing))
+		for _, value := range existing {
+			known[value] = true
+		}
+		for _, value := range compared[dimension] {
+			if known[value] {
+				continue
+			}
+			problem := fmt.Sprintf("%s %q has no cost data in the query range", dimension, value)
+			if suggestion, ok := closestValue(value, existing); ok {
+				problem += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
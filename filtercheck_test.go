@@ -0,0 +1,73 @@
+// File: filtercheck_test.go
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestClosestValue(t *testing.T) {
+	services := []string{"Amazon Relational Database Service", "Amazon Simple Storage Service", "AWS Lambda"}
+	tests := []struct {
+		value, want string
+		ok          bool
+	}{
+		{value: "Amazon Relational Database Servce", want: "Amazon Relational Database Service", ok: true},
+		{value: "aws lambda", want: "AWS Lambda", ok: true},
+		{value: "Amazon EC2", ok: false},
+	}
+	for _, tt := range tests {
+		if got, ok := closestValue(tt.value, services); got != tt.want || ok != tt.ok {
+			t.Errorf("closestValue(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestValidateFilter(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	var dimensions []types.Dimension
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetDimensionValuesFunc: func(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+			dimensions = append(dimensions, params.Dimension)
+			values := map[types.Dimension][]string{
+				types.DimensionService: {"Amazon Relational Database Service", "AWS Lambda"},
+				types.DimensionRegion:  {"us-east-1", "eu-west-1"},
+			}[params.Dimension]
+			out := &costexplorer.GetDimensionValuesOutput{}
+			for _, v := range values {
+				out.DimensionValues = append(out.DimensionValues, types.DimensionValuesWithAttributes{Value: aws.String(v)})
+			}
+			return out, nil
+		},
+	}}
+	q := CostQuery{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}
+
+	var err error
+	if q.Filter, err = ParseFilter(`service = "AWS Lambda" and region in (us-east-1, eu-west-1)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.ValidateFilter(context.Background(), q); err != nil {
+		t.Errorf("did not expect an error for existing values, but got: %v", err)
+	}
+	if len(dimensions) != 2 {
+		t.Errorf("expected one request per dimension, got %v", dimensions)
+	}
+
+	if q.Filter, err = ParseFilter(`service = "Amazon Relational Database Servce" or not region = mars-1`); err != nil {
+		t.Fatal(err)
+	}
+	err = tracker.ValidateFilter(context.Background(), q)
+	if err == nil {
+		t.Fatal("expected an error for unknown values")
+	}
+	if !strings.Contains(err.Error(), `did you mean "Amazon Relational Database Service"?`) || !strings.Contains(err.Error(), `REGION "mars-1"`) {
+		t.Errorf("expected both unknown values with a suggestion for the typo, got: %v", err)
+	}
+}
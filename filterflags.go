@@ -0,0 +1,210 @@
+// File: filterflags.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/viper"
+)
+
+// filterFlagDimensions are the config keys of the --filter-service, --filter-account and --filter-region
+// flags and the dimensions they select, in the order their filters are ANDed.
+var filterFlagDimensions = []struct {
+	Key       string
+	Dimension types.Dimension
+}{
+	{"filter_service", types.DimensionService},
+	{"filter_account", types.DimensionLinkedAccount},
+	{"filter_region", types.DimensionRegion},
+}
+
+// recordTypes are the Cost Explorer record types --exclude-record-types accepts, matched case-insensitively.
+var recordTypes = []string{
+	"Credit", "Refund", "Tax", "Support", "Fee", "RIFee", "Usage", "DiscountedUsage",
+	RecordTypeSavingsPlanCoveredUsage, "SavingsPlanNegation", "SavingsPlanRecurringFee", "SavingsPlanUpfrontFee",
+	"BundledDiscount", "Enterprise Discount Program Discount", "Private Rate Discount",
+	"Distributor Discount", "Solution Provider Program Discount",
+}
+
+// excludedRecordTypes returns the record types listed by --exclude-record-types, in Cost Explorer's
+// spelling. Values may be repeated or comma-separated.
+func excludedRecordTypes() ([]string, error) {
+	var excluded []string
+	for _, value := range configStringSlice("exclude_record_types") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			i := slices.IndexFunc(recordTypes, func(rt string) bool { return strings.EqualFold(rt, name) })
+			if i < 0 {
+				return nil, fmt.Errorf("unknown record type %q (expected one of %s)", name, strings.Join(recordTypes, ", "))
+			}
+			if !slices.Contains(excluded, recordTypes[i]) {
+				excluded = append(excluded, recordTypes[i])
+			}
+		}
+	}
+	return excluded, nil
+}
+
+// flagFilterFromConfig returns the filter built from the --filter-service, --filter-account,
+// --filter-region, --filter-tag and --exclude-record-types flags and the filter_json config key, ANDed,
+// or nil if none is set. Repeating a flag, or a tag key, selects any of its values.
+func flagFilterFromConfig() (*types.Expression, error) {
+	var parts []types.Expression
+	for _, f := range filterFlagDimensions {
+		values := filterFlagValues(f.Key)
+		if len(values) == 0 {
+			continue
+		}
+		if f.Dimension == types.DimensionLinkedAccount {
+			for i, target := range values {
+				account, err := accountIDFromTarget(target)
+				if err != nil {
+					return nil, fmt.Errorf("invalid --filter-account: %w", err)
+				}
+				values[i] = account
+			}
+		}
+		parts = append(parts, types.Expression{Dimensions: &types.DimensionValues{Key: f.Dimension, Values: values}})
+	}
+
+	var keys []string
+	tagValues := make(map[string][]string)
+	for _, spec := range filterFlagValues("filter_tag") {
+		key, value, ok := strings.Cut(spec, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("--filter-tag must be KEY=VALUE, e.g. team=data, got %q", spec)
+		}
+		if _, seen := tagValues[key]; !seen {
+			keys = append(keys, key)
+		}
+		tagValues[key] = append(tagValues[key], value)
+	}
+	for _, key := range keys {
+		parts = append(parts, types.Expression{Tags: &types.TagValues{Key: aws.String(key), Values: tagValues[key]}})
+	}
+
+	excluded, err := excludedRecordTypes()
+	if err != nil {
+		return nil, fmt.Errorf("invalid --exclude-record-types: %w", err)
+	}
+	if len(excluded) > 0 {
+		parts = append(parts, types.Expression{Not: &types.Expression{
+			Dimensions: &types.DimensionValues{Key: types.DimensionRecordType, Values: excluded},
+		}})
+	}
+
+	raw, err := rawFilterFromConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter_json: %w", err)
+	}
+	if raw != nil {
+		parts = append(parts, *raw)
+	}
+	switch len(parts) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &parts[0], nil
+	}
+	return &types.Expression{And: parts}, nil
+}
+
+// filterFlagValues returns the values of a --filter-* config key. A plain string, as an environment
+// variable gives, is one value rather than a space-separated list, since service names contain spaces.
+func filterFlagValues(key string) []string {
+	if s, ok := viper.Get(key).(string); ok {
+		if _, isJSON := envJSON(key); !isJSON {
+			if s == "" {
+				return nil
+			}
+			return []string{s}
+		}
+	}
+	return configStringSlice(key)
+}
+
+// rawFilterFromConfig decodes filter_json, a Cost Explorer Expression as the AWS CLI takes it, e.g.
+// {"Not": {"Dimensions": {"Key": "RECORD_TYPE", "Values": ["Credit"]}}}. It may be given as a JSON
+// string or, in a config file, as an object. Field names are matched case-insensitively, since Viper
+// lowercases config file keys.
+func rawFilterFromConfig() (*types.Expression, error) {
+	var data []byte
+	switch value := viper.Get("filter_json").(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if strings.TrimSpace(value) == "" {
+			return nil, nil
+		}
+		data = []byte(value)
+	default:
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+
+	var expr types.Expression
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&expr); err != nil {
+		return nil, err
+	}
+	if err := checkExpression(&expr); err != nil {
+		return nil, err
+	}
+	return &expr, nil
+}
+
+// checkExpression reports an expression Cost Explorer would reject for not having exactly one of And,
+// Or, Not, Dimensions, Tags or CostCategories, or for an And or Or of fewer than two expressions.
+func checkExpression(expr *types.Expression) error {
+	set := 0
+	for _, ok := range []bool{expr.And != nil, expr.Or != nil, expr.Not != nil, expr.Dimensions != nil, expr.Tags != nil, expr.CostCategories != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("an expression needs exactly one of And, Or, Not, Dimensions, Tags or CostCategories, got %d", set)
+	}
+	switch {
+	case expr.Dimensions != nil:
+		if expr.Dimensions.Key == "" {
+			return fmt.Errorf("Dimensions needs a Key")
+		}
+	case expr.Tags != nil:
+		if aws.ToString(expr.Tags.Key) == "" {
+			return fmt.Errorf("Tags needs a Key")
+		}
+	case expr.CostCategories != nil:
+		if aws.ToString(expr.CostCategories.Key) == "" {
+			return fmt.Errorf("CostCategories needs a Key")
+		}
+	case expr.Not != nil:
+		return checkExpression(expr.Not)
+	default:
+		operands := expr.And
+		if expr.Or != nil {
+			operands = expr.Or
+		}
+		if len(operands) < 2 {
+			return fmt.Errorf("And and Or need at least two expressions, got %d", len(operands))
+		}
+		for i := range operands {
+			if err := checkExpression(&operands[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// File: filterflags_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/viper"
+)
+
+func TestFlagFilterFromConfig(t *testing.T) {
+	keys := []string{"filter_service", "filter_account", "filter_region", "filter_tag", "filter_json"}
+	t.Cleanup(func() {
+		for _, key := range keys {
+			viper.Set(key, "")
+		}
+	})
+
+	filter, err := flagFilterFromConfig()
+	if err != nil || filter != nil {
+		t.Fatalf("expected no filter without flags, got %+v, %v", filter, err)
+	}
+
+	viper.Set("filter_service", "Amazon Simple Storage Service") // One value from the environment
+	viper.Set("filter_account", []string{"arn:aws:iam::111111111111:role/ops", "222222222222"})
+	viper.Set("filter_tag", []string{"team=data", "env=prod", "team=platform"})
+	viper.Set("filter_json", `{"Not": {"Dimensions": {"Key": "RECORD_TYPE", "Values": ["Credit"]}}}`)
+	filter, err = flagFilterFromConfig()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(filter.And) != 5 {
+		t.Fatalf("expected service, accounts, two tags and the raw filter to be ANDed, got %+v", filter)
+	}
+	if d := filter.And[0].Dimensions; d.Key != types.DimensionService || len(d.Values) != 1 || d.Values[0] != "Amazon Simple Storage Service" {
+		t.Errorf("expected the service name as one value, got %+v", d)
+	}
+	if d := filter.And[1].Dimensions; d.Key != types.DimensionLinkedAccount || strings.Join(d.Values, ",") != "111111111111,222222222222" {
+		t.Errorf("expected account IDs, got %+v", d)
+	}
+	if tags := filter.And[2].Tags; *tags.Key != "team" || strings.Join(tags.Values, ",") != "data,platform" {
+		t.Errorf("expected the values of team to be ORed, got %+v", tags)
+	}
+	if not := filter.And[4].Not; not == nil || not.Dimensions.Key != types.DimensionRecordType {
+		t.Errorf("expected the raw filter last, got %+v", filter.And[4])
+	}
+
+	viper.Set("filter_tag", []string{"team"})
+	if _, err := flagFilterFromConfig(); err == nil {
+		t.Error("expected an error for --filter-tag without a value")
+	}
+}
+
+func TestRawFilterFromConfig(t *testing.T) {
+	t.Cleanup(func() { viper.Set("filter_json", "") })
+
+	// A config file object, with the keys Viper has lowercased.
+	viper.Set("filter_json", map[string]interface{}{
+		"or": []interface{}{
+			map[string]interface{}{"dimensions": map[string]interface{}{"key": "REGION", "values": []string{"us-east-1"}}},
+			map[string]interface{}{"tags": map[string]interface{}{"key": "env", "values": []string{"prod"}}},
+		},
+	})
+	expr, err := rawFilterFromConfig()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(expr.Or) != 2 || expr.Or[0].Dimensions.Key != types.DimensionRegion || *expr.Or[1].Tags.Key != "env" {
+		t.Errorf("unexpected expression %+v", expr)
+	}
+
+	for _, invalid := range []string{
+		`{"Dimension": {"Key": "SERVICE"}}`,
+		`{"Or": [{"Dimensions": {"Key": "SERVICE", "Values": ["x"]}}]}`,
+		`{"Dimensions": {"Values": ["x"]}, "Tags": {"Key": "env"}}`,
+		`not json`,
+	} {
+		viper.Set("filter_json", invalid)
+		if _, err := rawFilterFromConfig(); err == nil {
+			t.Errorf("expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestExcludeRecordTypes(t *testing.T) {
+	t.Cleanup(func() { viper.Set("exclude_record_types", "") })
+
+	viper.Set("exclude_record_types", []string{"credit,REFUND", "Tax", "Credit"})
+	filter, err := flagFilterFromConfig()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if filter.Not == nil || filter.Not.Dimensions.Key != types.DimensionRecordType {
+		t.Fatalf("expected NOT RECORD_TYPE, got %+v", filter)
+	}
+	if got := strings.Join(filter.Not.Dimensions.Values, ","); got != "Credit,Refund,Tax" {
+		t.Errorf("expected record types in Cost Explorer's spelling without duplicates, got %s", got)
+	}
+
+	viper.Set("exclude_record_types", "Credits")
+	if _, err := flagFilterFromConfig(); err == nil || !strings.Contains(err.Error(), "unknown record type") {
+		t.Errorf("expected an error for an unknown record type, got %v", err)
+	}
+}
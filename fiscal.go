@@ -0,0 +1,140 @@
+// File: fiscal.go
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Fiscal period units accepted after "this-fiscal-" and "last-fiscal-" in --period.
+const (
+	FiscalMonth   = "month"
+	FiscalQuarter = "quarter"
+	FiscalYear    = "year"
+)
+
+// FiscalCalendar describes the company's finance calendar.
+// Without a week pattern, fiscal months are calendar months and the year starts on the 1st of StartMonth.
+// With a pattern such as 4-4-5, the year starts on the Monday nearest the 1st of StartMonth (as ISO years do
+// for January), each quarter is 13 weeks split into months by the pattern, and the 53rd week of a long year
+// is added to the last month.
+type FiscalCalendar struct {
+	StartMonth time.Month
+	Pattern    []int // Weeks in each month of a quarter, e.g. [4 4 5]; nil for calendar months
+}
+
+// FiscalCalendarFromViper reads the fiscal.* configuration keys.
+func FiscalCalendarFromViper() (FiscalCalendar, error) {
+	cal := FiscalCalendar{StartMonth: time.Month(viper.GetInt("fiscal.start_month"))}
+	if pattern := viper.GetString("fiscal.pattern"); pattern != "" {
+		for _, part := range strings.Split(pattern, "-") {
+			weeks, err := strconv.Atoi(part)
+			if err != nil {
+				return FiscalCalendar{}, fmt.Errorf("invalid fiscal.pattern %q: expected weeks per month such as 4-4-5", pattern)
+			}
+			cal.Pattern = append(cal.Pattern, weeks)
+		}
+	}
+	return cal, cal.Validate()
+}
+
+// Validate checks the start month and that the week pattern splits a quarter into three months of 13 weeks.
+func (c FiscalCalendar) Validate() error {
+	if c.StartMonth < time.January || c.StartMonth > time.December {
+		return fmt.Errorf("fiscal.start_month must be between 1 and 12, got %d", c.StartMonth)
+	}
+	if c.Pattern == nil {
+		return nil
+	}
+	total := 0
+	for _, weeks := range c.Pattern {
+		if weeks <= 0 {
+			return fmt.Errorf("fiscal.pattern weeks must be positive, got %v", c.Pattern)
+		}
+		total += weeks
+	}
+	if len(c.Pattern) != 3 || total != 13 {
+		return fmt.Errorf("fiscal.pattern must be three months totalling 13 weeks (4-4-5, 4-5-4 or 5-4-4), got %v", c.Pattern)
+	}
+	return nil
+}
+
+// yearStart returns the first day of the fiscal year that begins in the given calendar year.
+func (c FiscalCalendar) yearStart(year int) time.Time {
+	first := time.Date(year, c.StartMonth, 1, 0, 0, 0, 0, time.UTC)
+	if c.Pattern == nil {
+		return first
+	}
+	// The Monday nearest the 1st: the week containing the 4th starts on it
+	return isoWeekStart(first.AddDate(0, 0, 3))
+}
+
+// monthBoundaries returns the 13 boundaries of the 12 fiscal months of the year containing t.
+func (c FiscalCalendar) monthBoundaries(t time.Time) []time.Time {
+	year := t.Year()
+	switch {
+	case t.Before(c.yearStart(year)):
+		year--
+	case !t.Before(c.yearStart(year + 1)):
+		// With a week pattern the next fiscal year can start in the last days of December
+		year++
+	}
+	start, end := c.yearStart(year), c.yearStart(year+1)
+
+	boundaries := []time.Time{start}
+	for i := 1; i < 12; i++ {
+		if c.Pattern == nil {
+			boundaries = append(boundaries, start.AddDate(0, i, 0))
+			continue
+		}
+		weeks := c.Pattern[(i-1)%3]
+		boundaries = append(boundaries, boundaries[i-1].AddDate(0, 0, 7*weeks))
+	}
+	return append(boundaries, end)
+}
+
+// Range returns the fiscal month, quarter or year containing t.
+func (c FiscalCalendar) Range(unit string, t time.Time) (time.Time, time.Time, error) {
+	span := map[string]int{FiscalMonth: 1, FiscalQuarter: 3, FiscalYear: 12}[unit]
+	if span == 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown fiscal unit %q (expected month, quarter or year)", unit)
+	}
+	boundaries := c.monthBoundaries(t)
+	i := 0
+	for i+span < 12 && !t.Before(boundaries[i+span]) {
+		i += span
+	}
+	return boundaries[i], boundaries[i+span], nil
+}
+
+// fiscalPeriodRange resolves "this-fiscal-<unit>" (to date, ending today) and "last-fiscal-<unit>".
+func fiscalPeriodRange(when, unit string, now time.Time, cal FiscalCalendar) (time.Time, time.Time, error) {
+	if err := cal.Validate(); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start, end, err := cal.Range(unit, today)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if when == "last" {
+		return cal.Range(unit, start.AddDate(0, 0, -1))
+	}
+	if !start.Before(today) {
+		return time.Time{}, time.Time{}, fmt.Errorf("the current %s started today, so it has no complete days yet", unit)
+	}
+	if end.After(today) {
+		end = today
+	}
+	return start, end, nil
+}
+
+func init() {
+	viper.SetDefault("fiscal.start_month", 1)
+	viper.SetDefault("fiscal.pattern", "")
+}
@@ -0,0 +1,87 @@
+// File: fiscal_test.go
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFiscalPeriodRange(t *testing.T) {
+	april := FiscalCalendar{StartMonth: time.April}
+	weeks445 := FiscalCalendar{StartMonth: time.January, Pattern: []int{4, 4, 5}}
+
+	testCases := []struct {
+		name          string
+		period        string
+		now           time.Time
+		cal           FiscalCalendar
+		expectedStart string
+		expectedEnd   string
+		expectedError bool
+	}{
+		{name: "calendar months, quarter to date", period: "this-fiscal-quarter", now: time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC), cal: april, expectedStart: "2024-04-01", expectedEnd: "2024-05-20"},
+		{name: "calendar months, previous quarter", period: "last-fiscal-quarter", now: time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC), cal: april, expectedStart: "2024-01-01", expectedEnd: "2024-04-01"},
+		{name: "calendar months, previous year", period: "last-fiscal-year", now: time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC), cal: april, expectedStart: "2023-04-01", expectedEnd: "2024-04-01"},
+		{name: "calendar months, year before the start month", period: "this-fiscal-year", now: time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC), cal: april, expectedStart: "2023-04-01", expectedEnd: "2024-02-10"},
+		{name: "4-4-5, month to date", period: "this-fiscal-month", now: time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC), cal: weeks445, expectedStart: "2024-04-29", expectedEnd: "2024-05-10"},
+		{name: "4-4-5, previous month", period: "last-fiscal-month", now: time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC), cal: weeks445, expectedStart: "2024-04-01", expectedEnd: "2024-04-29"},
+		{name: "4-4-5, five-week month ends the quarter", period: "last-fiscal-quarter", now: time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC), cal: weeks445, expectedStart: "2024-01-01", expectedEnd: "2024-04-01"},
+		{name: "4-4-5, year starts on the nearest Monday", period: "last-fiscal-year", now: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), cal: weeks445, expectedStart: "2024-01-01", expectedEnd: "2024-12-30"},
+		{name: "4-4-5, 53-week year adds a week to the last month", period: "last-fiscal-month", now: time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC), cal: weeks445, expectedStart: "2020-11-23", expectedEnd: "2021-01-04"},
+		{name: "4-4-5, last days of December belong to the next year", period: "this-fiscal-year", now: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), cal: weeks445, expectedStart: "2024-12-30", expectedEnd: "2024-12-31"},
+		{name: "4-4-5, previous month before a year that starts in December", period: "last-fiscal-month", now: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), cal: weeks445, expectedStart: "2024-11-25", expectedEnd: "2024-12-30"},
+		{name: "current period started today", period: "this-fiscal-quarter", now: time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC), cal: april, expectedError: true},
+		{name: "unknown unit", period: "this-fiscal-week", now: time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC), cal: april, expectedError: true},
+		{name: "invalid pattern", period: "this-fiscal-month", now: time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC), cal: FiscalCalendar{StartMonth: time.January, Pattern: []int{4, 4, 4}}, expectedError: true},
+		{name: "invalid start month", period: "this-fiscal-year", now: time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC), cal: FiscalCalendar{StartMonth: 13}, expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start, end, err := periodRange(tc.period, tc.now, tc.cal)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("expected an error, but got %s - %s", start.Format(AWSDateFormat), end.Format(AWSDateFormat))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error, but got: %v", err)
+			}
+			if start.Format(AWSDateFormat) != tc.expectedStart || end.Format(AWSDateFormat) != tc.expectedEnd {
+				t.Errorf("expected %s - %s, got %s - %s", tc.expectedStart, tc.expectedEnd, start.Format(AWSDateFormat), end.Format(AWSDateFormat))
+			}
+		})
+	}
+}
+
+func TestFiscalCalendarRangeAtYearEnd(t *testing.T) {
+	// The 4-4-5 fiscal year 2025 starts on Monday 2024-12-30.
+	weeks445 := FiscalCalendar{StartMonth: time.January, Pattern: []int{4, 4, 5}}
+
+	testCases := []struct {
+		date          time.Time
+		unit          string
+		expectedStart string
+		expectedEnd   string
+	}{
+		{time.Date(2024, 12, 29, 0, 0, 0, 0, time.UTC), FiscalMonth, "2024-11-25", "2024-12-30"},
+		{time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC), FiscalMonth, "2024-12-30", "2025-01-27"},
+		{time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC), FiscalQuarter, "2024-12-30", "2025-03-31"},
+		{time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC), FiscalYear, "2024-12-30", "2025-12-29"},
+		{time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), FiscalMonth, "2024-12-30", "2025-01-27"},
+		{time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), FiscalQuarter, "2024-12-30", "2025-03-31"},
+		{time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), FiscalYear, "2024-12-30", "2025-12-29"},
+	}
+
+	for _, tc := range testCases {
+		start, end, err := weeks445.Range(tc.unit, tc.date)
+		if err != nil {
+			t.Fatalf("did not expect an error, but got: %v", err)
+		}
+		if start.Format(AWSDateFormat) != tc.expectedStart || end.Format(AWSDateFormat) != tc.expectedEnd {
+			t.Errorf("%s of %s: expected %s - %s, got %s - %s", tc.unit, tc.date.Format(AWSDateFormat),
+				tc.expectedStart, tc.expectedEnd, start.Format(AWSDateFormat), end.Format(AWSDateFormat))
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// File: focus.go
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	FormatFOCUS        = "focus" // FOCUS 1.0 CSV, see https://focus.finops.org
+	FOCUSProviderName  = "AWS"   // ProviderName, PublisherName and InvoiceIssuerName of exported rows
+	FOCUSChargeUsage   = "Usage" // ChargeCategory of exported rows
+	FOCUSOtherCategory = "Other" // ServiceCategory of services no pattern matches
+	focusTimeLayout    = time.RFC3339
+)
+
+// focusColumns are the FOCUS 1.0 columns written by writeFOCUS, in order. Columns that service
+// totals cannot fill, such as ListCost or ResourceId, are left out.
+var focusColumns = []string{
+	"BilledCost", "EffectiveCost", "BillingCurrency",
+	"BillingPeriodStart", "BillingPeriodEnd", "ChargePeriodStart", "ChargePeriodEnd",
+	"ChargeCategory", "ChargeDescription",
+	"ProviderName", "PublisherName", "InvoiceIssuerName",
+	"ServiceCategory", "ServiceName",
+}
+
+// focusServiceCategories maps AWS service names to FOCUS ServiceCategory values. The first match wins.
+var focusServiceCategories = []struct {
+	Category string
+	Pattern  *regexp.Regexp
+}{
+	{"AI and Machine Learning", regexp.MustCompile(`SageMaker|Bedrock|Comprehend|Rekognition|Textract|Translate|Polly|Transcribe|Lex|Kendra|Personalize|Forecast`)},
+	{"Databases", regexp.MustCompile(`Relational Database|DynamoDB|ElastiCache|Neptune|DocumentDB|Redshift|Keyspaces|MemoryDB|Timestream`)},
+	{"Analytics", regexp.MustCompile(`Athena|Glue|Kinesis|EMR|Elastic MapReduce|OpenSearch|QuickSight|Lake Formation|Managed Streaming for Apache Kafka`)},
+	{"Storage", regexp.MustCompile(`Simple Storage Service|Elastic File System|FSx|Glacier|Backup|Storage Gateway|Elastic Block Store`)},
+	{"Networking", regexp.MustCompile(`Data Transfer|CloudFront|Route 53|Virtual Private Cloud|VPC|Direct Connect|Elastic Load Balancing|Global Accelerator|API Gateway|Transit Gateway`)},
+	{"Security", regexp.MustCompile(`GuardDuty|Security Hub|Inspector|Macie|WAF|Shield|Key Management Service|Secrets Manager|Certificate Manager|Detective`)},
+	{"Identity", regexp.MustCompile(`Cognito|Directory Service|IAM Identity Center`)},
+	{"Management and Governance", regexp.MustCompile(`CloudWatch|CloudTrail|Config|Systems Manager|X-Ray|Managed Grafana|Managed Service for Prometheus|Support|Cost Explorer|Control Tower`)},
+	{"Integration", regexp.MustCompile(`Simple Queue Service|Simple Notification Service|EventBridge|Step Functions|MQ`)},
+	{"Developer Tools", regexp.MustCompile(`CodeBuild|CodePipeline|CodeCommit|CodeArtifact|CodeDeploy|Cloud9`)},
+	{"Compute", regexp.MustCompile(`Elastic Compute Cloud|EC2|Lambda|Fargate|Elastic Container|Elastic Kubernetes|Lightsail|Batch|App Runner|Elastic Beanstalk`)},
+}
+
+// focusServiceCategory returns the FOCUS ServiceCategory of an AWS service name.
+func focusServiceCategory(service string) string {
+	for _, c := range focusServiceCategories {
+		if c.Pattern.MatchString(service) {
+			return c.Category
+		}
+	}
+	return FOCUSOtherCategory
+}
+
+// parsePeriodTime parses a period boundary as returned by Cost Explorer, a date or an hourly timestamp.
+func parsePeriodTime(value string) (time.Time, error) {
+	if t, err := time.Parse(AWSDateFormat, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(AWSHourFormat, value)
+}
+
+// writeFOCUS writes costs as FOCUS 1.0 CSV, one row per service and period. Rows are service totals,
+// so credits, refunds and taxes are netted into them, and cost is both billed and effective cost.
+func writeFOCUS(w io.Writer, costs []CostByTime) error {
+	cw := csv.NewWriter(w)
+	cw.Write(focusColumns)
+	for _, period := range costs {
+		start, err := parsePeriodTime(period.Start)
+		if err != nil {
+			return fmt.Errorf("unexpected period start %q: %w", period.Start, err)
+		}
+		end, err := parsePeriodTime(period.End)
+		if err != nil {
+			return fmt.Errorf("unexpected period end %q: %w", period.End, err)
+		}
+		billingStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+		for _, serviceCost := range period.Groups {
+			cw.Write([]string{
+				serviceCost.Amount, serviceCost.Amount, serviceCost.Unit,
+				billingStart.Format(focusTimeLayout), billingStart.AddDate(0, 1, 0).Format(focusTimeLayout),
+				start.Format(focusTimeLayout), end.Format(focusTimeLayout),
+				FOCUSChargeUsage, serviceCost.Key,
+				FOCUSProviderName, FOCUSProviderName, FOCUSProviderName,
+				focusServiceCategory(serviceCost.Key), serviceCost.Key,
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export AWS costs in a standard format for other FinOps tools.",
+	Long: `Retrieves costs from Cost Explorer like 'get' and writes them to a file in --format. The only format is
+focus, CSV with FOCUS 1.0 column names, one row per service and period:
+
+  cost-tracker export --granularity daily --out costs.focus.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		format, _ := cmd.Flags().GetString("format")
+		if format != FormatFOCUS {
+			logger.Fatalw("Unsupported export format", "format", format, "supported", FormatFOCUS)
+		}
+		path, _ := cmd.Flags().GetString("out")
+
+		tracker, query, _ := setupReport(ctx)
+		costs, err := tracker.GetCosts(ctx, query)
+		if err != nil {
+			errMsg := fmt.Sprintf("Error getting costs: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error getting costs", "error", err)
+		}
+		if explaining() {
+			logger.Infow("Explaining the query plan. Skipping the export.", "path", path)
+			return
+		}
+		if path, err = artifactPath(path, cmd.Name(), query, time.Now()); err != nil {
+			logger.Fatalw("Invalid --out", "error", err)
+		}
+
+		f, err := os.Create(path)
+		if err != nil {
+			logger.Fatalw("Failed to create export file", "path", path, "error", err)
+		}
+		err = writeFOCUS(f, costs)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			logger.Fatalw("Failed to write export file", "path", path, "error", err)
+		}
+		if activeManifest != nil {
+			activeManifest.Artifacts = []string{path}
+		}
+		logger.Infow("Exported costs", "path", path, "format", format, "periods", len(costs))
+	},
+}
+
+func init() {
+	exportCmd.Flags().String("format", FormatFOCUS, "Export format (focus)")
+	exportCmd.Flags().String("out", "costs.focus.csv", "Path of the file to write; may use {{.Command}}, {{.Date}}, {{.Start}} and {{.End}}")
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,57 @@
+// File: focus_test.go
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestFocusServiceCategory(t *testing.T) {
+	testCases := map[string]string{
+		"Amazon Elastic Compute Cloud - Compute": "Compute",
+		"EC2 - Other":                            "Compute",
+		"Amazon Relational Database Service":     "Databases",
+		"Amazon Simple Storage Service":          "Storage",
+		"AmazonCloudWatch":                       "Management and Governance",
+		"AWS Data Transfer":                      "Networking",
+		"Amazon SageMaker":                       "AI and Machine Learning",
+		"Tax":                                    FOCUSOtherCategory,
+	}
+	for service, expected := range testCases {
+		if got := focusServiceCategory(service); got != expected {
+			t.Errorf("service %q categorized as %q, expected %q", service, got, expected)
+		}
+	}
+}
+
+func TestWriteFOCUS(t *testing.T) {
+	costs := []CostByTime{
+		{Start: "2024-01-31", End: "2024-02-01", Groups: []GroupedCost{{Key: "AWS Lambda", Amount: "1.25", Unit: "USD"}}},
+		{Start: "2024-02-01T05:00:00Z", End: "2024-02-01T06:00:00Z", Groups: []GroupedCost{{Key: "Amazon Simple Storage Service", Amount: "0.10", Unit: "USD"}}},
+	}
+	var buf bytes.Buffer
+	if err := writeFOCUS(&buf, costs); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read the CSV back: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected a header and two rows, got %d rows", len(rows))
+	}
+	row := make(map[string]string)
+	for i, column := range rows[0] {
+		row[column] = rows[1][i]
+	}
+	if row["BilledCost"] != "1.25" || row["BillingCurrency"] != "USD" || row["ServiceCategory"] != "Compute" {
+		t.Errorf("unexpected row: %v", row)
+	}
+	if row["BillingPeriodStart"] != "2024-01-01T00:00:00Z" || row["BillingPeriodEnd"] != "2024-02-01T00:00:00Z" || row["ChargePeriodEnd"] != "2024-02-01T00:00:00Z" {
+		t.Errorf("unexpected billing or charge period: %v", row)
+	}
+	if rows[2][5] != "2024-02-01T05:00:00Z" {
+		t.Errorf("expected the hourly charge period start to be kept, got %q", rows[2][5])
+	}
+}
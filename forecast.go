@@ -0,0 +1,416 @@
+// File: forecast.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+)
+
+// WeeklySeason is the season length, in days, of the seasonal forecast models.
+const WeeklySeason = 7
+
+// ForecastModel projects daily spend from daily history. Models are listed in forecastModels.
+type ForecastModel interface {
+	Name() string
+	MinHistory() int // Days of history the model needs
+	Forecast(history []float64, horizon int) []float64
+}
+
+// forecastModels are the models the forecast command can run, in display order.
+var forecastModels = []ForecastModel{
+	naiveModel{},
+	seasonalNaiveModel{Season: WeeklySeason},
+	holtWintersModel{Season: WeeklySeason, Alpha: 0.3, Beta: 0.05, Gamma: 0.2},
+}
+
+// naiveModel repeats the last day's spend.
+type naiveModel struct{}
+
+func (naiveModel) Name() string    { return "naive" }
+func (naiveModel) MinHistory() int { return 1 }
+
+func (naiveModel) Forecast(history []float64, horizon int) []float64 {
+	forecast := make([]float64, horizon)
+	for i := range forecast {
+		forecast[i] = history[len(history)-1]
+	}
+	return forecast
+}
+
+// seasonalNaiveModel repeats the last season, so each weekday gets that weekday's spend last week.
+type seasonalNaiveModel struct {
+	Season int
+}
+
+func (seasonalNaiveModel) Name() string      { return "seasonal-naive" }
+func (m seasonalNaiveModel) MinHistory() int { return m.Season }
+
+func (m seasonalNaiveModel) Forecast(history []float64, horizon int) []float64 {
+	last := history[len(history)-m.Season:]
+	forecast := make([]float64, horizon)
+	for i := range forecast {
+		forecast[i] = last[i%m.Season]
+	}
+	return forecast
+}
+
+// holtWintersModel is additive Holt-Winters (triple exponential smoothing) with smoothing factors
+// Alpha for the level, Beta for the trend and Gamma for the season.
+type holtWintersModel struct {
+	Season             int
+	Alpha, Beta, Gamma float64
+}
+
+func (holtWintersModel) Name() string      { return "holt-winters" }
+func (m holtWintersModel) MinHistory() int { return 2 * m.Season }
+
+func (m holtWintersModel) Forecast(history []float64, horizon int) []float64 {
+	// Initialise the level and trend from the first two seasons and the season from the first.
+	var first, second float64
+	for i := 0; i < m.Season; i++ {
+		first += history[i]
+		second += history[m.Season+i]
+	}
+	level := first / float64(m.Season)
+	trend := (second - first) / float64(m.Season*m.Season)
+	season := make([]float64, m.Season)
+	for i := range season {
+		season[i] = history[i] - level
+	}
+
+	for t, y := range history {
+		s := season[t%m.Season]
+		previous := level
+		level = m.Alpha*(y-s) + (1-m.Alpha)*(level+trend)
+		trend = m.Beta*(level-previous) + (1-m.Beta)*trend
+		season[t%m.Season] = m.Gamma*(y-level) + (1-m.Gamma)*s
+	}
+
+	forecast := make([]float64, horizon)
+	for h := range forecast {
+		// Spend cannot go below zero, however steep the trend.
+		forecast[h] = max(0, level+float64(h+1)*trend+season[(len(history)+h)%m.Season])
+	}
+	return forecast
+}
+
+// selectForecastModels returns the models named in names, in display order, or every model if names is empty.
+func selectForecastModels(names []string) ([]ForecastModel, error) {
+	if len(names) == 0 {
+		return forecastModels, nil
+	}
+	var selected []ForecastModel
+	for _, model := range forecastModels {
+		for _, name := range names {
+			if name == model.Name() {
+				selected = append(selected, model)
+				break
+			}
+		}
+	}
+	if len(selected) != len(names) {
+		var known []string
+		for _, model := range forecastModels {
+			known = append(known, model.Name())
+		}
+		return nil, fmt.Errorf("unknown forecast model in %s (supported: %s)", strings.Join(names, ", "), strings.Join(known, ", "))
+	}
+	return selected, nil
+}
+
+// DailyHistory is the total spend of each day from Start, with days without data as zero.
+type DailyHistory struct {
+	Start   time.Time
+	Amounts []float64
+	Unit    string
+}
+
+// GetDailyHistory returns the daily total spend matching filter between start and end, both truncated
+// to UTC days.
+func (ct *CostTracker) GetDailyHistory(ctx context.Context, filter *types.Expression, start, end time.Time) (*DailyHistory, error) {
+	start, end = start.UTC().Truncate(24*time.Hour), end.UTC().Truncate(24*time.Hour)
+	if !end.After(start) {
+		return nil, fmt.Errorf("the history must cover at least one whole day")
+	}
+	costs, err := ct.GetCosts(ctx, CostQuery{Start: start, End: end, Filter: filter, Granularity: types.GranularityDaily})
+	if err != nil {
+		return nil, err
+	}
+	history := &DailyHistory{Start: start, Amounts: make([]float64, int(end.Sub(start).Hours()/24))}
+	for _, period := range costs {
+		day, err := time.Parse(AWSDateFormat, period.Start)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected period start %q: %w", period.Start, err)
+		}
+		i := int(day.Sub(start).Hours() / 24)
+		if i < 0 || i >= len(history.Amounts) {
+			continue
+		}
+		for _, serviceCost := range period.Groups {
+			amount, err := strconv.ParseFloat(serviceCost.Amount, 64)
+			if err != nil {
+				logger.Warnw("Skipping unparseable cost amount",
+					"service", serviceCost.Key,
+					"amount", serviceCost.Amount)
+				continue
+			}
+			history.Amounts[i] += amount
+			history.Unit = serviceCost.Unit
+		}
+	}
+	return history, nil
+}
+
+// ForecastComparison holds each model's daily projection over the same horizon, for display side by side.
+type ForecastComparison struct {
+	Start     time.Time // First forecast day, the day after the history ends
+	Unit      string
+	Models    []string
+	Forecasts map[string][]float64 // model -> daily amounts
+	Skipped   []string             // Models that need more history than there is
+}
+
+// compareForecasts runs each model over history for horizon days. Models that need more history
+// than there is are skipped rather than failing the comparison.
+func compareForecasts(history *DailyHistory, models []ForecastModel, horizon int) *ForecastComparison {
+	c := &ForecastComparison{
+		Start:     history.Start.AddDate(0, 0, len(history.Amounts)),
+		Unit:      history.Unit,
+		Forecasts: make(map[string][]float64),
+	}
+	for _, model := range models {
+		if len(history.Amounts) < model.MinHistory() {
+			c.Skipped = append(c.Skipped, fmt.Sprintf("%s (needs %d days)", model.Name(), model.MinHistory()))
+			continue
+		}
+		c.Models = append(c.Models, model.Name())
+		c.Forecasts[model.Name()] = model.Forecast(history.Amounts, horizon)
+	}
+	return c
+}
+
+// displayForecastComparison writes one row per forecast day with a column per model, then each model's total.
+func displayForecastComparison(w io.Writer, c *ForecastComparison, historyDays, horizon int) {
+	fmt.Fprintf(w, "AWS spend forecast for the next %d days from %d days of history (%s):\n", horizon, historyDays, c.Unit)
+	fmt.Fprintln(w, "=====================================")
+	if len(c.Skipped) > 0 {
+		fmt.Fprintf(w, "Skipped models: %s. Use a longer --days.\n", strings.Join(c.Skipped, ", "))
+	}
+	if len(c.Models) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "%-12s", "Date")
+	for _, model := range c.Models {
+		fmt.Fprintf(w, " %14s", model)
+	}
+	fmt.Fprintln(w)
+	totals := make(map[string]float64)
+	for day := 0; day < horizon; day++ {
+		fmt.Fprintf(w, "%-12s", c.Start.AddDate(0, 0, day).Format(AWSDateFormat))
+		for _, model := range c.Models {
+			fmt.Fprintf(w, " %14.2f", c.Forecasts[model][day])
+			totals[model] += c.Forecasts[model][day]
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%-12s", "Total")
+	for _, model := range c.Models {
+		fmt.Fprintf(w, " %14.2f", totals[model])
+	}
+	fmt.Fprintln(w)
+}
+
+// forecastMetrics maps --metric values to the metrics GetCostForecast takes.
+var forecastMetrics = map[string]types.Metric{
+	MetricBlendedCost:      types.MetricBlendedCost,
+	MetricUnblendedCost:    types.MetricUnblendedCost,
+	MetricAmortizedCost:    types.MetricAmortizedCost,
+	MetricNetAmortizedCost: types.MetricNetAmortizedCost,
+	MetricNetUnblendedCost: types.MetricNetUnblendedCost,
+	MetricUsageQuantity:    types.MetricUsageQuantity,
+}
+
+// ForecastPeriod is Cost Explorer's forecast of one period, or of the whole range.
+type ForecastPeriod struct {
+	Start string
+	Mean  float64
+	Lower float64 // Bounds of the prediction interval
+	Upper float64
+}
+
+// CostExplorerForecast is the result of GetCostForecast.
+type CostExplorerForecast struct {
+	Start, End time.Time
+	Metric     string
+	Level      int // Prediction interval, in percent
+	Unit       string
+	Periods    []ForecastPeriod
+	Total      ForecastPeriod // Sum of the periods
+}
+
+// GetCostForecast asks Cost Explorer to forecast the spend matching q from q.Start to q.End at q's
+// granularity, monthly or daily, with a prediction interval of level percent (51 to 99). Cost Explorer
+// only forecasts from today, and supports fewer filters than GetCostAndUsage.
+func (ct *CostTracker) GetCostForecast(ctx context.Context, q CostQuery, level int) (*CostExplorerForecast, error) {
+	if !q.Start.Before(q.End) {
+		return nil, fmt.Errorf("start date %s must be before end date %s", q.Start.Format(AWSDateFormat), q.End.Format(AWSDateFormat))
+	}
+	if level < 51 || level > 99 {
+		return nil, fmt.Errorf("prediction interval must be between 51 and 99 percent, got %d", level)
+	}
+	granularity := q.Granularity
+	switch granularity {
+	case "":
+		granularity = GranularityMonthly
+	case GranularityMonthly, types.GranularityDaily:
+	default:
+		return nil, fmt.Errorf("Cost Explorer forecasts monthly or daily, not %s", strings.ToLower(string(granularity)))
+	}
+	metric, ok := forecastMetrics[q.metric()]
+	if !ok {
+		return nil, fmt.Errorf("Cost Explorer cannot forecast %s", q.metric())
+	}
+
+	result, err := ct.client.GetCostForecast(ctx, &costexplorer.GetCostForecastInput{
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(q.Start.Format(AWSDateFormat)),
+			End:   aws.String(q.End.Format(AWSDateFormat)),
+		},
+		Filter:                  q.Filter,
+		Granularity:             granularity,
+		Metric:                  metric,
+		PredictionIntervalLevel: aws.Int32(int32(level)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost forecast from AWS Cost Explorer: %w", err)
+	}
+
+	forecast := &CostExplorerForecast{Start: q.Start, End: q.End, Metric: q.metric(), Level: level, Total: ForecastPeriod{Start: q.Start.Format(AWSDateFormat)}}
+	if result.Total != nil {
+		forecast.Unit = aws.ToString(result.Total.Unit)
+	}
+	parse := func(value *string) float64 {
+		amount, err := strconv.ParseFloat(aws.ToString(value), 64)
+		if err != nil {
+			return 0
+		}
+		return amount
+	}
+	for _, r := range result.ForecastResultsByTime {
+		period := ForecastPeriod{
+			Mean:  parse(r.MeanValue),
+			Lower: parse(r.PredictionIntervalLowerBound),
+			Upper: parse(r.PredictionIntervalUpperBound),
+		}
+		if r.TimePeriod != nil {
+			period.Start = aws.ToString(r.TimePeriod.Start)
+		}
+		forecast.Periods = append(forecast.Periods, period)
+		forecast.Total.Mean += period.Mean
+		forecast.Total.Lower += period.Lower
+		forecast.Total.Upper += period.Upper
+	}
+	return forecast, nil
+}
+
+// displayCostExplorerForecast writes each forecast period with its prediction interval, then the total.
+// The total's bounds are the sums of the periods' bounds, so they are wider than a prediction interval of
+// the total would be.
+func displayCostExplorerForecast(w io.Writer, f *CostExplorerForecast) {
+	fmt.Fprintf(w, "Cost Explorer forecast of %s from %s to %s, %d%% prediction interval (%s):\n",
+		f.Metric, f.Start.Format(AWSDateFormat), f.End.AddDate(0, 0, -1).Format(AWSDateFormat), f.Level, f.Unit)
+	fmt.Fprintln(w, "=====================================")
+	if len(f.Periods) == 0 {
+		fmt.Fprintln(w, "No forecast returned for the specified period.")
+		return
+	}
+	fmt.Fprintf(w, "%-12s %14s %14s %14s\n", "Period", "Forecast", "Lower", "Upper")
+	for _, p := range f.Periods {
+		fmt.Fprintf(w, "%-12s %14.2f %14.2f %14.2f\n", p.Start, p.Mean, p.Lower, p.Upper)
+	}
+	fmt.Fprintf(w, "%-12s %14.2f %14.2f %14.2f\n", "Total", f.Total.Mean, f.Total.Lower, f.Total.Upper)
+}
+
+var forecastCmd = &cobra.Command{
+	Use:   "forecast",
+	Short: "Project daily AWS spend with several forecasting models side by side.",
+	Long: `Retrieves the daily spend of the last --days (or --period) and projects it --horizon days ahead with each
+forecasting model, so their projections can be compared:
+
+  naive           repeats the last day's spend
+  seasonal-naive  repeats last week's spend, weekday by weekday
+  holt-winters    additive Holt-Winters with a weekly season; needs at least 14 days of history
+
+Unlike Cost Explorer's own forecast, the models work with any --filter. Use --model to run only some of them:
+
+  cost-tracker forecast --days 56 --horizon 14 --model holt-winters
+
+With --cost-explorer, Cost Explorer's GetCostForecast projects --metric from today --horizon days ahead instead,
+monthly or, with --granularity daily, per day, with a --confidence prediction interval around each period. The
+rest of the current month is its first monthly period, so this answers whether this month's budget will hold:
+
+  cost-tracker forecast --cost-explorer --horizon 90 --confidence 90`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		horizon, _ := cmd.Flags().GetInt("horizon")
+		if horizon <= 0 {
+			logger.Fatalw("Invalid --horizon, must be a positive number of days", "horizon", horizon)
+		}
+		if useCostExplorer, _ := cmd.Flags().GetBool("cost-explorer"); useCostExplorer {
+			confidence, _ := cmd.Flags().GetInt("confidence")
+			tracker, query, _ := setupReport(ctx)
+			query.Start = time.Now().UTC().Truncate(24 * time.Hour)
+			query.End = query.Start.AddDate(0, 0, horizon)
+			forecast, err := tracker.GetCostForecast(ctx, query, confidence)
+			if err != nil {
+				errMsg := fmt.Sprintf("Error getting cost forecast: %v", err)
+				sendSlackNotification("Cost Tracker Error: " + errMsg)
+				logger.Fatalw("Error getting cost forecast", "error", err)
+			}
+
+			logger.Info("Displaying Cost Explorer forecast to console.")
+			out, done := consoleWriter()
+			displayCostExplorerForecast(out, forecast)
+			done()
+			return
+		}
+		names, _ := cmd.Flags().GetStringArray("model")
+		models, err := selectForecastModels(names)
+		if err != nil {
+			logger.Fatalw("Invalid --model", "error", err)
+		}
+
+		tracker, query, _ := setupReport(ctx)
+		history, err := tracker.GetDailyHistory(ctx, query.Filter, query.Start, query.End)
+		if err != nil {
+			errMsg := fmt.Sprintf("Error getting daily history: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error getting daily history", "error", err)
+		}
+
+		logger.Info("Displaying forecasts to console.")
+		out, done := consoleWriter()
+		displayForecastComparison(out, compareForecasts(history, models, horizon), len(history.Amounts), horizon)
+		done()
+	},
+}
+
+func init() {
+	forecastCmd.Flags().Int("horizon", 30, "Number of days to forecast")
+	forecastCmd.Flags().StringArray("model", nil, "Forecast model to run; repeat for several (default: all models)")
+	forecastCmd.Flags().Bool("cost-explorer", false, "Use Cost Explorer's GetCostForecast from today instead of the local models")
+	forecastCmd.Flags().Int("confidence", 80, "Prediction interval of --cost-explorer forecasts, in percent (51-99)")
+	rootCmd.AddCommand(forecastCmd)
+}
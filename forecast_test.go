@@ -0,0 +1,148 @@
+// File: forecast_test.go
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+// weeklyHistory returns weeks of daily spend that is 100 on weekdays and 20 at weekends, growing by growth a day.
+func weeklyHistory(weeks int, growth float64) []float64 {
+	var history []float64
+	for day := 0; day < weeks*WeeklySeason; day++ {
+		amount := 100.0
+		if day%WeeklySeason >= 5 {
+			amount = 20
+		}
+		history = append(history, amount+growth*float64(day))
+	}
+	return history
+}
+
+func TestForecastModels(t *testing.T) {
+	history := weeklyHistory(4, 0)
+
+	if got := (naiveModel{}).Forecast(history, 2); got[0] != 20 || got[1] != 20 {
+		t.Errorf("expected naive to repeat the last day, got %v", got)
+	}
+	if got := (seasonalNaiveModel{Season: WeeklySeason}).Forecast(history, 7); got[0] != 100 || got[5] != 20 {
+		t.Errorf("expected seasonal-naive to repeat last week, got %v", got)
+	}
+
+	hw := holtWintersModel{Season: WeeklySeason, Alpha: 0.3, Beta: 0.05, Gamma: 0.2}
+	got := hw.Forecast(history, 7)
+	for day, want := range history[:7] {
+		if math.Abs(got[day]-want) > 1 {
+			t.Errorf("expected holt-winters to follow a steady weekly pattern, got %v", got)
+			break
+		}
+	}
+	growing := weeklyHistory(4, 1)
+	if got := hw.Forecast(growing, 7); got[0] <= growing[len(growing)-7] {
+		t.Errorf("expected holt-winters to project a trend above last week, got %v", got)
+	}
+}
+
+func TestSelectForecastModels(t *testing.T) {
+	models, err := selectForecastModels([]string{"holt-winters", "naive"})
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(models) != 2 || models[0].Name() != "naive" || models[1].Name() != "holt-winters" {
+		t.Errorf("expected naive and holt-winters in display order, got %v", models)
+	}
+	if _, err := selectForecastModels([]string{"arima"}); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+}
+
+func TestGetDailyHistoryAndCompare(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			// The second of the ten days has no data.
+			var results []types.ResultByTime
+			for _, day := range []string{"2024-03-01", "2024-03-03", "2024-03-10"} {
+				results = append(results, types.ResultByTime{
+					TimePeriod: &types.DateInterval{Start: aws.String(day), End: aws.String(day)},
+					Groups: []types.Group{{
+						Keys:    []string{"Amazon EC2"},
+						Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("10"), Unit: aws.String("USD")}},
+					}},
+				})
+			}
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: results}, nil
+		},
+	}}
+
+	start := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	history, err := tracker.GetDailyHistory(context.Background(), nil, start, start.AddDate(0, 0, 10))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(history.Amounts) != 10 || history.Amounts[0] != 10 || history.Amounts[1] != 0 || history.Amounts[9] != 10 {
+		t.Errorf("unexpected daily amounts: %v", history.Amounts)
+	}
+
+	c := compareForecasts(history, forecastModels, 3)
+	if c.Start.Format(AWSDateFormat) != "2024-03-11" {
+		t.Errorf("expected the forecast to start the day after the history, got %s", c.Start)
+	}
+	if len(c.Models) != 2 || len(c.Skipped) != 1 {
+		t.Errorf("expected holt-winters to be skipped for 10 days of history, got models %v, skipped %v", c.Models, c.Skipped)
+	}
+}
+
+func TestGetCostForecast(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostForecastFunc: func(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+			if params.Metric != types.MetricAmortizedCost || aws.ToInt32(params.PredictionIntervalLevel) != 90 || params.Granularity != types.GranularityMonthly {
+				t.Errorf("unexpected request: metric %s, level %d, granularity %s", params.Metric, aws.ToInt32(params.PredictionIntervalLevel), params.Granularity)
+			}
+			result := func(start, mean, lower, upper string) types.ForecastResult {
+				return types.ForecastResult{
+					TimePeriod:                   &types.DateInterval{Start: aws.String(start)},
+					MeanValue:                    aws.String(mean),
+					PredictionIntervalLowerBound: aws.String(lower),
+					PredictionIntervalUpperBound: aws.String(upper),
+				}
+			}
+			return &costexplorer.GetCostForecastOutput{
+				Total:                 &types.MetricValue{Amount: aws.String("300"), Unit: aws.String("USD")},
+				ForecastResultsByTime: []types.ForecastResult{result("2024-03-20", "100", "90", "120"), result("2024-04-01", "200", "150", "260")},
+			}, nil
+		},
+	}}
+	q := CostQuery{
+		Start:   time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC),
+		End:     time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Metrics: []string{MetricAmortizedCost},
+	}
+
+	forecast, err := tracker.GetCostForecast(context.Background(), q, 90)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(forecast.Periods) != 2 || forecast.Unit != "USD" {
+		t.Fatalf("expected two periods in USD, got %+v", forecast)
+	}
+	if total := forecast.Total; total.Mean != 300 || total.Lower != 240 || total.Upper != 380 {
+		t.Errorf("expected the periods summed to 300 between 240 and 380, got %+v", total)
+	}
+
+	if _, err := tracker.GetCostForecast(context.Background(), q, 50); err == nil {
+		t.Error("expected an error for a 50% prediction interval")
+	}
+	q.Granularity = GranularityWeekly
+	if _, err := tracker.GetCostForecast(context.Background(), q, 80); err == nil {
+		t.Error("expected an error for weekly granularity")
+	}
+}
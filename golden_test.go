@@ -0,0 +1,87 @@
+// File: golden_test.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// update rewrites the golden files with the current output: go test -run TestGolden -update
+var update = flag.Bool("update", false, "update golden files in testdata/golden")
+
+// loadFixture decodes a JSON fixture from testdata/fixtures into v.
+// The fixtures are plain JSON so downstream users can feed the same inputs to their own tooling.
+func loadFixture(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "fixtures", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("failed to decode fixture %s: %v", name, err)
+	}
+}
+
+// assertGolden compares got with testdata/golden/<name>.golden, rewriting the file when -update is set.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("failed to update golden file %s: %v", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file %s (run with -update to create it): %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output does not match %s (run with -update if the change is intended)\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestGoldenOutputs(t *testing.T) {
+	var costs []CostByTime
+	loadFixture(t, "costs.json", &costs)
+	var matrix RegionCostMatrix
+	loadFixture(t, "region_matrix.json", &matrix)
+
+	testCases := []struct {
+		name   string
+		config map[string]interface{} // Viper overrides for the render, restored afterwards
+		render func(buf *bytes.Buffer)
+	}{
+		{name: "console", render: func(buf *bytes.Buffer) { displayCosts(buf, costs, 45) }},
+		{name: "console_empty", render: func(buf *bytes.Buffer) { displayCosts(buf, nil, 30) }},
+		{name: "console_no_trunc", config: map[string]interface{}{"no_trunc": true}, render: func(buf *bytes.Buffer) { displayCosts(buf, costs, 45) }},
+		{name: "console_plain", config: map[string]interface{}{"plain": true}, render: func(buf *bytes.Buffer) {
+			plain := &plainWriter{w: buf}
+			displayCosts(plain, costs, 45)
+			plain.Flush()
+		}},
+		{name: "costs_json", render: func(buf *bytes.Buffer) { renderCostsJSON(buf, costs, 45) }},
+		{name: "costs_json_empty", render: func(buf *bytes.Buffer) { renderCostsJSON(buf, nil, 30) }},
+		{name: "region_matrix", render: func(buf *bytes.Buffer) { displayRegionMatrix(buf, &matrix, 45) }},
+		{name: "region_matrix_max_rows", config: map[string]interface{}{"max_rows": 2}, render: func(buf *bytes.Buffer) { displayRegionMatrix(buf, &matrix, 45) }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, value := range tc.config {
+				previous := viper.Get(key)
+				viper.Set(key, value)
+				t.Cleanup(func() { viper.Set(key, previous) })
+			}
+			var buf bytes.Buffer
+			tc.render(&buf)
+			assertGolden(t, tc.name, buf.Bytes())
+		})
+	}
+}
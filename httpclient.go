@@ -0,0 +1,105 @@
+// File: httpclient.go
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/spf13/viper"
+)
+
+// HTTPConfig configures the HTTP client shared by AWS SDK calls and webhook posts.
+type HTTPConfig struct {
+	ProxyURL          string        // Empty uses HTTPS_PROXY/HTTP_PROXY/NO_PROXY from the environment
+	CABundle          string        // PEM file trusted in addition to the system roots, e.g. a TLS-intercepting proxy's CA
+	Timeout           time.Duration // Whole-request timeout; 0 disables it
+	KeepAlive         time.Duration // TCP keep-alive period
+	DisableKeepAlives bool          // Use a new connection for every request
+}
+
+// HTTPConfigFromViper reads the http.* configuration keys.
+func HTTPConfigFromViper() HTTPConfig {
+	return HTTPConfig{
+		ProxyURL:          viper.GetString("http.proxy_url"),
+		CABundle:          viper.GetString("http.ca_bundle"),
+		Timeout:           viper.GetDuration("http.timeout"),
+		KeepAlive:         viper.GetDuration("http.keep_alive"),
+		DisableKeepAlives: viper.GetBool("http.disable_keep_alives"),
+	}
+}
+
+// transportOptions returns a function that applies c to an http.Transport. It returns an error if
+// the proxy URL is invalid or the CA bundle cannot be read or contains no certificates.
+func (c HTTPConfig) transportOptions() (func(*http.Transport), error) {
+	var proxy *url.URL
+	if c.ProxyURL != "" {
+		var err error
+		proxy, err = url.Parse(c.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid http.proxy_url %q: expected e.g. http://proxy.example.com:3128", c.ProxyURL)
+		}
+	}
+
+	var roots *x509.CertPool
+	if c.CABundle != "" {
+		pem, err := os.ReadFile(c.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read http.ca_bundle: %w", err)
+		}
+		roots, err = x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("http.ca_bundle %s contains no PEM certificates", c.CABundle)
+		}
+	}
+
+	return func(transport *http.Transport) {
+		transport.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: c.KeepAlive}).DialContext
+		transport.DisableKeepAlives = c.DisableKeepAlives
+		if proxy != nil {
+			transport.Proxy = http.ProxyURL(proxy)
+		}
+		if roots != nil {
+			// Clone the pool, since the AWS SDK appends AWS_CA_BUNDLE to it
+			transport.TLSClientConfig = &tls.Config{RootCAs: roots.Clone(), MinVersion: tls.VersionTLS12}
+		}
+	}, nil
+}
+
+// NewHTTPClient builds an HTTP client from c, or returns the error from transportOptions.
+func (c HTTPConfig) NewHTTPClient() (*http.Client, error) {
+	configure, err := c.transportOptions()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	configure(transport)
+	return &http.Client{Transport: transport, Timeout: c.Timeout}, nil
+}
+
+// NewSDKHTTPClient is NewHTTPClient for the AWS SDK. The SDK can only apply settings of its own, such
+// as AWS_CA_BUNDLE, to its buildable client.
+func (c HTTPConfig) NewSDKHTTPClient() (*awshttp.BuildableClient, error) {
+	configure, err := c.transportOptions()
+	if err != nil {
+		return nil, err
+	}
+	return awshttp.NewBuildableClient().WithTransportOptions(configure).WithTimeout(c.Timeout), nil
+}
+
+func init() {
+	viper.SetDefault("http.proxy_url", "")
+	viper.SetDefault("http.ca_bundle", "")
+	viper.SetDefault("http.timeout", "60s")
+	viper.SetDefault("http.keep_alive", "30s")
+	viper.SetDefault("http.disable_keep_alives", false)
+}
@@ -0,0 +1,118 @@
+// File: httpclient_test.go
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+func TestNewHTTPClientErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "not-pem.crt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config HTTPConfig
+	}{
+		{name: "proxy without host", config: HTTPConfig{ProxyURL: "proxy.example.com"}},
+		{name: "missing CA bundle", config: HTTPConfig{CABundle: filepath.Join(dir, "missing.crt")}},
+		{name: "CA bundle without certificates", config: HTTPConfig{CABundle: notPEM}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.config.NewHTTPClient(); err == nil {
+				t.Errorf("expected an error, but got nil")
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientProxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.Host
+	}))
+	defer proxy.Close()
+
+	client, err := HTTPConfig{ProxyURL: proxy.URL}.NewHTTPClient()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	resp, err := client.Get("http://hooks.slack.example/services/T000")
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	resp.Body.Close()
+	if proxiedHost != "hooks.slack.example" {
+		t.Errorf("expected the request to go through the proxy, got host %q", proxiedHost)
+	}
+}
+
+func TestNewHTTPClientCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mustHTTPClient(t, HTTPConfig{}).Get(server.URL); err == nil {
+		t.Fatalf("expected the test server's certificate to be untrusted without the CA bundle")
+	}
+	resp, err := mustHTTPClient(t, HTTPConfig{CABundle: bundle}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the CA bundle to be trusted, but got: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewSDKHTTPClientWithAWSCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CA_BUNDLE", bundle)
+
+	client, err := HTTPConfig{}.NewSDKHTTPClient()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithHTTPClient(client))
+	if err != nil {
+		t.Fatalf("expected the SDK to accept AWS_CA_BUNDLE, but got: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected AWS_CA_BUNDLE to be trusted, but got: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func mustHTTPClient(t *testing.T, c HTTPConfig) *http.Client {
+	t.Helper()
+	client, err := c.NewHTTPClient()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	return client
+}
@@ -0,0 +1,194 @@
+// File: link.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ConsoleCostExplorerURL is the Cost Explorer page of the AWS console. Its view is set by the query in
+// the URL fragment.
+const ConsoleCostExplorerURL = "https://us-east-1.console.aws.amazon.com/cost-management/home#/cost-explorer"
+
+// consoleDimensionIDs holds the console names of dimensions that are not the PascalCase of their
+// Cost Explorer API names.
+var consoleDimensionIDs = map[types.Dimension]string{
+	types.DimensionAz: "AZ",
+}
+
+// consoleDimensionID returns the console name of a dimension, e.g. LinkedAccount for LINKED_ACCOUNT.
+func consoleDimensionID(dimension types.Dimension) string {
+	if id, ok := consoleDimensionIDs[dimension]; ok {
+		return id
+	}
+	var id strings.Builder
+	for _, word := range strings.Split(strings.ToLower(string(dimension)), "_") {
+		if word != "" {
+			id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return id.String()
+}
+
+// consoleValue is a value of a console filter or the key of a tag or cost category filter.
+type consoleValue struct {
+	Value        string `json:"value"`
+	DisplayValue string `json:"displayValue"`
+}
+
+// consoleFilter is one filter of a console view. The console ANDs its filters.
+type consoleFilter struct {
+	Dimension     consoleDimension `json:"dimension"`
+	Operator      string           `json:"operator"` // INCLUDES or EXCLUDES
+	Values        []consoleValue   `json:"values"`
+	GrowableValue *consoleValue    `json:"growableValue,omitempty"` // Tag or cost category key
+}
+
+type consoleDimension struct {
+	ID           string `json:"id"`
+	DisplayValue string `json:"displayValue"`
+}
+
+// consoleFilters flattens a filter expression into console filters. The console can only AND
+// filters that include or exclude values, so an expression using 'or', or 'not' around anything but
+// a single comparison, cannot be shown.
+func consoleFilters(expr *types.Expression) ([]consoleFilter, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	if len(expr.And) > 0 {
+		var filters []consoleFilter
+		for i := range expr.And {
+			f, err := consoleFilters(&expr.And[i])
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, f...)
+		}
+		return filters, nil
+	}
+	if len(expr.Or) > 0 {
+		return nil, fmt.Errorf("the console cannot show filters combined with 'or'")
+	}
+	operator := "INCLUDES"
+	if expr.Not != nil {
+		operator, expr = "EXCLUDES", expr.Not
+	}
+
+	values := func(raw []string) []consoleValue {
+		out := make([]consoleValue, len(raw))
+		for i, v := range raw {
+			out[i] = consoleValue{Value: v, DisplayValue: v}
+		}
+		return out
+	}
+	switch {
+	case expr.Dimensions != nil:
+		id := consoleDimensionID(expr.Dimensions.Key)
+		return []consoleFilter{{
+			Dimension: consoleDimension{ID: id, DisplayValue: id},
+			Operator:  operator,
+			Values:    values(expr.Dimensions.Values),
+		}}, nil
+	case expr.Tags != nil:
+		key := *expr.Tags.Key
+		return []consoleFilter{{
+			Dimension:     consoleDimension{ID: "TagKey", DisplayValue: "Tag"},
+			Operator:      operator,
+			Values:        values(expr.Tags.Values),
+			GrowableValue: &consoleValue{Value: key, DisplayValue: key},
+		}}, nil
+	case expr.CostCategories != nil:
+		key := *expr.CostCategories.Key
+		return []consoleFilter{{
+			Dimension:     consoleDimension{ID: "CostCategory", DisplayValue: "Cost category"},
+			Operator:      operator,
+			Values:        values(expr.CostCategories.Values),
+			GrowableValue: &consoleValue{Value: key, DisplayValue: key},
+		}}, nil
+	}
+	return nil, fmt.Errorf("the console cannot show 'not' around more than one comparison")
+}
+
+// consoleURL returns a Cost Explorer console URL showing q's range, granularity and filter grouped by
+// the groupBy dimension, with q's metric.
+func consoleURL(q CostQuery, groupBy types.Dimension) (string, error) {
+	filters, err := consoleFilters(q.Filter)
+	if err != nil {
+		return "", err
+	}
+	if filters == nil {
+		filters = []consoleFilter{}
+	}
+	filterJSON, err := json.Marshal(filters)
+	if err != nil {
+		return "", err
+	}
+	groupByJSON, err := json.Marshal([]string{consoleDimensionID(groupBy)})
+	if err != nil {
+		return "", err
+	}
+
+	// The console has no weekly view, and its end date is inclusive where the API's is exclusive.
+	granularity := "Monthly"
+	switch q.Granularity {
+	case types.GranularityDaily, GranularityWeekly:
+		granularity = "Daily"
+	case types.GranularityHourly:
+		granularity = "Hourly"
+	}
+	params := url.Values{
+		"chartStyle":              {"STACK"},
+		"costAggregate":           {consoleCostAggregate(q.metric())},
+		"startDate":               {q.Start.Format(AWSDateFormat)},
+		"endDate":                 {q.End.AddDate(0, 0, -1).Format(AWSDateFormat)},
+		"historicalRelativeRange": {"CUSTOM"},
+		"granularity":             {granularity},
+		"groupBy":                 {string(groupByJSON)},
+		"filter":                  {string(filterJSON)},
+		"isDefault":               {"false"},
+		"excludeForecasting":      {"false"},
+		"showOnlyUncategorized":   {"false"},
+		"showOnlyUntagged":        {"false"},
+		"useNormalizedUnits":      {"false"},
+	}
+	// The console reads the fragment with decodeURIComponent, which leaves '+' as is.
+	return ConsoleCostExplorerURL + "?" + strings.ReplaceAll(params.Encode(), "+", "%20"), nil
+}
+
+var linkCmd = &cobra.Command{
+	Use:   "link",
+	Short: "Print a Cost Explorer console URL for the current flags.",
+	Long: `Prints the URL of the AWS Cost Explorer console view matching --days or --period, --granularity and --filter,
+grouped by --group-by, to share an exact view with someone who prefers the console. The console ANDs its filters,
+so filters using 'or' cannot be linked. It makes no AWS requests:
+
+  cost-tracker link --period last-fiscal-quarter --filter 'tag:team = data' --group-by account`,
+	Run: func(cmd *cobra.Command, args []string) {
+		query, err := costQueryFromConfig(viper.GetInt("days"))
+		if err != nil {
+			logger.Fatalw("Invalid query", "error", err)
+		}
+		key, _ := cmd.Flags().GetString("group-by")
+		groupBy, ok := lookupDimension(key)
+		if !ok {
+			logger.Fatalw("Invalid --group-by, expected a dimension such as service, account or region", "group_by", key)
+		}
+		link, err := consoleURL(query, groupBy)
+		if err != nil {
+			logger.Fatalw("Cannot link to this view", "error", err)
+		}
+		fmt.Println(link)
+	},
+}
+
+func init() {
+	linkCmd.Flags().String("group-by", "service", "Dimension to group the console view by, e.g. service, account or region")
+	rootCmd.AddCommand(linkCmd)
+}
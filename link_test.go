@@ -0,0 +1,69 @@
+// File: link_test.go
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+func TestConsoleDimensionID(t *testing.T) {
+	for dimension, want := range map[types.Dimension]string{
+		types.DimensionService:       "Service",
+		types.DimensionLinkedAccount: "LinkedAccount",
+		types.DimensionUsageType:     "UsageType",
+		types.DimensionAz:            "AZ",
+	} {
+		if got := consoleDimensionID(dimension); got != want {
+			t.Errorf("consoleDimensionID(%s) = %s, want %s", dimension, got, want)
+		}
+	}
+}
+
+func TestConsoleURL(t *testing.T) {
+	filter, err := ParseFilter(`tag:team = data and service not in ("Amazon Simple Storage Service", Tax)`)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	q := CostQuery{
+		Start:       time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		Filter:      filter,
+		Granularity: GranularityWeekly,
+	}
+	link, err := consoleURL(q, types.DimensionLinkedAccount)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	base, fragment, _ := strings.Cut(link, "?")
+	if base != ConsoleCostExplorerURL || strings.Contains(fragment, "+") {
+		t.Fatalf("unexpected link %s", link)
+	}
+	params, err := url.ParseQuery(fragment)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if params.Get("startDate") != "2024-05-01" || params.Get("endDate") != "2024-05-31" || params.Get("granularity") != "Daily" {
+		t.Errorf("unexpected range or granularity: %v", params)
+	}
+	if params.Get("groupBy") != `["LinkedAccount"]` {
+		t.Errorf("unexpected group by %s", params.Get("groupBy"))
+	}
+	var filters []consoleFilter
+	if err := json.Unmarshal([]byte(params.Get("filter")), &filters); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(filters) != 2 || filters[0].Dimension.ID != "TagKey" || filters[0].GrowableValue.Value != "team" ||
+		filters[1].Operator != "EXCLUDES" || filters[1].Values[0].Value != "Amazon Simple Storage Service" {
+		t.Errorf("unexpected filters: %+v", filters)
+	}
+
+	q.Filter, _ = ParseFilter("region = us-east-1 or region = eu-west-1")
+	if _, err := consoleURL(q, types.DimensionService); err == nil {
+		t.Error("expected an error for a filter using 'or'")
+	}
+}
@@ -0,0 +1,81 @@
+// File: logfile.go
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LogFileConfig configures the optional log file, written in addition to stderr and rotated by
+// lumberjack, for hosts that have no log collector.
+type LogFileConfig struct {
+	Path        string // Empty disables the log file
+	MaxSizeMB   int    // Rotate when the file grows past this size
+	MaxBackups  int    // Rotated files to keep; 0 keeps all
+	MaxAgeDays  int    // Delete rotated files older than this; 0 keeps them
+	Compress    bool   // Gzip rotated files
+	RotateDaily bool   // Rotate at the first run of each UTC day, too
+}
+
+// LogFileConfigFromViper reads the log.* configuration keys.
+func LogFileConfigFromViper() LogFileConfig {
+	return LogFileConfig{
+		Path:        viper.GetString("log.file"),
+		MaxSizeMB:   viper.GetInt("log.max_size_mb"),
+		MaxBackups:  viper.GetInt("log.max_backups"),
+		MaxAgeDays:  viper.GetInt("log.max_age_days"),
+		Compress:    viper.GetBool("log.compress"),
+		RotateDaily: viper.GetBool("log.rotate_daily"),
+	}
+}
+
+// writer returns the rotating writer for the log file. With RotateDaily, a file last written on an
+// earlier UTC day than now is rotated first, so each day's runs start a new file.
+func (c LogFileConfig) writer(now time.Time) (*lumberjack.Logger, error) {
+	w := &lumberjack.Logger{
+		Filename:   c.Path,
+		MaxSize:    c.MaxSizeMB,
+		MaxBackups: c.MaxBackups,
+		MaxAge:     c.MaxAgeDays,
+		Compress:   c.Compress,
+	}
+	if c.RotateDaily {
+		info, err := os.Stat(c.Path)
+		if err == nil && info.Size() > 0 && info.ModTime().UTC().Truncate(24*time.Hour).Before(now.UTC().Truncate(24*time.Hour)) {
+			if err := w.Rotate(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return w, nil
+}
+
+// addLogFile returns logger writing to the configured log file as well, as JSON at info level and
+// above like stderr. It returns logger unchanged when no log file is configured.
+func addLogFile(logger *zap.SugaredLogger, c LogFileConfig, now time.Time) (*zap.SugaredLogger, error) {
+	if c.Path == "" {
+		return logger, nil
+	}
+	w, err := c.writer(now)
+	if err != nil {
+		return logger, err
+	}
+	file := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(w), zap.InfoLevel)
+	return logger.Desugar().WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, file)
+	})).Sugar(), nil
+}
+
+func init() {
+	viper.SetDefault("log.file", "")            // Log file path; empty logs to stderr only
+	viper.SetDefault("log.max_size_mb", 100)    // Rotate the log file past this size
+	viper.SetDefault("log.max_backups", 5)      // Rotated log files to keep
+	viper.SetDefault("log.max_age_days", 28)    // Delete rotated log files older than this
+	viper.SetDefault("log.compress", false)     // Gzip rotated log files
+	viper.SetDefault("log.rotate_daily", false) // Also rotate at the first run of each UTC day
+}
@@ -0,0 +1,61 @@
+// File: logfile_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAddLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "cost-tracker.log")
+	fileLogger, err := addLogFile(zaptest.NewLogger(t).Sugar(), LogFileConfig{Path: path, MaxSizeMB: 1}, time.Now())
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	fileLogger.Infow("Wrote report", "run_id", "abc")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected the log file to be written, got %v", err)
+	}
+	if !strings.Contains(string(data), `"msg":"Wrote report"`) || !strings.Contains(string(data), `"run_id":"abc"`) {
+		t.Errorf("unexpected log file contents: %s", data)
+	}
+}
+
+func TestLogFileRotateDaily(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cost-tracker.log")
+	if err := os.WriteFile(path, []byte("yesterday\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 5, 2, 6, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, now.AddDate(0, 0, -1), now.AddDate(0, 0, -1)); err != nil {
+		t.Fatal(err)
+	}
+
+	c := LogFileConfig{Path: path, RotateDaily: true}
+	if _, err := c.writer(now); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("expected yesterday's file to be rotated next to a new one, got %d files", len(entries))
+	}
+
+	// A file already written today is kept.
+	if err := os.Chtimes(path, now, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.writer(now); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("expected today's file not to be rotated, got %d files", len(entries))
+	}
+}
@@ -4,13 +4,17 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
-	"github.com/slack-go/slack"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -18,19 +22,31 @@ import (
 
 const (
 	AWSDateFormat        = "2006-01-02"                       // AWS date format used in API requests
+	AWSHourFormat        = "2006-01-02T15:04:05Z"             // AWS time format used in hourly API requests
 	MetricBlendedCost    = "BlendedCost"                      // Metric for blended cost
 	GranularityMonthly   = types.GranularityMonthly           // Monthly granularity for cost data
 	GroupByTypeDimension = types.GroupDefinitionTypeDimension // Group by dimension type
 	GroupByServiceKey    = "SERVICE"                          // Key for grouping by service
+	MaxGroupBy           = 2                                  // Cost Explorer accepts at most two group definitions
+	GroupKeySeparator    = " / "                              // Joins the keys of a group with several group definitions
 	DefaultDays          = 30                                 // Default number of days to look back for cost data
+	ExitPartialFailure   = 3                                  // Exit status of a run that reported on only some payers
 )
 
 var logger *zap.SugaredLogger
 
+// exitCode is the status to exit with once the command has finished, set by a command that succeeded only
+// in part. Exiting after Execute rather than in the command still writes the run manifest.
+var exitCode int
+
 // CostExplorerAPI defines the interface for AWS Cost Explorer client methods used by CostTracker.
 // This allows for mocking in tests.
 type CostExplorerAPI interface {
 	GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error)
+	GetDimensionValues(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error)
+	GetReservationCoverage(ctx context.Context, params *costexplorer.GetReservationCoverageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error)
+	GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error)
+	GetAnomalies(ctx context.Context, params *costexplorer.GetAnomaliesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomaliesOutput, error)
 }
 
 // CostTracker holds the AWS Cost Explorer client.
@@ -38,30 +54,141 @@ type CostTracker struct {
 	client CostExplorerAPI
 }
 
-// NewCostTracker initializes a new CostTracker with the default AWS configuration.
+// NewCostTracker initializes a new CostTracker with the default AWS configuration and the configured
+// HTTP client, assuming aws.role_arn if it is configured.
 // It returns an error if the AWS SDK configuration cannot be loaded.
 func NewCostTracker(ctx context.Context) (*CostTracker, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	role, err := AssumeRoleConfigFromViper()
 	if err != nil {
-		return nil, fmt.Errorf("unable to load SDK config: %w", err) // Use %w for error wrapping
+		return nil, err
 	}
+	return newCostTrackerAssuming(ctx, role)
+}
 
+// newCostTrackerAssuming initializes a CostTracker that queries Cost Explorer through role, or with
+// the default credentials if role has no ARN.
+func newCostTrackerAssuming(ctx context.Context, role AssumeRoleConfig) (*CostTracker, error) {
+	cfg, err := loadAWSConfig(ctx, role)
+	if err != nil {
+		return nil, err
+	}
 	return &CostTracker{
-		client: costexplorer.NewFromConfig(cfg),
+		client: &notEnabledClient{next: costexplorer.NewFromConfig(cfg)},
 	}, nil
 }
 
-// ServiceCost represents the cost for a specific AWS service.
-type ServiceCost struct {
-	ServiceName string
-	Amount      string
-	Unit        string
+// loadAWSConfig loads the default AWS configuration with the configured HTTP client, assuming role if
+// it has an ARN.
+func loadAWSConfig(ctx context.Context, role AssumeRoleConfig) (aws.Config, error) {
+	httpClient, err := HTTPConfigFromViper().NewSDKHTTPClient()
+	if err != nil {
+		return aws.Config{}, err
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithHTTPClient(httpClient))
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("unable to load SDK config: %w", err) // Use %w for error wrapping
+	}
+	applyAssumeRole(&cfg, role)
+	return cfg, nil
+}
+
+// GroupedCost represents the cost of one group of a period, such as a service or a linked account,
+// depending on the query's GroupBy.
+type GroupedCost struct {
+	Key     string   // Value of the grouping dimension, e.g. a service name or account ID; several are joined by GroupKeySeparator
+	Keys    []string `json:",omitempty"` // Each dimension's value, when grouped by more than one
+	Amount  string   // Of the query's first metric, in Unit
+	Unit    string
+	Metrics map[string]MetricAmount `json:",omitempty"` // Every metric, when the query has more than one
+}
+
+// MetricAmount is the amount of one metric of a group.
+type MetricAmount struct {
+	Amount string
+	Unit   string
 }
 
 type CostByTime struct {
-	Start        string
-	End          string
-	ServiceCosts []ServiceCost
+	Start       string
+	End         string
+	Groups      []GroupedCost
+	Approximate bool `json:",omitempty"` // Service costs are estimated by GetCostsApproximate
+}
+
+// totalsByService sums each service's cost across all periods and returns the totals with their unit.
+// Amounts that cannot be parsed are logged and skipped.
+func totalsByService(costs []CostByTime) (map[string]float64, string) {
+	totals := make(map[string]float64)
+	unit := ""
+	for _, period := range costs {
+		for _, serviceCost := range period.Groups {
+			amount, err := strconv.ParseFloat(serviceCost.Amount, 64)
+			if err != nil {
+				logger.Warnw("Skipping unparseable cost amount",
+					"service", serviceCost.Key,
+					"periodStart", period.Start,
+					"amount", serviceCost.Amount)
+				continue
+			}
+			totals[serviceCost.Key] += amount
+			unit = serviceCost.Unit
+		}
+	}
+	return totals, unit
+}
+
+// metrics returns the metrics q requests, BlendedCost unless Metrics is set.
+func (q CostQuery) metrics() []string {
+	if len(q.Metrics) == 0 {
+		return []string{MetricBlendedCost}
+	}
+	return q.Metrics
+}
+
+// metric returns the first metric of q, which is reported in each group's Amount.
+func (q CostQuery) metric() string {
+	return q.metrics()[0]
+}
+
+// groupDefinitions returns the Cost Explorer grouping for --group-by dimensions, given by name or
+// filter alias.
+func groupDefinitions(keys []string) ([]types.GroupDefinition, error) {
+	if len(keys) == 0 || len(keys) > MaxGroupBy {
+		return nil, fmt.Errorf("expected 1 to %d dimensions, got %d", MaxGroupBy, len(keys))
+	}
+	groups := make([]types.GroupDefinition, len(keys))
+	for i, key := range keys {
+		dimension, ok := lookupDimension(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown dimension %q, expected a dimension such as service, account or region", key)
+		}
+		for _, previous := range groups[:i] {
+			if aws.ToString(previous.Key) == string(dimension) {
+				return nil, fmt.Errorf("dimension %s is given twice", dimension)
+			}
+		}
+		groups[i] = types.GroupDefinition{Type: GroupByTypeDimension, Key: aws.String(string(dimension))}
+	}
+	return groups, nil
+}
+
+// CostQuery describes the time range, optional filter and grouping of a single Cost Explorer request.
+type CostQuery struct {
+	Start       time.Time
+	End         time.Time
+	Filter      *types.Expression       // Optional; nil queries all costs
+	GroupBy     []types.GroupDefinition // Optional; nil groups by SERVICE
+	Metrics     []string                // Optional; nil is BlendedCost. Reports use the first unless they show several
+	Granularity types.Granularity       // Optional; empty is monthly, GranularityWeekly is emulated from daily data, hourly needs UTC times
+	Fallback    bool                    // Whether an hourly query Cost Explorer rejects is retried at daily granularity
+	// VerifyTotals fetches each period's ungrouped total to check that the groups add up to it, at the
+	// cost of an extra request per fetch.
+	VerifyTotals bool
+}
+
+// Days returns the length of the query range in whole days.
+func (q CostQuery) Days() int {
+	return int(math.Round(q.End.Sub(q.Start).Hours() / 24))
 }
 
 // GetCostsByService retrieves AWS costs grouped by service for a specified number of days.
@@ -74,106 +201,269 @@ func (ct *CostTracker) GetCostsByService(ctx context.Context, days int) ([]CostB
 		return nil, fmt.Errorf("days must be a positive integer, got %d", days)
 	}
 
-	// Calculate date range
+	return ct.GetCosts(ctx, lastNDays(days))
+}
+
+// lastNDays returns a query covering the N days up to now.
+func lastNDays(days int) CostQuery {
 	endDate := time.Now()
-	startDate := endDate.AddDate(0, 0, -days)
+	return CostQuery{
+		Start: endDate.AddDate(0, 0, -days),
+		End:   endDate,
+	}
+}
+
+// costQueryFromConfig builds the query for the last N days, or for the configured --period instead,
+// applying the configured --filter expression and --granularity.
+func costQueryFromConfig(days int) (CostQuery, error) {
+	if days <= 0 {
+		return CostQuery{}, fmt.Errorf("days must be a positive integer, got %d", days)
+	}
+	query := lastNDays(days)
+	if start, end := viper.GetString("start"), viper.GetString("end"); start != "" || end != "" {
+		if viper.GetString("period") != "" {
+			return CostQuery{}, fmt.Errorf("--period cannot be combined with --start or --end")
+		}
+		var err error
+		if query.Start, query.End, err = explicitRange(start, end, days, time.Now()); err != nil {
+			return CostQuery{}, fmt.Errorf("invalid date range: %w", err)
+		}
+	}
+	if period := viper.GetString("period"); period != "" {
+		cal, err := FiscalCalendarFromViper()
+		if err != nil {
+			return CostQuery{}, fmt.Errorf("invalid fiscal calendar: %w", err)
+		}
+		start, end, err := periodRange(period, time.Now(), cal)
+		if err != nil {
+			return CostQuery{}, fmt.Errorf("invalid period: %w", err)
+		}
+		query.Start, query.End = start, end
+	}
+	granularity, err := parseGranularity(viper.GetString("granularity"))
+	if err != nil {
+		return CostQuery{}, err
+	}
+	query.Granularity = granularity
+	if query.Metrics, err = parseMetrics(configStringSlice("metric")); err != nil {
+		return CostQuery{}, err
+	}
+	query.Fallback = !viper.GetBool("strict_granularity")
+	query.VerifyTotals = viper.GetBool("verify_totals")
+	if granularity == types.GranularityHourly {
+		if err := checkHourlyRange(&query, time.Now()); err != nil {
+			if !query.Fallback {
+				return CostQuery{}, err
+			}
+			logger.Warnw("Falling back to daily granularity; pass --strict-granularity to fail instead", "reason", err)
+			fallBackToDaily(&query)
+		}
+	}
+	if filter := viper.GetString("filter"); filter != "" {
+		expr, err := ParseFilter(filter)
+		if err != nil {
+			return CostQuery{}, fmt.Errorf("invalid filter: %w", err)
+		}
+		query.Filter = expr
+	}
+	flagFilter, err := flagFilterFromConfig()
+	if err != nil {
+		return CostQuery{}, err
+	}
+	query.Filter = andExpression(query.Filter, flagFilter)
+	targets, err := targetFilterFromConfig(os.Stdin)
+	if err != nil {
+		return CostQuery{}, err
+	}
+	query.Filter = andExpression(query.Filter, targets)
+	return query, nil
+}
+
+// GetCosts retrieves AWS costs grouped by service for the time range and filter in q. If q.Fallback is
+// set and Cost Explorer rejects an hourly query, for instance because hourly data is not enabled or the
+// request limit is exceeded, it is retried at daily granularity.
+func (ct *CostTracker) GetCosts(ctx context.Context, q CostQuery) ([]CostByTime, error) {
+	costs, err := ct.getCosts(ctx, q)
+	if err != nil && q.Fallback && q.Granularity == types.GranularityHourly && hourlyRejected(err) {
+		logger.Warnw("Cost Explorer rejected the hourly query, falling back to daily granularity; pass --strict-granularity to fail instead", "error", err)
+		fallBackToDaily(&q)
+		return ct.getCosts(ctx, q)
+	}
+	return costs, err
+}
+
+// getCosts is GetCosts without the granularity fallback.
+func (ct *CostTracker) getCosts(ctx context.Context, q CostQuery) ([]CostByTime, error) {
+	if !q.Start.Before(q.End) {
+		return nil, fmt.Errorf("start date %s must be before end date %s", q.Start.Format(AWSDateFormat), q.End.Format(AWSDateFormat))
+	}
+	granularity := q.Granularity
+	switch granularity {
+	case "":
+		granularity = GranularityMonthly
+	case GranularityWeekly:
+		granularity = types.GranularityDaily
+	}
+	layout := AWSDateFormat
+	if granularity == types.GranularityHourly {
+		layout = AWSHourFormat
+	}
 
 	// Prepare the request
 	input := &costexplorer.GetCostAndUsageInput{
 		TimePeriod: &types.DateInterval{
-			Start: aws.String(startDate.Format(AWSDateFormat)),
-			End:   aws.String(endDate.Format(AWSDateFormat)),
-		},
-		Granularity: GranularityMonthly,
-		Metrics: []string{
-			MetricBlendedCost, // Use the constant for blended cost metric
+			Start: aws.String(q.Start.Format(layout)),
+			End:   aws.String(q.End.Format(layout)),
 		},
-		GroupBy: []types.GroupDefinition{
+		Filter:      q.Filter,
+		Granularity: granularity,
+		Metrics:     q.metrics(),
+		GroupBy:     q.GroupBy,
+	}
+	if input.GroupBy == nil {
+		input.GroupBy = []types.GroupDefinition{
 			{
 				Type: GroupByTypeDimension,
 				Key:  aws.String(GroupByServiceKey),
 			},
-		},
-	}
-
-	// Make the API call
-	result, err := ct.client.GetCostAndUsage(ctx, input)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cost data from AWS Cost Explorer: %w", err)
+		}
 	}
 
+	// Make the API calls. Results are paginated by group, so a period's groups can continue on the
+	// next page; they are merged into the period already seen.
 	var allCosts []CostByTime
-	for _, resultByTime := range result.ResultsByTime {
-		periodCosts := CostByTime{
-			Start: *resultByTime.TimePeriod.Start,
-			End:   *resultByTime.TimePeriod.End,
+	var issues []QualityIssue
+	periodIndex := make(map[string]int)
+	for {
+		result, err := ct.client.GetCostAndUsage(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cost data from AWS Cost Explorer: %w", err)
 		}
 
-		for _, group := range resultByTime.Groups {
-			serviceName := "N/A"
-			if len(group.Keys) > 0 {
-				serviceName = group.Keys[0] // Use the first key as the service name
+		pageStarts := make(map[string]bool, len(result.ResultsByTime))
+		for _, resultByTime := range result.ResultsByTime {
+			start, end := *resultByTime.TimePeriod.Start, *resultByTime.TimePeriod.End
+			if pageStarts[start] {
+				// Unlike a period continued on the next page, a repeat within a page would be counted twice.
+				issues = append(issues, QualityIssue{CheckDuplicatePeriod, start, "the period was returned more than once; the repeat was skipped"})
+				continue
+			}
+			pageStarts[start] = true
+			i, seen := periodIndex[start]
+			if !seen {
+				i = len(allCosts)
+				periodIndex[start] = i
+				allCosts = append(allCosts, CostByTime{Start: start, End: end})
 			}
 
-			// Safely access the metrics
-			metric, ok := group.Metrics[MetricBlendedCost]
-			if !ok || metric.Amount == nil || metric.Unit == nil {
-				logger.Warnw("Metric not found or incomplete for service",
-					"metric", MetricBlendedCost,
-					"service", serviceName,
-					"periodStart", periodCosts.Start,
-					"periodEnd", periodCosts.End)
-				continue // Skip if metric is missing or incomplete
+			for _, group := range resultByTime.Groups {
+				key := "N/A"
+				var keys []string
+				if len(group.Keys) > 0 {
+					key = strings.Join(group.Keys, GroupKeySeparator)
+				}
+				if len(group.Keys) > 1 {
+					keys = group.Keys
+				}
+
+				// Safely access the metrics
+				metric, ok := group.Metrics[q.metric()]
+				if !ok || metric.Amount == nil || metric.Unit == nil {
+					logger.Warnw("Metric not found or incomplete for group",
+						"metric", q.metric(),
+						"group", key,
+						"periodStart", start,
+						"periodEnd", end)
+					continue // Skip if metric is missing or incomplete
+				}
+
+				cost := GroupedCost{
+					Key:    key,
+					Keys:   keys,
+					Amount: *metric.Amount,
+					Unit:   *metric.Unit,
+				}
+				if len(q.metrics()) > 1 {
+					cost.Metrics = make(map[string]MetricAmount, len(q.metrics()))
+					for _, name := range q.metrics() {
+						if m, ok := group.Metrics[name]; ok && m.Amount != nil {
+							cost.Metrics[name] = MetricAmount{Amount: *m.Amount, Unit: aws.ToString(m.Unit)}
+						}
+					}
+				}
+				allCosts[i].Groups = append(allCosts[i].Groups, cost)
 			}
+		}
 
-			periodCosts.ServiceCosts = append(periodCosts.ServiceCosts, ServiceCost{
-				ServiceName: serviceName,
-				Amount:      *metric.Amount,
-				Unit:        *metric.Unit,
-			})
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			break
 		}
-		allCosts = append(allCosts, periodCosts)
+		input.NextPageToken = result.NextPageToken
 	}
 
+	issues = append(issues, checkCostQuality(q, granularity, allCosts)...)
+	if q.VerifyTotals {
+		totalIssues, err := ct.checkTotals(ctx, *input, q.metric(), allCosts)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, totalIssues...)
+	}
+	recordQualityIssues(issues)
+
+	if q.Granularity == GranularityWeekly {
+		return aggregateWeeks(allCosts)
+	}
 	return allCosts, nil
 }
 
-// displayCosts prints the retrieved cost data to the console.
-func displayCosts(costs []CostByTime, days int) {
-	fmt.Printf("AWS Costs for the last %d days:\n", days)
-	fmt.Println("=====================================")
+// displayCosts writes the retrieved cost data to w in the console table format.
+func displayCosts(w io.Writer, costs []CostByTime, days int) {
+	fmt.Fprintf(w, "AWS Costs for the last %d days:\n", days)
+	fmt.Fprintln(w, "=====================================")
 	if len(costs) == 0 {
-		fmt.Println("No cost data found for the specified period.")
+		fmt.Fprintln(w, "No cost data found for the specified period.")
 		return
 	}
 	for _, period := range costs {
-		fmt.Printf("Period: %s to %s\n", period.Start, period.End)
-		if len(period.ServiceCosts) == 0 {
-			fmt.Println("  No service costs found for this period.")
+		approximate := ""
+		if period.Approximate {
+			approximate = " (approximate)"
+		}
+		fmt.Fprintf(w, "Period: %s to %s%s\n", period.Start, period.End, approximate)
+		if len(period.Groups) == 0 {
+			fmt.Fprintln(w, "  No costs found for this period.")
 		} else {
-			for _, serviceCost := range period.ServiceCosts {
+			shown, hidden := rowLimit(len(period.Groups))
+			for _, serviceCost := range period.Groups[:shown] {
+				if serviceCost.Metrics != nil {
+					fmt.Fprintf(w, "  %-*s: %s\n", ServiceNameWidth, truncateName(serviceCost.Key, ServiceNameWidth), formatMetrics(serviceCost.Metrics))
+					continue
+				}
 				// Consider adding financial formatting (e.g., using "github.com/shopspring/decimal")
-				fmt.Printf("  %-30s: %s %s\n", serviceCost.ServiceName, serviceCost.Amount, serviceCost.Unit)
+				fmt.Fprintf(w, "  %-*s: %s %s\n", ServiceNameWidth, truncateName(serviceCost.Key, ServiceNameWidth), serviceCost.Amount, serviceCost.Unit)
 			}
+			writeHiddenRows(w, hidden)
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
 }
 
 // sendSlackNotification sends a message to a configured Slack webhook URL.
 // It reads the SLACK_WEBHOOK_URL environment variable.
 func sendSlackNotification(message string) {
+	if explaining() {
+		logger.Info("Explaining the query plan. Skipping Slack notification.")
+		return
+	}
 	webhookURL := viper.GetString("slack.webhook_url") // Read from Viper
 	if webhookURL == "" {
 		logger.Info("Slack webhook URL not configured. Skipping Slack notification. Set COSTTRACKER_SLACK_WEBHOOK_URL or configure in cost-tracker-config.yaml.")
 		return
 	}
 
-	msg := slack.WebhookMessage{
-		Text: message,
-	}
-
-	err := slack.PostWebhook(webhookURL, &msg)
+	notifier := &slackNotifier{webhookURL: webhookURL}
+	err := notifier.Notify(context.Background(), withRunID(message))
 	if err != nil {
 		logger.Errorw("Failed to send Slack notification", "error", err)
 		return
@@ -186,42 +476,203 @@ var rootCmd = &cobra.Command{
 	Use:   "cost-tracker",
 	Short: "A CLI tool to track AWS costs.",
 	Long:  `cost-tracker is a CLI tool that fetches and displays AWS cost and usage data grouped by service.`,
+	// Runs before every subcommand
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		var err error
+		if logger, err = addLogFile(logger, LogFileConfigFromViper(), time.Now()); err != nil {
+			logger.Warnw("Failed to rotate the log file", "path", viper.GetString("log.file"), "error", err)
+		}
+		logger = logger.With(runContextFields(cmd)...)
+		warnConfig(cmd, args)
+	},
+	// Runs after any subcommand that completes without exiting
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		reportDataQuality(cmd, args)
+		showQueryPlan(cmd, args)
+		writeManifest(cmd, args)
+	},
+}
+
+// setupReport builds the query from the shared report flags and creates a cost tracker for the
+// configured provider. Like the report commands themselves, it notifies Slack and exits on failure.
+func setupReport(ctx context.Context) (*CostTracker, CostQuery, int) {
+	days := viper.GetInt("days") // Viper now holds the value for 'days'
+	query, err := costQueryFromConfig(days)
+	if err != nil {
+		sendSlackNotification("Cost Tracker Error: Invalid query: " + err.Error())
+		logger.Fatalw("Invalid query", "error", err)
+	}
+	if viper.GetString("period") != "" || viper.GetString("start") != "" || viper.GetString("end") != "" {
+		days = query.Days()
+	}
+
+	// Create cost tracker
+	tracker, err := NewCostTrackerForProvider(ctx, viper.GetString("provider"))
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to create cost tracker: %v", err)
+		sendSlackNotification("Cost Tracker Error: " + errMsg)
+		logger.Fatalw("Failed to create cost tracker", "error", err)
+	}
+	if explaining() {
+		activePlan = &QueryPlan{}
+	}
+	if viper.GetString("manifest") != "" {
+		activeManifest = newRunManifest(query)
+	}
+	instrument(tracker)
+	if viper.GetBool("validate_filter") && query.Filter != nil {
+		// Under --explain the check finds nothing, but its requests are part of the plan
+		if err := tracker.ValidateFilter(ctx, query); err != nil && !explaining() {
+			sendSlackNotification("Cost Tracker Error: Invalid filter: " + err.Error())
+			logger.Fatalw("Invalid filter", "error", err)
+		}
+	}
+	return tracker, query, days
+}
+
+// instrument routes a tracker's requests to the --explain plan and records them in the --manifest,
+// when those are set. Trackers created after setupReport, such as one per payer, need it too.
+func instrument(tracker *CostTracker) {
+	if activePlan != nil {
+		tracker.client = &explainClient{plan: activePlan}
+	}
+	if activeManifest != nil {
+		tracker.client = &recordingClient{next: tracker.client, manifest: activeManifest}
+	}
 }
 
 var getCostsCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get AWS costs for a specified number of days.",
-	Long:  `Retrieves and displays AWS costs from Cost Explorer for the last N days, grouped by service.`,
+	Long: `Retrieves and displays AWS costs from Cost Explorer for the last N days, grouped by service, or by another
+dimension with --group-by, e.g. --group-by account for each linked account of an organization. Repeat
+--group-by to break costs down by two dimensions, e.g. --group-by service --group-by account.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		days := viper.GetInt("days") // Viper now holds the value for 'days'
-
 		// Use a background context for the main application lifecycle
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute) // Example: 5-minute timeout
 		defer cancel()                                                          // Ensure the context is cancelled when main exits
 
-		// Create cost tracker
-		tracker, err := NewCostTracker(ctx)
+		format := viper.GetString("output")
+		render, err := costRendererFor(format)
 		if err != nil {
-			errMsg := fmt.Sprintf("Failed to create cost tracker: %v", err)
-			sendSlackNotification("Cost Tracker Error: " + errMsg)
-			logger.Fatalw("Failed to create cost tracker", "error", err)
+			logger.Fatalw("Invalid --output", "error", err)
+		}
+		if format != OutputTable && (viper.GetBool("per_payer") || viper.GetBool("per_region")) {
+			logger.Fatalw("--output is only supported for the service table, not with --per-payer or --per-region", "output", format)
+		}
+		keys, _ := cmd.Flags().GetStringArray("group-by")
+		groupBy, err := groupDefinitions(keys)
+		if err != nil {
+			logger.Fatalw("Invalid --group-by", "error", err)
+		}
+		byService := len(groupBy) == 1 && aws.ToString(groupBy[0].Key) == GroupByServiceKey
+		if !byService && (viper.GetBool("per_payer") || viper.GetBool("per_region")) {
+			logger.Fatalw("--group-by is only supported for the service table, not with --per-payer or --per-region", "group_by", keys)
+		}
+
+		tracker, query, days := setupReport(ctx)
+		query.GroupBy = groupBy
+
+		if viper.GetBool("per_payer") {
+			payers, err := PayersFromViper()
+			if err == nil {
+				names, _ := cmd.Flags().GetStringArray("payer")
+				payers, err = selectPayers(payers, names)
+			}
+			if err == nil && len(payers) == 0 {
+				err = fmt.Errorf("no payers configured")
+			}
+			if err != nil {
+				logger.Fatalw("Invalid payer selection", "error", err)
+			}
+			// A payer whose role cannot be assumed is reported with those that fail to query, not fatal.
+			var failed []PayerFailure
+			var queried []Payer
+			var trackers []*CostTracker
+			for _, payer := range payers {
+				payerTracker, err := newPayerTracker(ctx, viper.GetString("provider"), payer)
+				if err != nil {
+					logger.Warnw("Failed to create cost tracker, continuing with the other payers", "payer", payer.Name, "error", err)
+					failed = append(failed, PayerFailure{Payer: payer.Name, Err: err})
+					continue
+				}
+				queried = append(queried, payer)
+				trackers = append(trackers, payerTracker)
+			}
+			if len(queried) == 0 {
+				errMsg := fmt.Sprintf("Failed to create a cost tracker for any payer, e.g. %s: %v", failed[0].Payer, failed[0].Err)
+				sendSlackNotification("Cost Tracker Error: " + errMsg)
+				logger.Fatalw("Failed to create cost tracker for any payer", "payer", failed[0].Payer, "error", failed[0].Err)
+			}
+			conversion, err := CurrencyConversionFromViper()
+			if err != nil {
+				logger.Fatalw("Invalid currency conversion", "error", err)
+			}
+			matrix, err := GetCostsPerPayer(ctx, query, queried, trackers, conversion)
+			if err != nil {
+				errMsg := fmt.Sprintf("Error getting per-payer costs: %v", err)
+				sendSlackNotification("Cost Tracker Error: " + errMsg)
+				logger.Fatalw("Error getting per-payer costs", "error", err)
+			}
+			matrix.Failed = append(failed, matrix.Failed...)
+			logger.Info("Displaying per-payer costs to console.")
+			out, done := consoleWriter()
+			displayPayerMatrix(out, matrix, days)
+			done()
+			if len(matrix.Failed) > 0 {
+				names := failedPayerNames(matrix.Failed)
+				if activeManifest != nil {
+					activeManifest.markFailed(names)
+				}
+				sendSlackNotification(fmt.Sprintf("Cost Tracker Warning: Fetched per-payer AWS costs for the last %d days for %d of %d payers; failed: %s.",
+					days, len(matrix.Payers), len(payers), strings.Join(names, ", ")))
+				exitCode = ExitPartialFailure
+				return
+			}
+			sendSlackNotification(fmt.Sprintf("Successfully fetched per-payer AWS costs for the last %d days.", days))
+			return
+		}
+
+		if viper.GetBool("per_region") {
+			matrix, err := tracker.GetCostsPerRegion(ctx, query)
+			if err != nil {
+				errMsg := fmt.Sprintf("Error getting per-region costs: %v", err)
+				sendSlackNotification("Cost Tracker Error: " + errMsg)
+				logger.Fatalw("Error getting per-region costs", "error", err)
+			}
+			logger.Info("Displaying per-region costs to console.")
+			out, done := consoleWriter()
+			displayRegionMatrix(out, matrix, days)
+			done()
+			sendSlackNotification(fmt.Sprintf("Successfully fetched per-region AWS costs for the last %d days.", days))
+			return
 		}
 
 		// Get costs
-		costs, err := tracker.GetCostsByService(ctx, days)
+		getCosts := tracker.GetCosts
+		if viper.GetBool("approximate") {
+			if len(query.Metrics) > 1 {
+				logger.Fatalw("--approximate estimates a single metric; pass one --metric", "metric", query.Metrics)
+			}
+			getCosts = tracker.GetCostsApproximate
+		}
+		costs, err := getCosts(ctx, query)
 		if err != nil {
 			errMsg := fmt.Sprintf("Error getting costs: %v", err)
 			sendSlackNotification("Cost Tracker Error: " + errMsg)
 			logger.Fatalw("Error getting costs", "error", err)
 		}
 		// Display costs
-		logger.Info("Displaying costs to console.")
-		displayCosts(costs, days)
+		logger.Infow("Displaying costs.", "output", format)
+		out, done := outputWriter(format)
+		err = render(out, costs, days)
+		done()
+		if err != nil {
+			logger.Fatalw("Failed to write costs", "error", err)
+		}
 
 		// Send Slack notification
 		slackMessage := fmt.Sprintf("Successfully fetched AWS costs for the last %d days.", days)
-		// You could enhance this message with a summary of costs if desired.
-		// For example, by modifying displayCosts to return a string or by re-processing `costs` here.
 		sendSlackNotification(slackMessage)
 	},
 }
@@ -237,19 +688,63 @@ func init() {
 	logger = rawLogger.Sugar()
 
 	// Initialize Viper configuration
-	viper.SetDefault("days", DefaultDays)     // Set default value for 'days'
-	viper.SetDefault("slack.webhook_url", "") // Set default for Slack webhook URL (empty means disabled)
+	viper.SetDefault("days", DefaultDays)         // Set default value for 'days'
+	viper.SetDefault("slack.webhook_url", "")     // Set default for Slack webhook URL (empty means disabled)
+	viper.SetDefault("per_region", false)         // Set default for the region×service matrix output
+	viper.SetDefault("approximate", false)        // Set default for estimating daily breakdowns from weekly ones
+	viper.SetDefault("per_payer", false)          // Set default for the payer×service matrix output
+	viper.SetDefault("provider", ProviderAWS)     // Set default cost data provider
+	viper.SetDefault("filter", "")                // Set default filter expression (empty means all costs)
+	viper.SetDefault("filter_service", "")        // Set default services to include (empty means all services)
+	viper.SetDefault("filter_account", "")        // Set default accounts to include (empty means all accounts)
+	viper.SetDefault("filter_region", "")         // Set default regions to include (empty means all regions)
+	viper.SetDefault("filter_tag", "")            // Set default tag values to include, as KEY=VALUE (empty means all)
+	viper.SetDefault("filter_json", "")           // Set default raw Cost Explorer filter expression (empty means none)
+	viper.SetDefault("exclude_record_types", "")  // Set default record types to leave out, e.g. Credit (empty means none)
+	viper.SetDefault("validate_filter", false)    // Set default for checking filter values before querying costs
+	viper.SetDefault("accounts_from", "")         // Set default account list file (empty means all accounts)
+	viper.SetDefault("tag_values_from", "")       // Set default tag value list, as KEY=PATH (empty means all values)
+	viper.SetDefault("period", "")                // Set default named period (empty means the last --days days)
+	viper.SetDefault("start", "")                 // Set default first day, YYYY-MM-DD (empty means --days before the end)
+	viper.SetDefault("end", "")                   // Set default day after the last, YYYY-MM-DD (empty means now)
+	viper.SetDefault("granularity", "")           // Set default granularity (empty means monthly)
+	viper.SetDefault("strict_granularity", false) // Set default for failing rather than falling back from hourly to daily
+	viper.SetDefault("verify_totals", false)      // Set default for checking that groups add up to each period's total
+	viper.SetDefault("metric", "")                // Set default cost metrics (empty means BlendedCost)
+	viper.SetDefault("no_trunc", false)           // Set default for truncating long names in console tables
+	viper.SetDefault("max_rows", 0)               // Set default row limit for console tables (0 means unlimited)
+	viper.SetDefault("no_pager", false)           // Set default for paging console output through $PAGER
+	viper.SetDefault("plain", false)              // Set default for screen-reader-friendly console output
+	viper.SetDefault("output", OutputTable)       // Set default format of the get command's output
+	viper.SetDefault("manifest", "")              // Set default run manifest path (empty means no manifest)
+
+	// Defaults for the synthetic data generator used by --provider mock
+	viper.SetDefault("mock.seed", 1)
+	viper.SetDefault("mock.growth", 0.002)
+	viper.SetDefault("mock.noise", 0.08)
+	viper.SetDefault("mock.regions", []string{"us-east-1", "eu-west-1", "ap-southeast-2"})
+	viper.SetDefault("mock.accounts", []string{"111111111111", "222222222222"})
+	viper.SetDefault("mock.tags", []map[string]interface{}{
+		{"key": "env", "values": []string{"prod", "staging", "dev"}},
+		{"key": "team", "values": []string{"platform", "payments", "data"}},
+	})
 
 	// Configure Viper to read from environment variables
 	// It will look for variables like COSTTRACKER_DAYS and COSTTRACKER_SLACK_WEBHOOK_URL
-	viper.SetEnvPrefix("COSTTRACKER")
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
 	// Configure Viper to read from a configuration file (optional)
-	viper.SetConfigName("cost-tracker-config") // Name of config file (e.g., cost-tracker-config.yaml)
-	viper.SetConfigType("json")                // Can be yaml, json, toml, etc.
-	viper.AddConfigPath(".")                   // Look for config in the current directory
-	viper.AddConfigPath("$HOME/.cost-tracker") // And in the user's home .cost-tracker directory
+	if path := os.Getenv(EnvPrefix + "_CONFIG"); path != "" {
+		viper.SetConfigFile(path) // An explicit file, e.g. a YAML file mounted into the container
+		viper.SetConfigType(configFileType(path))
+	} else {
+		viper.SetConfigName("cost-tracker-config") // Name of config file (e.g., cost-tracker-config.yaml)
+		viper.SetConfigType("json")                // Can be yaml, json, toml, etc.
+		viper.AddConfigPath(".")                   // Look for config in the current directory
+		viper.AddConfigPath("$HOME/.cost-tracker") // And in the user's home .cost-tracker directory
+	}
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			// Config file not found; this is not an error, just a warning
@@ -261,16 +756,137 @@ func init() {
 	}
 
 	rootCmd.AddCommand(getCostsCmd)
-	// Define the 'days' flag using Cobra
-	getCostsCmd.Flags().IntP("days", "d", DefaultDays, "Number of days to look back for cost data")
+	// Define the 'provider' flag on the root command so every subcommand can use synthetic data
+	rootCmd.PersistentFlags().String("provider", ProviderAWS, "Cost data provider to use (aws|mock)")
+	if err := viper.BindPFlag("provider", rootCmd.PersistentFlags().Lookup("provider")); err != nil {
+		logger.Panicw("Failed to bind 'provider' flag to viper configuration", "error", err)
+	}
+
+	// Define the 'days' flag using Cobra. It is persistent so every report command shares it.
+	rootCmd.PersistentFlags().IntP("days", "d", DefaultDays, "Number of days to look back for cost data")
 
 	// Bind the Cobra 'days' flag to Viper.
 	// This means Viper will respect the flag if set, then environment variables,
 	// then config file values, and finally its own defaults.
-	if err := viper.BindPFlag("days", getCostsCmd.Flags().Lookup("days")); err != nil {
+	if err := viper.BindPFlag("days", rootCmd.PersistentFlags().Lookup("days")); err != nil {
 		// This panic is for a programming error (e.g., flag "days" not found), should not happen in normal operation.
 		logger.Panicw("Failed to bind 'days' flag to viper configuration", "error", err)
 	}
+
+	rootCmd.PersistentFlags().String("filter", "", `Filter expression, e.g. 'service = "Amazon Simple Storage Service" and region in (us-east-1, eu-west-1)'`)
+	if err := viper.BindPFlag("filter", rootCmd.PersistentFlags().Lookup("filter")); err != nil {
+		logger.Panicw("Failed to bind 'filter' flag to viper configuration", "error", err)
+	}
+	for _, f := range []struct{ name, usage string }{
+		{"filter-service", "Only include this service, e.g. 'Amazon Simple Storage Service'; repeat for any of several"},
+		{"filter-account", "Only include this account ID or ARN; repeat for any of several"},
+		{"filter-region", "Only include this region, e.g. us-east-1; repeat for any of several"},
+		{"filter-tag", "Only include costs tagged KEY=VALUE; repeat for any of several values of a key"},
+	} {
+		rootCmd.PersistentFlags().StringArray(f.name, nil, f.usage)
+		if err := viper.BindPFlag(strings.ReplaceAll(f.name, "-", "_"), rootCmd.PersistentFlags().Lookup(f.name)); err != nil {
+			logger.Panicw("Failed to bind '"+f.name+"' flag to viper configuration", "error", err)
+		}
+	}
+	rootCmd.PersistentFlags().StringSlice("exclude-record-types", nil, "Leave out these record types, e.g. Credit,Refund,Tax,Support, so credits do not look like a drop in spend")
+	if err := viper.BindPFlag("exclude_record_types", rootCmd.PersistentFlags().Lookup("exclude-record-types")); err != nil {
+		logger.Panicw("Failed to bind 'exclude-record-types' flag to viper configuration", "error", err)
+	}
+	rootCmd.PersistentFlags().Bool("validate-filter", false, "Check that --filter's dimension values have cost data, suggesting close matches for typos, before querying costs")
+	if err := viper.BindPFlag("validate_filter", rootCmd.PersistentFlags().Lookup("validate-filter")); err != nil {
+		logger.Panicw("Failed to bind 'validate-filter' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().String("accounts-from", "", "Only include the account IDs or ARNs listed in this file, or on stdin for -")
+	if err := viper.BindPFlag("accounts_from", rootCmd.PersistentFlags().Lookup("accounts-from")); err != nil {
+		logger.Panicw("Failed to bind 'accounts-from' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().String("tag-values-from", "", "Only include the values of tag KEY listed in a file, as KEY=PATH, or on stdin for KEY=-")
+	if err := viper.BindPFlag("tag_values_from", rootCmd.PersistentFlags().Lookup("tag-values-from")); err != nil {
+		logger.Panicw("Failed to bind 'tag-values-from' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().String("start", "", "First day to report, YYYY-MM-DD (default: --days before --end)")
+	if err := viper.BindPFlag("start", rootCmd.PersistentFlags().Lookup("start")); err != nil {
+		logger.Panicw("Failed to bind 'start' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().String("end", "", "Day after the last day to report, YYYY-MM-DD, like Cost Explorer's exclusive end (default: now)")
+	if err := viper.BindPFlag("end", rootCmd.PersistentFlags().Lookup("end")); err != nil {
+		logger.Panicw("Failed to bind 'end' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().String("period", "", "Named period to report instead of --days (last-week, this-month, last-month, mtd, qtd, ytd, last-7d, this-fiscal-quarter, ...)")
+	if err := viper.BindPFlag("period", rootCmd.PersistentFlags().Lookup("period")); err != nil {
+		logger.Panicw("Failed to bind 'period' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().String("granularity", "", "Period granularity (monthly|daily|weekly|hourly); weekly sums daily data into ISO weeks, hourly covers the last 14 days")
+	if err := viper.BindPFlag("granularity", rootCmd.PersistentFlags().Lookup("granularity")); err != nil {
+		logger.Panicw("Failed to bind 'granularity' flag to viper configuration", "error", err)
+	}
+	rootCmd.PersistentFlags().Bool("strict-granularity", false, "Fail when hourly data cannot be queried instead of falling back to daily granularity")
+	if err := viper.BindPFlag("strict_granularity", rootCmd.PersistentFlags().Lookup("strict-granularity")); err != nil {
+		logger.Panicw("Failed to bind 'strict-granularity' flag to viper configuration", "error", err)
+	}
+	rootCmd.PersistentFlags().Bool("verify-totals", false, "Check that each period's groups add up to its total, with an extra Cost Explorer request per fetch")
+	if err := viper.BindPFlag("verify_totals", rootCmd.PersistentFlags().Lookup("verify-totals")); err != nil {
+		logger.Panicw("Failed to bind 'verify-totals' flag to viper configuration", "error", err)
+	}
+	rootCmd.PersistentFlags().StringArray("metric", nil, "Cost metric: BlendedCost (default), UnblendedCost, AmortizedCost, NetAmortizedCost, NetUnblendedCost or UsageQuantity; repeat to show several side by side")
+	if err := viper.BindPFlag("metric", rootCmd.PersistentFlags().Lookup("metric")); err != nil {
+		logger.Panicw("Failed to bind 'metric' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().Bool("no-trunc", false, "Do not truncate long service names in console output")
+	if err := viper.BindPFlag("no_trunc", rootCmd.PersistentFlags().Lookup("no-trunc")); err != nil {
+		logger.Panicw("Failed to bind 'no-trunc' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().Int("max-rows", 0, "Maximum rows to print per table (0 prints all)")
+	if err := viper.BindPFlag("max_rows", rootCmd.PersistentFlags().Lookup("max-rows")); err != nil {
+		logger.Panicw("Failed to bind 'max-rows' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().Bool("no-pager", false, "Do not page console output through $PAGER")
+	if err := viper.BindPFlag("no_pager", rootCmd.PersistentFlags().Lookup("no-pager")); err != nil {
+		logger.Panicw("Failed to bind 'no-pager' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().Bool("plain", false, "Screen-reader-friendly output: no separator lines, symbols or truncation")
+	if err := viper.BindPFlag("plain", rootCmd.PersistentFlags().Lookup("plain")); err != nil {
+		logger.Panicw("Failed to bind 'plain' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().Bool("explain", false, "Print the Cost Explorer requests a report would make, and their estimated cost, instead of running it")
+	if err := viper.BindPFlag("explain", rootCmd.PersistentFlags().Lookup("explain")); err != nil {
+		logger.Panicw("Failed to bind 'explain' flag to viper configuration", "error", err)
+	}
+
+	rootCmd.PersistentFlags().String("manifest", "", "Write a JSON run manifest (parameters, API calls, data completeness) to this path")
+	if err := viper.BindPFlag("manifest", rootCmd.PersistentFlags().Lookup("manifest")); err != nil {
+		logger.Panicw("Failed to bind 'manifest' flag to viper configuration", "error", err)
+	}
+
+	getCostsCmd.Flags().Bool("per-region", false, "Display a region×service cost matrix")
+	if err := viper.BindPFlag("per_region", getCostsCmd.Flags().Lookup("per-region")); err != nil {
+		logger.Panicw("Failed to bind 'per-region' flag to viper configuration", "error", err)
+	}
+	getCostsCmd.Flags().Bool("per-payer", false, "Query each configured payer concurrently and display a payer×service cost matrix")
+	if err := viper.BindPFlag("per_payer", getCostsCmd.Flags().Lookup("per-payer")); err != nil {
+		logger.Panicw("Failed to bind 'per-payer' flag to viper configuration", "error", err)
+	}
+	getCostsCmd.Flags().String("output", OutputTable, "Output format: table, or json for scripts (a versioned document on stdout)")
+	if err := viper.BindPFlag("output", getCostsCmd.Flags().Lookup("output")); err != nil {
+		logger.Panicw("Failed to bind 'output' flag to viper configuration", "error", err)
+	}
+	getCostsCmd.Flags().StringArray("group-by", []string{"service"}, "Dimension to group costs by, e.g. service, or account for each linked account of an organization; repeat for up to two")
+	getCostsCmd.Flags().StringArray("payer", nil, "Payer to include with --per-payer; repeat for several (default: all payers)")
+	getCostsCmd.Flags().Bool("approximate", false, "Estimate daily service costs from daily totals and weekly breakdowns, using fewer Cost Explorer requests")
+	if err := viper.BindPFlag("approximate", getCostsCmd.Flags().Lookup("approximate")); err != nil {
+		logger.Panicw("Failed to bind 'approximate' flag to viper configuration", "error", err)
+	}
 }
 
 func main() {
@@ -280,4 +896,8 @@ func main() {
 		sendSlackNotification("Cost Tracker Critical Error: " + errMsg)
 		logger.Fatalw("Error executing root command", "error", err)
 	}
+	if exitCode != 0 {
+		logger.Sync()
+		os.Exit(exitCode)
+	}
 }
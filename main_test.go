@@ -15,7 +15,11 @@ import (
 
 // mockCostExplorerClient is a mock implementation of the CostExplorerAPI interface.
 type mockCostExplorerClient struct {
-	GetCostAndUsageFunc func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error)
+	GetCostAndUsageFunc        func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error)
+	GetDimensionValuesFunc     func(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error)
+	GetReservationCoverageFunc func(ctx context.Context, params *costexplorer.GetReservationCoverageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error)
+	GetCostForecastFunc        func(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error)
+	GetAnomaliesFunc           func(ctx context.Context, params *costexplorer.GetAnomaliesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomaliesOutput, error)
 }
 
 // GetCostAndUsage satisfies the CostExplorerAPI interface.
@@ -26,6 +30,38 @@ func (m *mockCostExplorerClient) GetCostAndUsage(ctx context.Context, params *co
 	return nil, fmt.Errorf("GetCostAndUsageFunc not implemented in mock")
 }
 
+// GetDimensionValues satisfies the CostExplorerAPI interface.
+func (m *mockCostExplorerClient) GetDimensionValues(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+	if m.GetDimensionValuesFunc != nil {
+		return m.GetDimensionValuesFunc(ctx, params, optFns...)
+	}
+	return nil, fmt.Errorf("GetDimensionValuesFunc not implemented in mock")
+}
+
+// GetReservationCoverage satisfies the CostExplorerAPI interface.
+func (m *mockCostExplorerClient) GetReservationCoverage(ctx context.Context, params *costexplorer.GetReservationCoverageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error) {
+	if m.GetReservationCoverageFunc != nil {
+		return m.GetReservationCoverageFunc(ctx, params, optFns...)
+	}
+	return nil, fmt.Errorf("GetReservationCoverageFunc not implemented in mock")
+}
+
+// GetCostForecast satisfies the CostExplorerAPI interface.
+func (m *mockCostExplorerClient) GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+	if m.GetCostForecastFunc != nil {
+		return m.GetCostForecastFunc(ctx, params, optFns...)
+	}
+	return nil, fmt.Errorf("GetCostForecastFunc not implemented in mock")
+}
+
+// GetAnomalies satisfies the CostExplorerAPI interface.
+func (m *mockCostExplorerClient) GetAnomalies(ctx context.Context, params *costexplorer.GetAnomaliesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomaliesOutput, error) {
+	if m.GetAnomaliesFunc != nil {
+		return m.GetAnomaliesFunc(ctx, params, optFns...)
+	}
+	return nil, fmt.Errorf("GetAnomaliesFunc not implemented in mock")
+}
+
 func TestNewCostTracker(t *testing.T) {
 	ctx := context.Background()
 	// This test relies on the AWS SDK's default config loading behavior.
@@ -95,14 +131,14 @@ func TestGetCostsByService(t *testing.T) {
 			expectedCostsLen: 1,
 			expectedError:    false,
 			checkSpecificCost: func(t *testing.T, costs []CostByTime) {
-				if len(costs[0].ServiceCosts) != 1 {
-					t.Fatalf("expected 1 service cost, got %d", len(costs[0].ServiceCosts))
+				if len(costs[0].Groups) != 1 {
+					t.Fatalf("expected 1 service cost, got %d", len(costs[0].Groups))
 				}
-				if costs[0].ServiceCosts[0].ServiceName != "Amazon EC2" {
-					t.Errorf("expected service name 'Amazon EC2', got '%s'", costs[0].ServiceCosts[0].ServiceName)
+				if costs[0].Groups[0].Key != "Amazon EC2" {
+					t.Errorf("expected service name 'Amazon EC2', got '%s'", costs[0].Groups[0].Key)
 				}
-				if costs[0].ServiceCosts[0].Amount != "100.00" {
-					t.Errorf("expected amount '100.00', got '%s'", costs[0].ServiceCosts[0].Amount)
+				if costs[0].Groups[0].Amount != "100.00" {
+					t.Errorf("expected amount '100.00', got '%s'", costs[0].Groups[0].Amount)
 				}
 			},
 		},
@@ -176,11 +212,11 @@ func TestGetCostsByService(t *testing.T) {
 					},
 				}
 			},
-			expectedCostsLen: 1, // One period, but ServiceCosts within it should be empty
+			expectedCostsLen: 1, // One period, but Groups within it should be empty
 			expectedError:    false,
 			checkSpecificCost: func(t *testing.T, costs []CostByTime) {
-				if len(costs[0].ServiceCosts) != 0 {
-					t.Errorf("expected 0 service costs due to missing metric, got %d", len(costs[0].ServiceCosts))
+				if len(costs[0].Groups) != 0 {
+					t.Errorf("expected 0 service costs due to missing metric, got %d", len(costs[0].Groups))
 				}
 			},
 		},
@@ -213,3 +249,142 @@ func TestGetCostsByService(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCostsPagination(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	group := func(service string) types.Group {
+		return types.Group{
+			Keys:    []string{service},
+			Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("1"), Unit: aws.String("USD")}},
+		}
+	}
+	period := func(start, end string, groups ...types.Group) types.ResultByTime {
+		return types.ResultByTime{TimePeriod: &types.DateInterval{Start: aws.String(start), End: aws.String(end)}, Groups: groups}
+	}
+	// January's groups continue on the second page, and February's on the third.
+	pages := map[string]*costexplorer.GetCostAndUsageOutput{
+		"": {
+			ResultsByTime: []types.ResultByTime{period("2024-01-01", "2024-02-01", group("Amazon EC2"))},
+			NextPageToken: aws.String("page-2"),
+		},
+		"page-2": {
+			ResultsByTime: []types.ResultByTime{
+				period("2024-01-01", "2024-02-01", group("Amazon S3")),
+				period("2024-02-01", "2024-03-01", group("Amazon EC2")),
+			},
+			NextPageToken: aws.String("page-3"),
+		},
+		"page-3": {
+			ResultsByTime: []types.ResultByTime{period("2024-02-01", "2024-03-01", group("Amazon S3"), group("AWS Lambda"))},
+		},
+	}
+	var tokens []string
+	failOn := "never"
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			token := aws.ToString(params.NextPageToken)
+			tokens = append(tokens, token)
+			if token == failOn {
+				return nil, fmt.Errorf("throttled")
+			}
+			return pages[token], nil
+		},
+	}}
+	q := CostQuery{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+
+	costs, err := tracker.GetCosts(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(tokens) != 3 || tokens[1] != "page-2" || tokens[2] != "page-3" {
+		t.Errorf("expected three requests following the page tokens, got %q", tokens)
+	}
+	if len(costs) != 2 {
+		t.Fatalf("expected pages to be merged into 2 periods, got %d", len(costs))
+	}
+	if len(costs[0].Groups) != 2 || costs[0].Groups[1].Key != "Amazon S3" {
+		t.Errorf("expected January's groups from both pages, got %+v", costs[0].Groups)
+	}
+	if len(costs[1].Groups) != 3 || costs[1].Start != "2024-02-01" {
+		t.Errorf("expected February's groups from both pages, got %+v", costs[1])
+	}
+
+	tokens, failOn = nil, "page-2"
+	if _, err := tracker.GetCosts(context.Background(), q); err == nil {
+		t.Error("expected an error when a later page fails")
+	}
+}
+
+func TestGetCostsByLinkedAccount(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	var requested []types.GroupDefinition
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			requested = params.GroupBy
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{{
+				TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-02-01")},
+				Groups: []types.Group{{
+					Keys:    []string{"111111111111"},
+					Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("12.5"), Unit: aws.String("USD")}},
+				}},
+			}}}, nil
+		},
+	}}
+	q := CostQuery{
+		Start:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		GroupBy: []types.GroupDefinition{{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionLinkedAccount))}},
+	}
+
+	costs, err := tracker.GetCosts(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(requested) != 1 || aws.ToString(requested[0].Key) != "LINKED_ACCOUNT" {
+		t.Errorf("expected a request grouped by LINKED_ACCOUNT, got %+v", requested)
+	}
+	if len(costs) != 1 || len(costs[0].Groups) != 1 || costs[0].Groups[0].Key != "111111111111" {
+		t.Errorf("expected one group per account, got %+v", costs)
+	}
+}
+
+func TestGroupDefinitions(t *testing.T) {
+	groups, err := groupDefinitions([]string{"service", "LINKED_ACCOUNT"})
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(groups) != 2 || aws.ToString(groups[0].Key) != "SERVICE" || aws.ToString(groups[1].Key) != "LINKED_ACCOUNT" {
+		t.Errorf("unexpected group definitions: %+v", groups)
+	}
+	for _, keys := range [][]string{nil, {"service", "account", "region"}, {"service", "SERVICE"}, {"colour"}} {
+		if _, err := groupDefinitions(keys); err == nil {
+			t.Errorf("expected an error for %q", keys)
+		}
+	}
+}
+
+func TestGetCostsCompositeKeys(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{{
+				TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-02-01")},
+				Groups: []types.Group{{
+					Keys:    []string{"Amazon EC2", "111111111111"},
+					Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("3"), Unit: aws.String("USD")}},
+				}},
+			}}}, nil
+		},
+	}}
+	groupBy, _ := groupDefinitions([]string{"service", "account"})
+	q := CostQuery{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), GroupBy: groupBy}
+
+	costs, err := tracker.GetCosts(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	got := costs[0].Groups[0]
+	if got.Key != "Amazon EC2 / 111111111111" || len(got.Keys) != 2 || got.Keys[1] != "111111111111" {
+		t.Errorf("expected a composite key with each dimension's value, got %+v", got)
+	}
+}
@@ -0,0 +1,205 @@
+// File: manifest.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// manifestParameters are the configuration keys recorded in a run manifest. Secrets such as
+// slack.webhook_url are deliberately left out.
+var manifestParameters = []string{"provider", "days", "start", "end", "period", "granularity", "strict_granularity", "verify_totals", "metric", "filter", "filter_service", "filter_account", "filter_region", "filter_tag", "filter_json", "exclude_record_types", "per_region", "per_payer", "max_rows", "no_trunc", "output", "explain"}
+
+// APICall records one Cost Explorer request made during a run.
+type APICall struct {
+	Operation  string    `json:"operation"`
+	Start      string    `json:"start,omitempty"` // Requested time period
+	End        string    `json:"end,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// RunManifest describes a report run so automated pipelines can verify how its output was produced.
+type RunManifest struct {
+	RunID      string                 `json:"run_id"` // Also on every log line of the run
+	Command    string                 `json:"command"`
+	Args       []string               `json:"args"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt time.Time              `json:"finished_at"`
+	Parameters map[string]interface{} `json:"parameters"`
+	Start      string                 `json:"start"` // Query range, end exclusive
+	End        string                 `json:"end"`
+	APICalls   []APICall              `json:"api_calls"`
+	CacheHits  int                    `json:"cache_hits"` // Responses are not cached yet, so this is always 0
+	// Complete is false when Cost Explorer marked any returned period as estimated, or a payer failed.
+	Complete         bool     `json:"complete"`
+	EstimatedPeriods []string `json:"estimated_periods,omitempty"`
+	FailedPayers     []string `json:"failed_payers,omitempty"` // Payers left out of a --per-payer report
+	// QualityIssues are the data quality problems found in the fetched cost data.
+	QualityIssues []QualityIssue `json:"quality_issues,omitempty"`
+	Artifacts     []string       `json:"artifacts"` // Where the report was written; "stdout" for the console
+
+	mu sync.Mutex
+}
+
+// activeManifest is the manifest of the current run, or nil when --manifest is not set.
+var activeManifest *RunManifest
+
+// newRunManifest starts a manifest for a query, recording the configured parameters.
+func newRunManifest(q CostQuery) *RunManifest {
+	m := &RunManifest{
+		RunID:      runID,
+		Args:       os.Args[1:],
+		StartedAt:  time.Now().UTC(),
+		Parameters: make(map[string]interface{}, len(manifestParameters)),
+		Start:      q.Start.Format(AWSDateFormat),
+		End:        q.End.Format(AWSDateFormat),
+		APICalls:   []APICall{},
+		Complete:   true,
+		Artifacts:  []string{"stdout"},
+	}
+	for _, key := range manifestParameters {
+		m.Parameters[key] = viper.Get(key)
+	}
+	return m
+}
+
+// record appends an API call that started at startedAt.
+func (m *RunManifest) record(operation string, period *types.DateInterval, startedAt time.Time, err error) {
+	call := APICall{
+		Operation:  operation,
+		StartedAt:  startedAt.UTC(),
+		DurationMS: time.Since(startedAt).Milliseconds(),
+	}
+	if period != nil {
+		call.Start, call.End = aws.ToString(period.Start), aws.ToString(period.End)
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.APICalls = append(m.APICalls, call)
+}
+
+// markEstimated flags a returned period as estimated, i.e. not yet final.
+func (m *RunManifest) markEstimated(start string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Complete = false
+	for _, existing := range m.EstimatedPeriods {
+		if existing == start {
+			return
+		}
+	}
+	m.EstimatedPeriods = append(m.EstimatedPeriods, start)
+}
+
+// markFailed records payers left out of the report because their costs could not be fetched.
+func (m *RunManifest) markFailed(payers []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Complete = false
+	m.FailedPayers = append(m.FailedPayers, payers...)
+}
+
+// addQualityIssues records data quality problems found in the fetched cost data.
+func (m *RunManifest) addQualityIssues(issues []QualityIssue) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.QualityIssues = append(m.QualityIssues, issues...)
+}
+
+// Write finishes the manifest and writes it as indented JSON to path.
+func (m *RunManifest) Write(path, command string) error {
+	m.mu.Lock()
+	m.Command = command
+	m.FinishedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(m, "", "  ")
+	m.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode run manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write run manifest: %w", err)
+	}
+	return nil
+}
+
+// recordingClient wraps a CostExplorerAPI and records every call in a run manifest.
+type recordingClient struct {
+	next     CostExplorerAPI
+	manifest *RunManifest
+}
+
+// GetCostAndUsage satisfies the CostExplorerAPI interface, also recording estimated periods.
+func (c *recordingClient) GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+	startedAt := time.Now()
+	result, err := c.next.GetCostAndUsage(ctx, params, optFns...)
+	c.manifest.record("GetCostAndUsage", params.TimePeriod, startedAt, err)
+	if err == nil {
+		for _, resultByTime := range result.ResultsByTime {
+			if resultByTime.Estimated && resultByTime.TimePeriod != nil {
+				c.manifest.markEstimated(aws.ToString(resultByTime.TimePeriod.Start))
+			}
+		}
+	}
+	return result, err
+}
+
+// GetDimensionValues satisfies the CostExplorerAPI interface.
+func (c *recordingClient) GetDimensionValues(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+	startedAt := time.Now()
+	result, err := c.next.GetDimensionValues(ctx, params, optFns...)
+	c.manifest.record("GetDimensionValues", params.TimePeriod, startedAt, err)
+	return result, err
+}
+
+// GetReservationCoverage satisfies the CostExplorerAPI interface.
+func (c *recordingClient) GetReservationCoverage(ctx context.Context, params *costexplorer.GetReservationCoverageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error) {
+	startedAt := time.Now()
+	result, err := c.next.GetReservationCoverage(ctx, params, optFns...)
+	c.manifest.record("GetReservationCoverage", params.TimePeriod, startedAt, err)
+	return result, err
+}
+
+// GetCostForecast satisfies the CostExplorerAPI interface.
+func (c *recordingClient) GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+	startedAt := time.Now()
+	result, err := c.next.GetCostForecast(ctx, params, optFns...)
+	c.manifest.record("GetCostForecast", params.TimePeriod, startedAt, err)
+	return result, err
+}
+
+// GetAnomalies satisfies the CostExplorerAPI interface.
+func (c *recordingClient) GetAnomalies(ctx context.Context, params *costexplorer.GetAnomaliesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomaliesOutput, error) {
+	startedAt := time.Now()
+	result, err := c.next.GetAnomalies(ctx, params, optFns...)
+	c.manifest.record("GetAnomalies", anomalyInterval(params.DateInterval), startedAt, err)
+	return result, err
+}
+
+// writeManifest writes the active manifest after a command completes. A failed write is logged,
+// not fatal, since the report itself has already been produced.
+func writeManifest(cmd *cobra.Command, args []string) {
+	path := viper.GetString("manifest")
+	if path == "" || activeManifest == nil {
+		return
+	}
+	if err := activeManifest.Write(path, cmd.CommandPath()); err != nil {
+		logger.Errorw("Failed to write run manifest", "path", path, "error", err)
+		return
+	}
+	logger.Infow("Wrote run manifest", "path", path)
+}
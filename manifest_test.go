@@ -0,0 +1,80 @@
+// File: manifest_test.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRecordingClient(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	mockClient := &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{
+				{TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-02-01")}},
+				{TimePeriod: &types.DateInterval{Start: aws.String("2024-02-01"), End: aws.String("2024-02-15")}, Estimated: true},
+			}}, nil
+		},
+		GetDimensionValuesFunc: func(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+			return nil, errors.New("throttled")
+		},
+	}
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC),
+	}
+	manifest := newRunManifest(q)
+	tracker := &CostTracker{client: &recordingClient{next: mockClient, manifest: manifest}}
+
+	if _, err := tracker.GetCosts(context.Background(), q); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if _, err := tracker.client.GetDimensionValues(context.Background(), &costexplorer.GetDimensionValuesInput{Dimension: types.DimensionRegion}); err == nil {
+		t.Fatalf("expected the GetDimensionValues error to pass through, but got nil")
+	}
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := manifest.Write(path, "cost-tracker get"); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	var got struct {
+		Command          string    `json:"command"`
+		Start            string    `json:"start"`
+		End              string    `json:"end"`
+		APICalls         []APICall `json:"api_calls"`
+		Complete         bool      `json:"complete"`
+		EstimatedPeriods []string  `json:"estimated_periods"`
+		Parameters       map[string]interface{}
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+
+	if got.Command != "cost-tracker get" || got.Start != "2024-01-01" || got.End != "2024-02-15" {
+		t.Errorf("unexpected command or range: %+v", got)
+	}
+	if len(got.APICalls) != 2 || got.APICalls[0].Operation != "GetCostAndUsage" || got.APICalls[1].Error != "throttled" {
+		t.Errorf("unexpected API calls: %+v", got.APICalls)
+	}
+	if got.Complete || len(got.EstimatedPeriods) != 1 || got.EstimatedPeriods[0] != "2024-02-01" {
+		t.Errorf("expected the estimated February period to mark the run incomplete, got complete=%t periods=%v", got.Complete, got.EstimatedPeriods)
+	}
+	if _, ok := got.Parameters["slack.webhook_url"]; ok {
+		t.Errorf("the manifest must not record the Slack webhook URL")
+	}
+}
@@ -0,0 +1,280 @@
+// File: margin.go
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// UnassignedLabel names spend in linked accounts that no reseller customer claims.
+const UnassignedLabel = "(unassigned)"
+
+// ResellerCustomer is a customer billed for the spend of its linked accounts at cost plus a markup.
+type ResellerCustomer struct {
+	Name     string   `mapstructure:"name"`
+	Accounts []string `mapstructure:"accounts"`
+	Markup   *float64 `mapstructure:"markup"` // Overrides reseller.markup; 0.1 bills cost plus 10%
+}
+
+// ResellerCustomersFromViper reads reseller.customers, applying reseller.markup to customers without
+// their own. Each account may belong to only one customer.
+func ResellerCustomersFromViper() ([]ResellerCustomer, error) {
+	var customers []ResellerCustomer
+	if err := unmarshalConfigKey("reseller.customers", &customers); err != nil {
+		return nil, fmt.Errorf("invalid reseller.customers: %w", err)
+	}
+	defaultMarkup := viper.GetFloat64("reseller.markup")
+	owners := make(map[string]string)
+	for i := range customers {
+		c := &customers[i]
+		if c.Name == "" {
+			return nil, fmt.Errorf("reseller.customers[%d] has no name", i)
+		}
+		if len(c.Accounts) == 0 {
+			return nil, fmt.Errorf("reseller customer %q has no accounts", c.Name)
+		}
+		for _, account := range c.Accounts {
+			if !isAccountID(account) {
+				return nil, fmt.Errorf("reseller customer %q: %q is not a 12-digit account ID", c.Name, account)
+			}
+			if owner, ok := owners[account]; ok {
+				return nil, fmt.Errorf("account %s belongs to both %q and %q", account, owner, c.Name)
+			}
+			owners[account] = c.Name
+		}
+		if c.Markup == nil {
+			c.Markup = aws.Float64(defaultMarkup)
+		}
+	}
+	return customers, nil
+}
+
+// CustomerMargin is what one customer cost and is billed.
+type CustomerMargin struct {
+	Customer string
+	Accounts []string
+	Markup   float64
+	Cost     float64 // Real cost of the customer's accounts
+	Billed   float64 // Cost plus markup
+	Benefit  float64 // Commitment benefit of the customer's accounts, already reflected in Cost
+}
+
+// Margin returns the amount billed over cost.
+func (m CustomerMargin) Margin() float64 {
+	return m.Billed - m.Cost
+}
+
+// MarginRate returns the margin as a fraction of the amount billed, or 0 if nothing was billed.
+func (m CustomerMargin) MarginRate() float64 {
+	if m.Billed == 0 {
+		return 0
+	}
+	return m.Margin() / m.Billed
+}
+
+// MarginReport is the result of GetResellerMargins.
+type MarginReport struct {
+	Days       float64
+	Unit       string
+	Customers  []CustomerMargin
+	Unassigned float64 // Cost of accounts no customer claims, which is not billed to anyone
+	Benefits   bool    // Whether each customer's commitment benefit was attributed
+}
+
+// AttributeBenefits credits each customer with the commitment benefit of its accounts, by account.
+func (r *MarginReport) AttributeBenefits(benefits map[string]float64) {
+	for i := range r.Customers {
+		c := &r.Customers[i]
+		c.Benefit = 0
+		for _, account := range c.Accounts {
+			c.Benefit += benefits[account]
+		}
+	}
+	r.Benefits = true
+}
+
+// GetResellerMargins fetches cost by linked account and bills each customer its accounts' cost plus
+// its markup. Cost is the metric the tool reports everywhere, so it is the payer's real rate.
+func (ct *CostTracker) GetResellerMargins(ctx context.Context, q CostQuery, customers []ResellerCustomer) (*MarginReport, error) {
+	q.GroupBy = []types.GroupDefinition{
+		{Type: types.GroupDefinitionTypeDimension, Key: aws.String(string(types.DimensionLinkedAccount))},
+	}
+	costs, err := ct.GetCosts(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	totals, unit := totalsByService(costs)
+
+	report := &MarginReport{Days: q.End.Sub(q.Start).Hours() / 24, Unit: unit}
+	claimed := make(map[string]bool)
+	for _, c := range customers {
+		margin := CustomerMargin{Customer: c.Name, Accounts: c.Accounts, Markup: aws.ToFloat64(c.Markup)}
+		for _, account := range c.Accounts {
+			margin.Cost += totals[account]
+			claimed[account] = true
+		}
+		margin.Billed = margin.Cost * (1 + margin.Markup)
+		report.Customers = append(report.Customers, margin)
+	}
+	for account, cost := range totals {
+		if !claimed[account] {
+			report.Unassigned += cost
+		}
+	}
+	sort.SliceStable(report.Customers, func(i, j int) bool {
+		return report.Customers[i].Margin() > report.Customers[j].Margin()
+	})
+	return report, nil
+}
+
+// displayMarginReport writes the per-customer margins to w, largest margin first.
+func displayMarginReport(w io.Writer, report *MarginReport) {
+	fmt.Fprintf(w, "Reseller margin by customer for the last %.0f days:\n", report.Days)
+	fmt.Fprintln(w, "=====================================")
+	if len(report.Customers) == 0 {
+		fmt.Fprintln(w, "No reseller customers configured.")
+		return
+	}
+
+	header := fmt.Sprintf("%-30s %8s %14s %14s %14s %8s", "Customer", "Markup", "Cost", "Billed", "Margin", "Margin%")
+	if report.Benefits {
+		header += fmt.Sprintf(" %14s", "Benefit")
+	}
+	fmt.Fprintln(w, header)
+	var cost, billed, benefit float64
+	for _, m := range report.Customers {
+		row := fmt.Sprintf("%-30s %7.1f%% %14.2f %14.2f %14.2f %7.1f%%",
+			truncateName(m.Customer, 30), m.Markup*100, m.Cost, m.Billed, m.Margin(), m.MarginRate()*100)
+		if report.Benefits {
+			row += fmt.Sprintf(" %14.2f", m.Benefit)
+		}
+		fmt.Fprintln(w, row)
+		cost += m.Cost
+		billed += m.Billed
+		benefit += m.Benefit
+	}
+	total := fmt.Sprintf("%-30s %8s %14.2f %14.2f %14.2f", "Total", "", cost, billed, billed-cost)
+	if report.Benefits {
+		total += fmt.Sprintf(" %8s %14.2f", "", benefit)
+	}
+	fmt.Fprintln(w, total)
+	if report.Unassigned != 0 {
+		fmt.Fprintf(w, "%-30s %8s %14.2f\n", UnassignedLabel, "", report.Unassigned)
+	}
+	fmt.Fprintf(w, "Amounts in %s. Margin%% is the margin as a share of the amount billed.\n", report.Unit)
+	if report.Benefits {
+		fmt.Fprintln(w, "Benefit is what Savings Plans and reserved instances saved the customer's usage against on-demand rates;")
+		fmt.Fprintln(w, "cost already reflects it.")
+	}
+}
+
+// writeMarginCSV writes one row per customer, for invoicing. Accounts are separated by spaces. A
+// commitment_benefit column is added when benefits were attributed.
+func writeMarginCSV(w io.Writer, report *MarginReport) error {
+	cw := csv.NewWriter(w)
+	header := []string{"customer", "accounts", "markup", "cost", "billed", "margin", "unit"}
+	if report.Benefits {
+		header = append(header, "commitment_benefit")
+	}
+	cw.Write(header)
+	for _, m := range report.Customers {
+		row := []string{
+			m.Customer,
+			strings.Join(m.Accounts, " "),
+			fmt.Sprintf("%.4f", m.Markup),
+			fmt.Sprintf("%.2f", m.Cost),
+			fmt.Sprintf("%.2f", m.Billed),
+			fmt.Sprintf("%.2f", m.Margin()),
+			report.Unit,
+		}
+		if report.Benefits {
+			row = append(row, fmt.Sprintf("%.2f", m.Benefit))
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var marginCmd = &cobra.Command{
+	Use:   "margin",
+	Short: "Report the margin on each reseller customer's spend.",
+	Long: `Bills each customer in reseller.customers the real cost of its linked accounts plus its markup (default
+reseller.markup) and reports cost, amount billed and margin per customer. Pass --csv to also write the rows
+to a file for invoicing, and --commitment-benefit to show what Savings Plans and reserved instances saved each
+customer's usage, as the commitments command reports per account:
+
+  cost-tracker margin --days 30 --csv margins.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		customers, err := ResellerCustomersFromViper()
+		if err != nil {
+			logger.Fatalw("Invalid reseller configuration", "error", err)
+		}
+
+		tracker, query, _ := setupReport(ctx)
+		report, err := tracker.GetResellerMargins(ctx, query, customers)
+		if err != nil {
+			errMsg := fmt.Sprintf("Error computing reseller margins: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error computing reseller margins", "error", err)
+		}
+		if withBenefit, _ := cmd.Flags().GetBool("commitment-benefit"); withBenefit {
+			benefits, err := tracker.GetCommitmentBenefits(ctx, query)
+			if err != nil {
+				errMsg := fmt.Sprintf("Error attributing commitment benefits: %v", err)
+				sendSlackNotification("Cost Tracker Error: " + errMsg)
+				logger.Fatalw("Error attributing commitment benefits", "error", err)
+			}
+			report.AttributeBenefits(benefits.Benefits())
+		}
+
+		logger.Info("Displaying reseller margins to console.")
+		out, done := consoleWriter()
+		displayMarginReport(out, report)
+		done()
+
+		if path, _ := cmd.Flags().GetString("csv"); path != "" && !explaining() {
+			path, err := artifactPath(path, cmd.Name(), query, time.Now())
+			if err != nil {
+				logger.Fatalw("Invalid --csv", "error", err)
+			}
+			f, err := os.Create(path)
+			if err != nil {
+				logger.Fatalw("Failed to create CSV file", "path", path, "error", err)
+			}
+			err = writeMarginCSV(f, report)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				logger.Fatalw("Failed to write CSV file", "path", path, "error", err)
+			}
+			if activeManifest != nil {
+				activeManifest.Artifacts = []string{path}
+			}
+			logger.Infow("Wrote reseller margins", "path", path)
+		}
+	},
+}
+
+func init() {
+	viper.SetDefault("reseller.markup", 0.0)
+
+	marginCmd.Flags().String("csv", "", "Also write the per-customer rows to this CSV file; may use {{.Command}}, {{.Date}}, {{.Start}} and {{.End}}")
+	marginCmd.Flags().Bool("commitment-benefit", false, "Add each customer's Savings Plan and reserved instance benefit")
+	rootCmd.AddCommand(marginCmd)
+}
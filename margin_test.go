@@ -0,0 +1,126 @@
+// File: margin_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestResellerCustomersFromViper(t *testing.T) {
+	viper.Set("reseller.markup", 0.1)
+	viper.Set("reseller.customers", []map[string]interface{}{
+		{"name": "Acme", "accounts": []string{"111111111111"}},
+		{"name": "Globex", "accounts": []string{"222222222222"}, "markup": 0},
+	})
+	t.Cleanup(func() {
+		viper.Set("reseller.markup", 0.0)
+		viper.Set("reseller.customers", nil)
+	})
+
+	customers, err := ResellerCustomersFromViper()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(customers) != 2 || *customers[0].Markup != 0.1 || *customers[1].Markup != 0 {
+		t.Errorf("expected the default markup only where none is set, got %+v", customers)
+	}
+
+	viper.Set("reseller.customers", []map[string]interface{}{
+		{"name": "Acme", "accounts": []string{"111111111111"}},
+		{"name": "Globex", "accounts": []string{"111111111111"}},
+	})
+	if _, err := ResellerCustomersFromViper(); err == nil || !strings.Contains(err.Error(), "both") {
+		t.Errorf("expected an error for an account claimed twice, got %v", err)
+	}
+}
+
+func TestGetResellerMargins(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	group := func(account, amount string) types.Group {
+		return types.Group{Keys: []string{account}, Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String(amount), Unit: aws.String("USD")}}}
+	}
+	mockClient := &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			if len(params.GroupBy) != 1 || aws.ToString(params.GroupBy[0].Key) != string(types.DimensionLinkedAccount) {
+				t.Errorf("expected grouping by linked account, got %+v", params.GroupBy)
+			}
+			return &costexplorer.GetCostAndUsageOutput{
+				ResultsByTime: []types.ResultByTime{{
+					TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-01-31")},
+					Groups:     []types.Group{group("111111111111", "100"), group("222222222222", "50"), group("333333333333", "400"), group("444444444444", "7")},
+				}},
+			}, nil
+		},
+	}
+	tracker := &CostTracker{client: mockClient}
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	customers := []ResellerCustomer{
+		{Name: "Acme", Accounts: []string{"111111111111", "222222222222"}, Markup: aws.Float64(0.1)},
+		{Name: "Globex", Accounts: []string{"333333333333"}, Markup: aws.Float64(0.05)},
+	}
+
+	report, err := tracker.GetResellerMargins(context.Background(), q, customers)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(report.Customers) != 2 || report.Customers[0].Customer != "Globex" {
+		t.Fatalf("expected Globex, with the larger margin, first: %+v", report.Customers)
+	}
+	acme := report.Customers[1]
+	if acme.Cost != 150 || math.Abs(acme.Billed-165) > 1e-9 || math.Abs(acme.Margin()-15) > 1e-9 {
+		t.Errorf("expected Acme to cost 150 and be billed 165, got %+v", acme)
+	}
+	if report.Unassigned != 7 {
+		t.Errorf("expected 7 of unassigned spend, got %.2f", report.Unassigned)
+	}
+
+	var buf bytes.Buffer
+	if err := writeMarginCSV(&buf, report); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	expected := "customer,accounts,markup,cost,billed,margin,unit\n" +
+		"Globex,333333333333,0.0500,400.00,420.00,20.00,USD\n" +
+		"Acme,111111111111 222222222222,0.1000,150.00,165.00,15.00,USD\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+}
+
+func TestMarginReportBenefits(t *testing.T) {
+	report := &MarginReport{
+		Unit: "USD",
+		Customers: []CustomerMargin{
+			{Customer: "Acme", Accounts: []string{"111111111111", "222222222222"}, Cost: 100, Billed: 110},
+			{Customer: "Globex", Accounts: []string{"333333333333"}, Cost: 50, Billed: 50},
+		},
+	}
+	report.AttributeBenefits(map[string]float64{"111111111111": 12, "222222222222": 3, "444444444444": 99})
+	if report.Customers[0].Benefit != 15 || report.Customers[1].Benefit != 0 {
+		t.Errorf("expected benefits of 15 and 0, got %+v", report.Customers)
+	}
+
+	var buf bytes.Buffer
+	if err := writeMarginCSV(&buf, report); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	expected := "customer,accounts,markup,cost,billed,margin,unit,commitment_benefit\n" +
+		"Acme,111111111111 222222222222,0.0000,100.00,110.00,10.00,USD,15.00\n" +
+		"Globex,333333333333,0.0000,50.00,50.00,0.00,USD,0.00\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+}
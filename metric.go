@@ -0,0 +1,75 @@
+// File: metric.go
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	MetricUnblendedCost    = "UnblendedCost"    // Metric for cost at each account's own rates
+	MetricAmortizedCost    = "AmortizedCost"    // Metric for cost with upfront and recurring commitment fees spread over usage
+	MetricNetAmortizedCost = "NetAmortizedCost" // AmortizedCost after discounts such as the Enterprise Discount Program
+	MetricNetUnblendedCost = "NetUnblendedCost" // UnblendedCost after discounts
+)
+
+// costMetrics are the --metric values, in the order they are listed in errors.
+var costMetrics = []string{
+	MetricBlendedCost,
+	MetricUnblendedCost,
+	MetricAmortizedCost,
+	MetricNetAmortizedCost,
+	MetricNetUnblendedCost,
+	MetricUsageQuantity,
+}
+
+// parseMetric resolves a --metric value case-insensitively, with - and _ ignored so net-amortized-cost
+// also works. Empty is BlendedCost.
+func parseMetric(metric string) (string, error) {
+	if metric == "" {
+		return MetricBlendedCost, nil
+	}
+	normalized := strings.NewReplacer("-", "", "_", "").Replace(metric)
+	for _, m := range costMetrics {
+		if strings.EqualFold(m, normalized) {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unknown metric %q (expected one of %s)", metric, strings.Join(costMetrics, ", "))
+}
+
+// parseMetrics resolves --metric values with parseMetric, dropping duplicates. None is BlendedCost.
+func parseMetrics(values []string) ([]string, error) {
+	if len(values) == 0 {
+		return []string{MetricBlendedCost}, nil
+	}
+	var metrics []string
+	for _, value := range values {
+		metric, err := parseMetric(value)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(metrics, metric) {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics, nil
+}
+
+// formatMetrics writes every metric of a group in costMetrics order, e.g.
+// "12.5 USD BlendedCost, 300 Hrs UsageQuantity".
+func formatMetrics(metrics map[string]MetricAmount) string {
+	var parts []string
+	for _, name := range costMetrics {
+		if m, ok := metrics[name]; ok {
+			parts = append(parts, strings.TrimSpace(m.Amount+" "+m.Unit)+" "+name)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// consoleCostAggregate returns the Cost Explorer console's name for a metric, e.g. amortizedCost.
+func consoleCostAggregate(metric string) string {
+	return strings.ToLower(metric[:1]) + metric[1:]
+}
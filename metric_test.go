@@ -0,0 +1,116 @@
+// File: metric_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestParseMetric(t *testing.T) {
+	tests := map[string]string{
+		"":                   MetricBlendedCost,
+		"AmortizedCost":      MetricAmortizedCost,
+		"net-amortized-cost": MetricNetAmortizedCost,
+		"netunblendedcost":   MetricNetUnblendedCost,
+		"usage_quantity":     MetricUsageQuantity,
+	}
+	for input, want := range tests {
+		if got, err := parseMetric(input); err != nil || got != want {
+			t.Errorf("parseMetric(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := parseMetric("amortised"); err == nil {
+		t.Error("expected an error for an unknown metric")
+	}
+	if got := consoleCostAggregate(MetricNetAmortizedCost); got != "netAmortizedCost" {
+		t.Errorf("unexpected console cost aggregate %q", got)
+	}
+}
+
+func TestGetCostsMetric(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	var requested []string
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			requested = params.Metrics
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{{
+				TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-02-01")},
+				Groups: []types.Group{{
+					Keys:    []string{"Amazon EC2"},
+					Metrics: map[string]types.MetricValue{MetricAmortizedCost: {Amount: aws.String("42"), Unit: aws.String("USD")}},
+				}},
+			}}}, nil
+		},
+	}}
+	q := CostQuery{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Metrics: []string{MetricAmortizedCost}}
+
+	costs, err := tracker.GetCosts(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(requested) != 1 || requested[0] != MetricAmortizedCost {
+		t.Errorf("expected an AmortizedCost request, got %v", requested)
+	}
+	if len(costs) != 1 || len(costs[0].Groups) != 1 || costs[0].Groups[0].Amount != "42" {
+		t.Errorf("expected the amortized amount, got %+v", costs)
+	}
+}
+
+func TestMultipleMetrics(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	metrics, err := parseMetrics([]string{"blended-cost", "UsageQuantity", "BlendedCost"})
+	if err != nil || len(metrics) != 2 || metrics[1] != MetricUsageQuantity {
+		t.Fatalf("expected BlendedCost and UsageQuantity, got %v, %v", metrics, err)
+	}
+
+	var requested []string
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			requested = params.Metrics
+			var results []types.ResultByTime
+			for _, day := range []string{"2024-01-01", "2024-01-02"} {
+				results = append(results, types.ResultByTime{
+					TimePeriod: &types.DateInterval{Start: aws.String(day), End: aws.String(day)},
+					Groups: []types.Group{{
+						Keys: []string{"Amazon EC2"},
+						Metrics: map[string]types.MetricValue{
+							MetricBlendedCost:   {Amount: aws.String("2.5"), Unit: aws.String("USD")},
+							MetricUsageQuantity: {Amount: aws.String("24"), Unit: aws.String("Hrs")},
+						},
+					}},
+				})
+			}
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: results}, nil
+		},
+	}}
+	q := CostQuery{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Metrics: metrics, Granularity: GranularityWeekly}
+
+	costs, err := tracker.GetCosts(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(requested) != 2 {
+		t.Errorf("expected both metrics in one request, got %v", requested)
+	}
+	if len(costs) != 1 {
+		t.Fatalf("expected the days summed into one week, got %+v", costs)
+	}
+	got := costs[0].Groups[0]
+	if got.Amount != "5" || got.Metrics[MetricUsageQuantity] != (MetricAmount{Amount: "48", Unit: "Hrs"}) {
+		t.Errorf("expected every metric summed over the week, got %+v", got)
+	}
+
+	var buf bytes.Buffer
+	displayCosts(&buf, costs, 2)
+	if !strings.Contains(buf.String(), ": 5 USD BlendedCost, 48 Hrs UsageQuantity") {
+		t.Errorf("expected the metrics side by side, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,101 @@
+// File: ml.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/viper"
+)
+
+// mlDefinition aggregates SageMaker, Bedrock and accelerated (GPU, Inferentia, Trainium) EC2 instances.
+// Third-party Bedrock models are billed under their own marketplace service names and are not included.
+// Only accelerated instance families are kept from EC2; other compute is dropped rather than reported as Other.
+var mlDefinition = SpotlightDefinition{
+	Title:    "ML and AI cost breakdown",
+	Services: []string{"Amazon SageMaker", "Amazon Bedrock", "Amazon Elastic Compute Cloud - Compute"},
+	Categories: []SpotlightCategory{
+		{Name: "Bedrock: model invocations", Pattern: regexp.MustCompile(`(?i)(input|output)-tokens|(Input|Output)TokenCount|-images?$`)},
+		{Name: "Bedrock: provisioned throughput", Pattern: regexp.MustCompile(`ProvisionedThroughput|ModelUnit`)},
+		{Name: "SageMaker: storage", Pattern: regexp.MustCompile(`(Notebk|Train|Host|Processing|Studio)[A-Za-z-]*:VolumeUsage`)},
+		{Name: "SageMaker: notebooks and Studio", Pattern: regexp.MustCompile(`Notebk|Studio|Canvas`)},
+		{Name: "SageMaker: training", Pattern: regexp.MustCompile(`Train(-Spot)?:ml\.`)},
+		{Name: "SageMaker: inference", Pattern: regexp.MustCompile(`Host:ml\.|AsyncInf|ServerlessInf|BatchTransform`)},
+		{Name: "SageMaker: processing", Pattern: regexp.MustCompile(`Processing:ml\.|Data-Wrangler`)},
+		{Name: "SageMaker: other", Pattern: regexp.MustCompile(`ml\.|SageMaker|FeatureStore`)},
+		{Name: "EC2: accelerated instances", Pattern: regexp.MustCompile(`(BoxUsage|SpotUsage|DedicatedUsage|HostUsage|UnusedBox):(p\d|g\d|inf\d|trn\d|dl\d)`)},
+	},
+}
+
+// annotateMLTeams attributes the matched ML usage to teams by the ml.team_tag tag.
+// The follow-up query is restricted to the usage types the report matched, so dropped EC2 usage stays out.
+func annotateMLTeams(ctx context.Context, tracker *CostTracker, q CostQuery, report *SpotlightReport) error {
+	var usageTypes []string
+	for _, line := range report.Lines {
+		for _, u := range line.UsageTypes {
+			usageTypes = append(usageTypes, u.UsageType)
+		}
+	}
+	if len(usageTypes) == 0 {
+		return nil
+	}
+
+	teamTag := viper.GetString("ml.team_tag")
+	q.Filter = andExpression(q.Filter, &types.Expression{
+		And: []types.Expression{
+			{Dimensions: &types.DimensionValues{Key: types.DimensionService, Values: mlDefinition.Services}},
+			{Dimensions: &types.DimensionValues{Key: types.DimensionUsageType, Values: usageTypes}},
+		},
+	})
+	q.GroupBy = []types.GroupDefinition{
+		{Type: types.GroupDefinitionTypeTag, Key: aws.String(teamTag)},
+	}
+	costs, err := tracker.GetCosts(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	totals, _ := totalsByService(costs)
+	teams := make(map[string]float64, len(totals))
+	var total float64
+	for groupKey, cost := range totals {
+		teams[tagValue(groupKey)] += cost
+		total += cost
+	}
+	names := make([]string, 0, len(teams))
+	for name := range teams {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if teams[names[i]] != teams[names[j]] {
+			return teams[names[i]] > teams[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	section := ReportSection{Title: fmt.Sprintf("Cost by %s", teamTag), Header: []string{"Team", "Cost", "Share"}}
+	for _, name := range names {
+		share := 0.0
+		if total > 0 {
+			share = teams[name] / total * 100
+		}
+		section.Rows = append(section.Rows, []string{name, fmt.Sprintf("%.2f", teams[name]), fmt.Sprintf("%.1f%%", share)})
+	}
+	report.Sections = append(report.Sections, section)
+	return nil
+}
+
+func init() {
+	viper.SetDefault("ml.team_tag", "team")
+
+	rootCmd.AddCommand(newSpotlightCommand("ml",
+		"Break down SageMaker, Bedrock and accelerated EC2 costs by component and team.",
+		`Aggregates AI/ML spend: SageMaker notebooks, training, inference, processing and storage, Bedrock model
+invocations and provisioned throughput, and GPU/Inferentia/Trainium EC2 instances, attributed to teams by
+the ml.team_tag cost allocation tag.`,
+		mlDefinition, annotateMLTeams))
+}
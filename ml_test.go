@@ -0,0 +1,94 @@
+// File: ml_test.go
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMLDefinition(t *testing.T) {
+	testCases := map[string]string{
+		"USE1-Claude3Sonnet-input-tokens":      "Bedrock: model invocations",
+		"USW2-TitanEmbeddings-InputTokenCount": "Bedrock: model invocations",
+		"USE1-ProvisionedThroughput-Hours":     "Bedrock: provisioned throughput",
+		"USE1-Train:VolumeUsage.gp2":           "SageMaker: storage",
+		"USE1-Notebk:ml.t3.medium":             "SageMaker: notebooks and Studio",
+		"Train:ml.p3.2xlarge":                  "SageMaker: training",
+		"USE1-Train-Spot:ml.g5.xlarge":         "SageMaker: training",
+		"USE1-Host:ml.inf2.xlarge":             "SageMaker: inference",
+		"USE1-ServerlessInf:Mem-4GB":           "SageMaker: inference",
+		"Processing:ml.m5.xlarge":              "SageMaker: processing",
+		"USE1-FeatureStore-ReadRequestUnits":   "SageMaker: other",
+		"BoxUsage:p4d.24xlarge":                "EC2: accelerated instances",
+		"USE1-SpotUsage:g5.2xlarge":            "EC2: accelerated instances",
+		"BoxUsage:trn1.32xlarge":               "EC2: accelerated instances",
+		"BoxUsage:m5.large":                    "",
+		"BoxUsage:t3.medium":                   "",
+	}
+	for usageType, expected := range testCases {
+		lines := classifyUsage([]UsageTypeCost{{UsageType: usageType, Cost: 1}}, mlDefinition)
+		got := ""
+		if len(lines) == 1 {
+			got = lines[0].Category
+		}
+		if got != expected {
+			t.Errorf("usage type %q classified as %q, expected %q", usageType, got, expected)
+		}
+	}
+}
+
+func TestAnnotateMLTeams(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	mockClient := &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			if len(params.GroupBy) != 1 || params.GroupBy[0].Type != types.GroupDefinitionTypeTag || aws.ToString(params.GroupBy[0].Key) != "team" {
+				t.Errorf("expected grouping by the team tag, got %+v", params.GroupBy)
+			}
+			if params.Filter == nil || len(params.Filter.And) != 2 || params.Filter.And[1].Dimensions.Key != types.DimensionUsageType {
+				t.Fatalf("expected the query to be restricted to matched usage types, got %+v", params.Filter)
+			}
+			if values := params.Filter.And[1].Dimensions.Values; len(values) != 2 {
+				t.Errorf("expected the two matched usage types, got %v", values)
+			}
+			return &costexplorer.GetCostAndUsageOutput{
+				ResultsByTime: []types.ResultByTime{{
+					TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-01-15")},
+					Groups: []types.Group{
+						{Keys: []string{"team$data"}, Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("75"), Unit: aws.String("USD")}}},
+						{Keys: []string{"team$"}, Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("25"), Unit: aws.String("USD")}}},
+					},
+				}},
+			}, nil
+		},
+	}
+	tracker := &CostTracker{client: mockClient}
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+	report := &SpotlightReport{
+		Lines: classifyUsage([]UsageTypeCost{
+			{UsageType: "Train:ml.p3.2xlarge", Cost: 60},
+			{UsageType: "BoxUsage:g5.xlarge", Cost: 40},
+		}, mlDefinition),
+	}
+
+	// Relies on the default ml.team_tag of "team"
+	if err := annotateMLTeams(context.Background(), tracker, q, report); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(report.Sections) != 1 {
+		t.Fatalf("expected a team section, got %d", len(report.Sections))
+	}
+	rows := report.Sections[0].Rows
+	if len(rows) != 2 || rows[0][0] != "data" || rows[0][2] != "75.0%" || rows[1][0] != UntaggedLabel {
+		t.Errorf("unexpected team rows: %v", rows)
+	}
+}
@@ -0,0 +1,608 @@
+// File: mock_provider.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/viper"
+)
+
+const (
+	ProviderAWS  = "aws"  // Real AWS Cost Explorer
+	ProviderMock = "mock" // Synthetic data generator, no AWS credentials required
+)
+
+// defaultMockServices is the service mix used when mock.services is not configured (daily cost in USD).
+var defaultMockServices = map[string]float64{
+	"Amazon Elastic Compute Cloud - Compute": 42.00,
+	"EC2 - Other":                            9.80,
+	"Amazon Relational Database Service":     18.50,
+	"Amazon Simple Storage Service":          6.20,
+	"AmazonCloudWatch":                       3.10,
+	"AWS Lambda":                             1.40,
+	"AWS Data Transfer":                      2.75,
+	"Amazon SageMaker":                       4.60,
+}
+
+// defaultMockUsageTypes lists the usage types each default service's cost is spread across.
+var defaultMockUsageTypes = map[string][]string{
+	"Amazon Elastic Compute Cloud - Compute": {"BoxUsage:m5.large", "BoxUsage:t3.medium", "SpotUsage:c5.xlarge"},
+	"EC2 - Other":                            {"EBS:VolumeUsage.gp2", "EBS:VolumeUsage.gp3", "EBS:SnapshotUsage", "NatGateway-Hours", "NatGateway-Bytes"},
+	"Amazon Relational Database Service":     {"InstanceUsage:db.r5.large", "RDS:GP2-Storage", "RDS:ChargedBackupUsage"},
+	"Amazon Simple Storage Service":          {"TimedStorage-ByteHrs", "Requests-Tier1", "Requests-Tier2"},
+	"AmazonCloudWatch":                       {"DataProcessing-Bytes", "TimedStorage-ByteHrs", "CW:MetricMonitorUsage"},
+	"AWS Lambda":                             {"Lambda-GB-Second", "Request"},
+	"AWS Data Transfer":                      {"DataTransfer-Out-Bytes", "DataTransfer-Regional-Bytes"},
+	"Amazon SageMaker":                       {"Train:ml.p3.2xlarge", "Host:ml.g5.xlarge", "Notebk:ml.t3.medium"},
+}
+
+// mockUnitPrice converts generated cost into a usage quantity for the UsageQuantity metric.
+const mockUnitPrice = 0.10
+
+// mockUsageUnit returns a plausible UsageQuantity unit for a generated usage type.
+func mockUsageUnit(usageType string) string {
+	switch {
+	case strings.Contains(usageType, "VolumeUsage"), strings.Contains(usageType, "Snapshot"),
+		strings.Contains(usageType, "Storage"), strings.Contains(usageType, "Backup"):
+		return "GB-Mo"
+	case strings.Contains(usageType, "Usage:"), strings.Contains(usageType, "Hours"), strings.Contains(usageType, ":ml."):
+		return "Hrs"
+	case strings.Contains(usageType, "Bytes"):
+		return "GB"
+	case strings.Contains(usageType, "GB-Second"):
+		return "Lambda-GB-Second"
+	case strings.Contains(usageType, "Request"):
+		return "Requests"
+	case strings.Contains(usageType, "Metric"):
+		return "Metrics"
+	}
+	return "N/A"
+}
+
+// MockService configures one generated service and its base daily cost.
+type MockService struct {
+	Name       string   `mapstructure:"name"`
+	DailyCost  float64  `mapstructure:"daily_cost"`
+	UsageTypes []string `mapstructure:"usage_types"`
+}
+
+// MockTag configures a cost allocation tag whose values the generated costs are spread across.
+type MockTag struct {
+	Key    string   `mapstructure:"key"`
+	Values []string `mapstructure:"values"`
+}
+
+// MockAnomaly multiplies a service's daily cost by Factor between Start and End (inclusive, YYYY-MM-DD).
+type MockAnomaly struct {
+	Service string  `mapstructure:"service"`
+	Start   string  `mapstructure:"start"`
+	End     string  `mapstructure:"end"`
+	Factor  float64 `mapstructure:"factor"`
+}
+
+// MockProvider is a CostExplorerAPI implementation that generates realistic synthetic cost data.
+// Amounts are derived deterministically from the seed, service, region and day, so repeated
+// queries over overlapping ranges agree with each other.
+type MockProvider struct {
+	Anchor     time.Time // Day on which each service costs exactly its base daily cost; growth is applied relative to it
+	Seed       int64
+	Services   map[string]float64  // service -> base daily cost
+	UsageTypes map[string][]string // service -> usage types its cost is spread across; none means a single unnamed usage type
+	Regions    []string
+	Accounts   []string
+	Tags       []MockTag
+	Growth     float64 // Daily compound growth rate, e.g. 0.002 for ~6% a month
+	Noise      float64 // Relative standard deviation of day-to-day noise, e.g. 0.1
+	Anomalies  []MockAnomaly
+}
+
+// mockRecord is one generated line item: the cost of a service in a region and account on a day.
+type mockRecord struct {
+	day    time.Time
+	dims   map[string]string
+	amount float64
+}
+
+// NewMockProviderFromConfig builds a MockProvider from the mock.* configuration keys.
+func NewMockProviderFromConfig() (*MockProvider, error) {
+	p := &MockProvider{
+		Anchor:     time.Now().UTC().Truncate(24 * time.Hour),
+		Seed:       viper.GetInt64("mock.seed"),
+		Services:   defaultMockServices,
+		UsageTypes: defaultMockUsageTypes,
+		Regions:    configStringSlice("mock.regions"),
+		Accounts:   configStringSlice("mock.accounts"),
+		Growth:     viper.GetFloat64("mock.growth"),
+		Noise:      viper.GetFloat64("mock.noise"),
+	}
+	if viper.IsSet("mock.services") {
+		// A list rather than a map, because Viper lowercases map keys and service names are case-sensitive.
+		var services []MockService
+		if err := unmarshalConfigKey("mock.services", &services); err != nil {
+			return nil, fmt.Errorf("invalid mock.services configuration: %w", err)
+		}
+		p.Services = make(map[string]float64, len(services))
+		p.UsageTypes = make(map[string][]string, len(services))
+		for _, service := range services {
+			p.Services[service.Name] = service.DailyCost
+			p.UsageTypes[service.Name] = service.UsageTypes
+		}
+	}
+	if err := unmarshalConfigKey("mock.tags", &p.Tags); err != nil {
+		return nil, fmt.Errorf("invalid mock.tags configuration: %w", err)
+	}
+	if err := unmarshalConfigKey("mock.anomalies", &p.Anomalies); err != nil {
+		return nil, fmt.Errorf("invalid mock.anomalies configuration: %w", err)
+	}
+	if len(p.Regions) == 0 || len(p.Accounts) == 0 {
+		return nil, fmt.Errorf("mock provider needs at least one region and one account")
+	}
+	return p, nil
+}
+
+// NewCostTrackerForProvider initializes a CostTracker backed by the named provider.
+func NewCostTrackerForProvider(ctx context.Context, provider string) (*CostTracker, error) {
+	switch provider {
+	case ProviderAWS, "":
+		return NewCostTracker(ctx)
+	case ProviderMock:
+		mock, err := NewMockProviderFromConfig()
+		if err != nil {
+			return nil, err
+		}
+		return &CostTracker{client: mock}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q (supported: %s, %s)", provider, ProviderAWS, ProviderMock)
+	}
+}
+
+// random returns a generator seeded from the provider seed and the given parts.
+func (p *MockProvider) random(parts ...string) *rand.Rand {
+	h := fnv.New64a()
+	fmt.Fprint(h, p.Seed)
+	for _, part := range parts {
+		h.Write([]byte{0})
+		h.Write([]byte(part))
+	}
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+// weights splits 1.0 deterministically across the given names for a service.
+func (p *MockProvider) weights(service string, names []string) []float64 {
+	r := p.random("weights", service, fmt.Sprint(names))
+	weights := make([]float64, len(names))
+	var total float64
+	for i := range names {
+		weights[i] = 0.2 + r.Float64()
+		total += weights[i]
+	}
+	for i := range weights {
+		weights[i] /= total
+	}
+	return weights
+}
+
+// dailyCost returns the generated total cost of a service on a day.
+func (p *MockProvider) dailyCost(service string, base float64, day time.Time) float64 {
+	days := day.Sub(p.Anchor).Hours() / 24
+	amount := base * math.Pow(1+p.Growth, days)
+	if p.Noise > 0 {
+		amount *= 1 + p.random("noise", service, day.Format(AWSDateFormat)).NormFloat64()*p.Noise
+	}
+	dayStr := day.Format(AWSDateFormat)
+	for _, anomaly := range p.Anomalies {
+		end := anomaly.End
+		if end == "" {
+			end = anomaly.Start
+		}
+		if anomaly.Service == service && dayStr >= anomaly.Start && dayStr <= end {
+			amount *= anomaly.Factor
+		}
+	}
+	return math.Max(amount, 0)
+}
+
+// records generates every line item between start (inclusive) and end (exclusive).
+func (p *MockProvider) records(start, end time.Time) []mockRecord {
+	services := make([]string, 0, len(p.Services))
+	for service := range p.Services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	var records []mockRecord
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		for _, service := range services {
+			total := p.dailyCost(service, p.Services[service], day)
+			usageTypes := p.UsageTypes[service]
+			if len(usageTypes) == 0 {
+				usageTypes = []string{""}
+			}
+			regionWeights := p.weights(service, p.Regions)
+			accountWeights := p.weights(service, p.Accounts)
+			usageWeights := p.weights(service, usageTypes)
+			for i, region := range p.Regions {
+				for j, account := range p.Accounts {
+					for k, usageType := range usageTypes {
+						dims := map[string]string{
+							string(types.DimensionService):       service,
+							string(types.DimensionRegion):        region,
+							string(types.DimensionLinkedAccount): account,
+							string(types.DimensionUsageType):     usageType,
+						}
+						records = p.appendTagged(records, day, service, dims, total*regionWeights[i]*accountWeights[j]*usageWeights[k], 0)
+					}
+				}
+			}
+		}
+	}
+	return records
+}
+
+// appendTagged spreads amount across the values of each configured tag, starting at p.Tags[tagIndex],
+// and appends one record per combination of tag values.
+func (p *MockProvider) appendTagged(records []mockRecord, day time.Time, service string, dims map[string]string, amount float64, tagIndex int) []mockRecord {
+	if tagIndex == len(p.Tags) {
+		return append(records, mockRecord{day: day, dims: dims, amount: amount})
+	}
+	tag := p.Tags[tagIndex]
+	for k, weight := range p.weights(service+"/"+tag.Key, tag.Values) {
+		tagged := make(map[string]string, len(dims)+1)
+		for key, value := range dims {
+			tagged[key] = value
+		}
+		tagged[mockTagDimension(tag.Key)] = tag.Values[k]
+		records = p.appendTagged(records, day, service, tagged, amount*weight, tagIndex+1)
+	}
+	return records
+}
+
+// mockTagDimension is the record dimension key under which a tag value is stored.
+func mockTagDimension(key string) string {
+	return "tag:" + key
+}
+
+// matchesExpression evaluates a Cost Explorer filter expression against a record's dimensions.
+// Cost category filters are not modelled and never match.
+func matchesExpression(expr *types.Expression, dims map[string]string) bool {
+	if expr == nil {
+		return true
+	}
+	switch {
+	case len(expr.And) > 0:
+		for i := range expr.And {
+			if !matchesExpression(&expr.And[i], dims) {
+				return false
+			}
+		}
+		return true
+	case len(expr.Or) > 0:
+		for i := range expr.Or {
+			if matchesExpression(&expr.Or[i], dims) {
+				return true
+			}
+		}
+		return false
+	case expr.Not != nil:
+		return !matchesExpression(expr.Not, dims)
+	case expr.Dimensions != nil:
+		value := dims[string(expr.Dimensions.Key)]
+		for _, candidate := range expr.Dimensions.Values {
+			if candidate == value {
+				return true
+			}
+		}
+		return false
+	case expr.Tags != nil:
+		value, ok := dims[mockTagDimension(aws.ToString(expr.Tags.Key))]
+		if !ok {
+			return false
+		}
+		for _, candidate := range expr.Tags.Values {
+			if candidate == value {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// parseDateInterval parses a Cost Explorer DateInterval into start and end times.
+func parseDateInterval(interval *types.DateInterval) (time.Time, time.Time, error) {
+	if interval == nil || interval.Start == nil || interval.End == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("time period is required")
+	}
+	start, err := time.Parse(AWSDateFormat, *interval.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start date %q: %w", *interval.Start, err)
+	}
+	end, err := time.Parse(AWSDateFormat, *interval.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end date %q: %w", *interval.End, err)
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start date %s must be before end date %s", *interval.Start, *interval.End)
+	}
+	return start, end, nil
+}
+
+// periodStart returns the start of the result period a day falls into.
+func periodStart(day time.Time, granularity types.Granularity) time.Time {
+	if granularity == types.GranularityMonthly {
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// GetCostAndUsage satisfies the CostExplorerAPI interface with generated data.
+func (p *MockProvider) GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+	start, end, err := parseDateInterval(params.TimePeriod)
+	if err != nil {
+		return nil, err
+	}
+	if params.Granularity != types.GranularityMonthly && params.Granularity != types.GranularityDaily {
+		return nil, fmt.Errorf("granularity %s is not supported by the mock provider", params.Granularity)
+	}
+
+	type groupTotals struct {
+		keys         []string
+		amount       float64
+		quantityUnit string
+	}
+	var periods []time.Time
+	totals := make(map[time.Time]map[string]*groupTotals)
+	for _, record := range p.records(start, end) {
+		if !matchesExpression(params.Filter, record.dims) {
+			continue
+		}
+		period := periodStart(record.day, params.Granularity)
+		if _, ok := totals[period]; !ok {
+			totals[period] = make(map[string]*groupTotals)
+			periods = append(periods, period)
+		}
+		var keys []string
+		for _, group := range params.GroupBy {
+			if group.Type == types.GroupDefinitionTypeTag {
+				// Cost Explorer reports tag groups as "key$value", with an empty value for untagged costs
+				keys = append(keys, aws.ToString(group.Key)+"$"+record.dims[mockTagDimension(aws.ToString(group.Key))])
+				continue
+			}
+			keys = append(keys, record.dims[aws.ToString(group.Key)])
+		}
+		id := fmt.Sprint(keys)
+		unit := mockUsageUnit(record.dims[string(types.DimensionUsageType)])
+		group, ok := totals[period][id]
+		if !ok {
+			group = &groupTotals{keys: keys, quantityUnit: unit}
+			totals[period][id] = group
+		}
+		group.amount += record.amount
+		if group.quantityUnit != unit {
+			group.quantityUnit = "N/A" // Like Cost Explorer, quantities of mixed usage types have no unit
+		}
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	output := &costexplorer.GetCostAndUsageOutput{}
+	for _, period := range periods {
+		periodEnd := period.AddDate(0, 0, 1)
+		if params.Granularity == types.GranularityMonthly {
+			periodEnd = period.AddDate(0, 1, 0)
+		}
+		periodStartDate := period
+		if periodStartDate.Before(start) {
+			periodStartDate = start
+		}
+		if periodEnd.After(end) {
+			periodEnd = end
+		}
+
+		ids := make([]string, 0, len(totals[period]))
+		for id := range totals[period] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		resultByTime := types.ResultByTime{
+			TimePeriod: &types.DateInterval{
+				Start: aws.String(periodStartDate.Format(AWSDateFormat)),
+				End:   aws.String(periodEnd.Format(AWSDateFormat)),
+			},
+			Estimated: !periodEnd.Before(today),
+		}
+		for _, id := range ids {
+			group := totals[period][id]
+			metrics := make(map[string]types.MetricValue)
+			for _, metric := range params.Metrics {
+				if metric == MetricUsageQuantity {
+					metrics[metric] = types.MetricValue{
+						Amount: aws.String(strconv.FormatFloat(group.amount/mockUnitPrice, 'f', 10, 64)),
+						Unit:   aws.String(group.quantityUnit),
+					}
+					continue
+				}
+				metrics[metric] = types.MetricValue{
+					Amount: aws.String(strconv.FormatFloat(group.amount, 'f', 10, 64)),
+					Unit:   aws.String("USD"),
+				}
+			}
+			if len(params.GroupBy) == 0 {
+				resultByTime.Total = metrics // Like Cost Explorer, ungrouped results have only a total
+				continue
+			}
+			resultByTime.Groups = append(resultByTime.Groups, types.Group{Keys: group.keys, Metrics: metrics})
+		}
+		output.ResultsByTime = append(output.ResultsByTime, resultByTime)
+	}
+	return output, nil
+}
+
+// GetDimensionValues satisfies the CostExplorerAPI interface with the generated dimension values.
+func (p *MockProvider) GetDimensionValues(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+	start, end, err := parseDateInterval(params.TimePeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	output := &costexplorer.GetDimensionValuesOutput{}
+	for _, record := range p.records(start, end) {
+		value, ok := record.dims[string(params.Dimension)]
+		if !ok {
+			return nil, fmt.Errorf("dimension %s is not supported by the mock provider", params.Dimension)
+		}
+		if seen[value] || !matchesExpression(params.Filter, record.dims) {
+			continue
+		}
+		seen[value] = true
+		output.DimensionValues = append(output.DimensionValues, types.DimensionValuesWithAttributes{Value: aws.String(value)})
+	}
+	return output, nil
+}
+
+// GetReservationCoverage satisfies the CostExplorerAPI interface. The mock generates no reservations,
+// so every usage is reported as uncovered.
+func (p *MockProvider) GetReservationCoverage(ctx context.Context, params *costexplorer.GetReservationCoverageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error) {
+	if _, _, err := parseDateInterval(params.TimePeriod); err != nil {
+		return nil, err
+	}
+	return &costexplorer.GetReservationCoverageOutput{}, nil
+}
+
+// GetCostForecast satisfies the CostExplorerAPI interface with the generated cost of the forecast
+// range, which the mock can produce for future days too. The bounds widen with mock.noise and the
+// prediction interval level.
+func (p *MockProvider) GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+	start, end, err := parseDateInterval(params.TimePeriod)
+	if err != nil {
+		return nil, err
+	}
+	if params.Granularity != types.GranularityMonthly && params.Granularity != types.GranularityDaily {
+		return nil, fmt.Errorf("granularity %s is not supported by the mock provider", params.Granularity)
+	}
+
+	var periods []time.Time
+	means := make(map[time.Time]float64)
+	for _, record := range p.records(start, end) {
+		if !matchesExpression(params.Filter, record.dims) {
+			continue
+		}
+		period := periodStart(record.day, params.Granularity)
+		if _, ok := means[period]; !ok {
+			periods = append(periods, period)
+		}
+		means[period] += record.amount
+	}
+
+	spread := 2 * p.Noise * float64(aws.ToInt32(params.PredictionIntervalLevel)) / 80
+	format := func(amount float64) *string { return aws.String(strconv.FormatFloat(amount, 'f', 10, 64)) }
+	output := &costexplorer.GetCostForecastOutput{Total: &types.MetricValue{Unit: aws.String("USD")}}
+	var total float64
+	for _, period := range periods {
+		periodEnd := period.AddDate(0, 0, 1)
+		if params.Granularity == types.GranularityMonthly {
+			periodEnd = period.AddDate(0, 1, 0)
+		}
+		if period.Before(start) {
+			period = start
+		}
+		if periodEnd.After(end) {
+			periodEnd = end
+		}
+		mean := means[periodStart(period, params.Granularity)]
+		total += mean
+		output.ForecastResultsByTime = append(output.ForecastResultsByTime, types.ForecastResult{
+			TimePeriod:                   &types.DateInterval{Start: aws.String(period.Format(AWSDateFormat)), End: aws.String(periodEnd.Format(AWSDateFormat))},
+			MeanValue:                    format(mean),
+			PredictionIntervalLowerBound: format(mean * math.Max(1-spread, 0)),
+			PredictionIntervalUpperBound: format(mean * (1 + spread)),
+		})
+	}
+	output.Total.Amount = format(total)
+	return output, nil
+}
+
+// GetAnomalies satisfies the CostExplorerAPI interface with the configured mock.anomalies whose last
+// day falls in the date interval. The expected spend is the generated spend without the anomaly's
+// factor, and the root cause is the service in its largest region and account.
+func (p *MockProvider) GetAnomalies(ctx context.Context, params *costexplorer.GetAnomaliesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomaliesOutput, error) {
+	if params.DateInterval == nil || params.DateInterval.StartDate == nil {
+		return nil, fmt.Errorf("date interval is required")
+	}
+	from := aws.ToString(params.DateInterval.StartDate)
+	to := aws.ToString(params.DateInterval.EndDate)
+	if params.TotalImpact != nil && params.TotalImpact.NumericOperator != types.NumericOperatorGreaterThanOrEqual {
+		return nil, fmt.Errorf("total impact operator %s is not supported by the mock provider", params.TotalImpact.NumericOperator)
+	}
+
+	output := &costexplorer.GetAnomaliesOutput{}
+	for i, anomaly := range p.Anomalies {
+		end := anomaly.End
+		if end == "" {
+			end = anomaly.Start
+		}
+		if end < from || (to != "" && end > to) || anomaly.Factor == 0 {
+			continue
+		}
+		start, err := time.Parse(AWSDateFormat, anomaly.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mock anomaly start %q: %w", anomaly.Start, err)
+		}
+		last, err := time.Parse(AWSDateFormat, end)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mock anomaly end %q: %w", end, err)
+		}
+		var actual float64
+		for day := start; !day.After(last); day = day.AddDate(0, 0, 1) {
+			actual += p.dailyCost(anomaly.Service, p.Services[anomaly.Service], day)
+		}
+		expected := actual / anomaly.Factor
+		impact := actual - expected
+		if params.TotalImpact != nil && impact < params.TotalImpact.StartValue {
+			continue
+		}
+
+		largest := func(names []string) string {
+			weights := p.weights(anomaly.Service, names)
+			best := 0
+			for j := range weights {
+				if weights[j] > weights[best] {
+					best = j
+				}
+			}
+			return names[best]
+		}
+		output.Anomalies = append(output.Anomalies, types.Anomaly{
+			AnomalyId:        aws.String(fmt.Sprintf("mock-anomaly-%d", i)),
+			MonitorArn:       aws.String("arn:aws:ce::" + p.Accounts[0] + ":anomalymonitor/mock"),
+			AnomalyStartDate: aws.String(anomaly.Start),
+			AnomalyEndDate:   aws.String(end),
+			DimensionValue:   aws.String(anomaly.Service),
+			AnomalyScore:     &types.AnomalyScore{CurrentScore: anomaly.Factor, MaxScore: anomaly.Factor},
+			Impact: &types.Impact{
+				MaxImpact:          impact / (last.Sub(start).Hours()/24 + 1),
+				TotalImpact:        impact,
+				TotalActualSpend:   aws.Float64(actual),
+				TotalExpectedSpend: aws.Float64(expected),
+			},
+			RootCauses: []types.RootCause{{
+				Service:       aws.String(anomaly.Service),
+				LinkedAccount: aws.String(largest(p.Accounts)),
+				Region:        aws.String(largest(p.Regions)),
+			}},
+		})
+	}
+	return output, nil
+}
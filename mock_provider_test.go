@@ -0,0 +1,157 @@
+// File: mock_provider_test.go
+package main
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+// newTestMockProvider returns a MockProvider with a fixed anchor and a small, predictable service mix.
+func newTestMockProvider() *MockProvider {
+	return &MockProvider{
+		Anchor:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Seed:     42,
+		Services: map[string]float64{"Amazon EC2": 10, "Amazon S3": 2},
+		Regions:  []string{"us-east-1", "eu-west-1"},
+		Accounts: []string{"111111111111"},
+	}
+}
+
+// totalCost sums every group amount in a GetCostAndUsage response.
+func totalCost(t *testing.T, output *costexplorer.GetCostAndUsageOutput) float64 {
+	t.Helper()
+	var total float64
+	for _, resultByTime := range output.ResultsByTime {
+		for _, group := range resultByTime.Groups {
+			amount, err := strconv.ParseFloat(*group.Metrics[MetricBlendedCost].Amount, 64)
+			if err != nil {
+				t.Fatalf("unparseable amount: %v", err)
+			}
+			total += amount
+		}
+	}
+	return total
+}
+
+func TestMockProviderGetCostAndUsage(t *testing.T) {
+	ctx := context.Background()
+	interval := &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-01-11")}
+	groupByService := []types.GroupDefinition{{Type: GroupByTypeDimension, Key: aws.String(GroupByServiceKey)}}
+
+	testCases := []struct {
+		name          string
+		configure     func(p *MockProvider)
+		input         *costexplorer.GetCostAndUsageInput
+		expectedTotal float64
+		expectedError bool
+	}{
+		{
+			name:          "base costs without growth or noise",
+			input:         &costexplorer.GetCostAndUsageInput{TimePeriod: interval, Granularity: types.GranularityMonthly, Metrics: []string{MetricBlendedCost}, GroupBy: groupByService},
+			expectedTotal: 120, // 10 days * (10 + 2)
+		},
+		{
+			name: "anomaly multiplies a single day",
+			configure: func(p *MockProvider) {
+				p.Anomalies = []MockAnomaly{{Service: "Amazon EC2", Start: "2024-01-05", Factor: 5}}
+			},
+			input:         &costexplorer.GetCostAndUsageInput{TimePeriod: interval, Granularity: types.GranularityDaily, Metrics: []string{MetricBlendedCost}, GroupBy: groupByService},
+			expectedTotal: 160, // 120 + 4 * 10
+		},
+		{
+			name: "filter restricts to one service",
+			input: &costexplorer.GetCostAndUsageInput{
+				TimePeriod:  interval,
+				Granularity: types.GranularityMonthly,
+				Metrics:     []string{MetricBlendedCost},
+				GroupBy:     groupByService,
+				Filter:      &types.Expression{Dimensions: &types.DimensionValues{Key: types.DimensionService, Values: []string{"Amazon S3"}}},
+			},
+			expectedTotal: 20,
+		},
+		{
+			name:          "hourly granularity is unsupported",
+			input:         &costexplorer.GetCostAndUsageInput{TimePeriod: interval, Granularity: types.GranularityHourly, Metrics: []string{MetricBlendedCost}},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestMockProvider()
+			if tc.configure != nil {
+				tc.configure(p)
+			}
+
+			output, err := p.GetCostAndUsage(ctx, tc.input)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("expected an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error, but got: %v", err)
+			}
+			if got := totalCost(t, output); math.Abs(got-tc.expectedTotal) > 1e-6 {
+				t.Errorf("expected total %.2f, got %.2f", tc.expectedTotal, got)
+			}
+		})
+	}
+}
+
+func TestMockProviderIsDeterministic(t *testing.T) {
+	ctx := context.Background()
+	p := newTestMockProvider()
+	p.Growth = 0.01
+	p.Noise = 0.2
+
+	input := &costexplorer.GetCostAndUsageInput{
+		TimePeriod:  &types.DateInterval{Start: aws.String("2024-02-01"), End: aws.String("2024-03-01")},
+		Granularity: types.GranularityDaily,
+		Metrics:     []string{MetricBlendedCost},
+	}
+	first, err := p.GetCostAndUsage(ctx, input)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	second, err := p.GetCostAndUsage(ctx, input)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if totalCost(t, first) != totalCost(t, second) {
+		t.Errorf("expected identical totals for identical queries, got %f and %f", totalCost(t, first), totalCost(t, second))
+	}
+	if len(first.ResultsByTime) != 29 {
+		t.Errorf("expected 29 daily periods in February 2024, got %d", len(first.ResultsByTime))
+	}
+}
+
+func TestMockProviderPerRegion(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	tracker := &CostTracker{client: newTestMockProvider()}
+
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC),
+	}
+	matrix, err := tracker.GetCostsPerRegion(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(matrix.Regions) != 2 {
+		t.Fatalf("expected 2 regions, got %v", matrix.Regions)
+	}
+	ec2 := matrix.Amounts["Amazon EC2"]["us-east-1"] + matrix.Amounts["Amazon EC2"]["eu-west-1"]
+	if math.Abs(ec2-100) > 1e-6 {
+		t.Errorf("expected EC2 regional costs to add up to 100.00, got %.2f", ec2)
+	}
+}
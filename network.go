@@ -0,0 +1,77 @@
+// File: network.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+const NetworkCategoryNATProcessing = "NAT gateway: data processing"
+
+// networkDefinition highlights the networking charges that are most often misread: NAT gateway processing,
+// VPC endpoints, Transit Gateway and traffic between availability zones and regions.
+// NAT gateways bill under "EC2 - Other" alongside EBS, which is dropped here rather than reported as Other.
+var networkDefinition = SpotlightDefinition{
+	Title:    "Network cost breakdown",
+	Services: []string{EBSServiceName, "Amazon Virtual Private Cloud", "AWS Data Transfer"},
+	Categories: []SpotlightCategory{
+		{Name: NetworkCategoryNATProcessing, Pattern: regexp.MustCompile(`NatGateway-Bytes`)},
+		{Name: "NAT gateway: hours", Pattern: regexp.MustCompile(`NatGateway-Hours`)},
+		{Name: "VPC endpoints", Pattern: regexp.MustCompile(`VpcEndpoint`)},
+		{Name: "Transit Gateway: attachments", Pattern: regexp.MustCompile(`TransitGateway-Hours`)},
+		{Name: "Transit Gateway: data processing", Pattern: regexp.MustCompile(`TransitGateway-Bytes`)},
+		{Name: "Cross-AZ traffic", Pattern: regexp.MustCompile(`DataTransfer-Regional-Bytes`)},
+		{Name: "Inter-region traffic", Pattern: regexp.MustCompile(`AWS-(In|Out)-Bytes`)},
+		{Name: "Internet egress", Pattern: regexp.MustCompile(`DataTransfer-Out-Bytes`)},
+		{Name: "Public IPv4 addresses", Pattern: regexp.MustCompile(`PublicIPv4`)},
+	},
+}
+
+// usageTypeSection lists every usage type of the report with its category, cost and quantity, costliest first.
+func usageTypeSection(report *SpotlightReport) ReportSection {
+	type row struct {
+		category string
+		usage    UsageTypeCost
+	}
+	var rows []row
+	for _, line := range report.Lines {
+		for _, u := range line.UsageTypes {
+			rows = append(rows, row{category: line.Category, usage: u})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].usage.Cost > rows[j].usage.Cost })
+
+	section := ReportSection{Title: "By usage type", Header: []string{"Usage type", "Cost", "Quantity", "Category"}}
+	for _, r := range rows {
+		quantity := ""
+		if r.usage.QuantityUnit != "" {
+			quantity = fmt.Sprintf("%.2f %s", r.usage.Quantity, r.usage.QuantityUnit)
+		}
+		section.Rows = append(section.Rows, []string{r.usage.UsageType, fmt.Sprintf("%.2f", r.usage.Cost), quantity, r.category})
+	}
+	return section
+}
+
+// annotateNetwork adds the per-usage-type breakdown and a hint when NAT gateway processing dominates.
+func annotateNetwork(ctx context.Context, tracker *CostTracker, q CostQuery, report *SpotlightReport) error {
+	if len(report.Lines) == 0 {
+		return nil
+	}
+	report.Sections = append(report.Sections, usageTypeSection(report))
+	if line := report.Line(NetworkCategoryNATProcessing); line != nil && line.Cost > report.Total()/4 {
+		report.Notes = append(report.Notes, fmt.Sprintf(
+			"NAT gateway data processing is %.1f%% of network spend. Traffic to S3 and DynamoDB can bypass NAT through free gateway VPC endpoints.",
+			line.Cost/report.Total()*100))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(newSpotlightCommand("network",
+		"Break down NAT gateway, VPC endpoint, Transit Gateway and cross-AZ traffic costs.",
+		`Highlights NAT gateway processing and hours, VPC endpoints, Transit Gateway attachments and data, and
+traffic between availability zones and regions, with the cost and quantity of every usage type.`,
+		networkDefinition, annotateNetwork))
+}
@@ -0,0 +1,57 @@
+// File: network_test.go
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNetworkDefinition(t *testing.T) {
+	testCases := map[string]string{
+		"NatGateway-Bytes":             "NAT gateway: data processing",
+		"APS2-NatGateway-Hours":        "NAT gateway: hours",
+		"USE1-VpcEndpoint-Hours":       "VPC endpoints",
+		"VpcEndpoint-Bytes":            "VPC endpoints",
+		"TransitGateway-Hours":         "Transit Gateway: attachments",
+		"USE1-TransitGateway-Bytes":    "Transit Gateway: data processing",
+		"DataTransfer-Regional-Bytes":  "Cross-AZ traffic",
+		"USE1-EUC1-AWS-Out-Bytes":      "Inter-region traffic",
+		"APS2-DataTransfer-Out-Bytes":  "Internet egress",
+		"USE1-PublicIPv4:InUseAddress": "Public IPv4 addresses",
+		"EBS:VolumeUsage.gp2":          "",
+	}
+	for usageType, expected := range testCases {
+		lines := classifyUsage([]UsageTypeCost{{UsageType: usageType, Cost: 1}}, networkDefinition)
+		got := ""
+		if len(lines) == 1 {
+			got = lines[0].Category
+		}
+		if got != expected {
+			t.Errorf("usage type %q classified as %q, expected %q", usageType, got, expected)
+		}
+	}
+}
+
+func TestAnnotateNetwork(t *testing.T) {
+	usage := []UsageTypeCost{
+		{UsageType: "NatGateway-Hours", Cost: 5, Quantity: 110, QuantityUnit: "Hrs"},
+		{UsageType: "NatGateway-Bytes", Cost: 30, Quantity: 666, QuantityUnit: "GB"},
+		{UsageType: "DataTransfer-Regional-Bytes", Cost: 10, Quantity: 1000, QuantityUnit: "GB"},
+	}
+	report := &SpotlightReport{Lines: classifyUsage(usage, networkDefinition)}
+
+	if err := annotateNetwork(context.Background(), nil, CostQuery{}, report); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(report.Sections) != 1 {
+		t.Fatalf("expected a usage type section, got %d", len(report.Sections))
+	}
+	rows := report.Sections[0].Rows
+	if len(rows) != 3 || rows[0][0] != "NatGateway-Bytes" || rows[0][2] != "666.00 GB" || rows[2][3] != "NAT gateway: hours" {
+		t.Errorf("unexpected usage type rows: %v", rows)
+	}
+	if len(report.Notes) != 1 || !strings.Contains(report.Notes[0], "66.7%") {
+		t.Errorf("expected a NAT processing note, got %v", report.Notes)
+	}
+}
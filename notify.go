@@ -0,0 +1,125 @@
+// File: notify.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Notifier delivers a plain-text message to a single notification channel.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, message string) error
+}
+
+// slackNotifier posts messages to a Slack incoming webhook through the configured HTTP client.
+type slackNotifier struct {
+	webhookURL string
+}
+
+func (n *slackNotifier) Name() string { return "slack" }
+
+func (n *slackNotifier) Notify(ctx context.Context, message string) error {
+	client, err := HTTPConfigFromViper().NewHTTPClient()
+	if err != nil {
+		return err
+	}
+	return slack.PostWebhookCustomHTTPContext(ctx, n.webhookURL, client, &slack.WebhookMessage{Text: message})
+}
+
+// configuredNotifiers returns a notifier for every channel that has been configured.
+func configuredNotifiers() []Notifier {
+	var notifiers []Notifier
+	if webhookURL := viper.GetString("slack.webhook_url"); webhookURL != "" {
+		notifiers = append(notifiers, &slackNotifier{webhookURL: webhookURL})
+	}
+	return notifiers
+}
+
+// NotifyResult records the outcome of sending a message through one notifier.
+type NotifyResult struct {
+	Channel string
+	Latency time.Duration
+	Err     error
+}
+
+// notifyAll sends the message through every notifier concurrently and returns
+// one result per notifier, in the same order as notifiers.
+func notifyAll(ctx context.Context, notifiers []Notifier, message string) []NotifyResult {
+	results := make([]NotifyResult, len(notifiers))
+	var wg sync.WaitGroup
+	for i, notifier := range notifiers {
+		wg.Add(1)
+		go func(i int, notifier Notifier) {
+			defer wg.Done()
+			start := time.Now()
+			err := notifier.Notify(ctx, message)
+			results[i] = NotifyResult{
+				Channel: notifier.Name(),
+				Latency: time.Since(start),
+				Err:     err,
+			}
+		}(i, notifier)
+	}
+	wg.Wait()
+	return results
+}
+
+// sampleReportMessage builds the message sent by 'notify test'.
+func sampleReportMessage() string {
+	return "Cost Tracker test notification: this is a sample report.\n" +
+		"AWS Costs for the last 30 days:\n" +
+		"  Amazon Elastic Compute Cloud - Compute: 123.45 USD\n" +
+		"  Amazon Simple Storage Service: 6.78 USD\n" +
+		"If you can read this, the channel is configured correctly."
+}
+
+// displayNotifyResults prints one line per channel and reports whether all channels succeeded.
+func displayNotifyResults(results []NotifyResult) bool {
+	ok := true
+	for _, result := range results {
+		status := "OK"
+		if result.Err != nil {
+			status = fmt.Sprintf("FAILED (%v)", result.Err)
+			ok = false
+		}
+		fmt.Printf("  %-10s %8dms  %s\n", result.Channel, result.Latency.Milliseconds(), status)
+	}
+	return ok
+}
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Manage and verify notification channels.",
+}
+
+var notifyTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Send a sample report through every configured notification channel.",
+	Long:  `Sends a sample report through every configured notifier concurrently and reports per-channel success or failure with latency.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		notifiers := configuredNotifiers()
+		if len(notifiers) == 0 {
+			logger.Fatal("No notification channels configured. Set COSTTRACKER_SLACK_WEBHOOK_URL or configure slack.webhook_url in cost-tracker-config.json.")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		fmt.Printf("Sending test notification to %d channel(s):\n", len(notifiers))
+		if !displayNotifyResults(notifyAll(ctx, notifiers, withRunID(sampleReportMessage()))) {
+			logger.Fatal("One or more notification channels failed.")
+		}
+	},
+}
+
+func init() {
+	notifyCmd.AddCommand(notifyTestCmd)
+	rootCmd.AddCommand(notifyCmd)
+}
@@ -0,0 +1,51 @@
+// File: notify_test.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeNotifier is a Notifier that records the message it was sent and optionally fails.
+type fakeNotifier struct {
+	name     string
+	delay    time.Duration
+	err      error
+	received string
+}
+
+func (f *fakeNotifier) Name() string { return f.name }
+
+func (f *fakeNotifier) Notify(ctx context.Context, message string) error {
+	time.Sleep(f.delay)
+	f.received = message
+	return f.err
+}
+
+func TestNotifyAll(t *testing.T) {
+	ok := &fakeNotifier{name: "ok", delay: 20 * time.Millisecond}
+	failing := &fakeNotifier{name: "failing", err: fmt.Errorf("simulated webhook error")}
+
+	results := notifyAll(context.Background(), []Notifier{ok, failing}, "hello")
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Channel != "ok" || results[0].Err != nil {
+		t.Errorf("expected first channel 'ok' to succeed, got %+v", results[0])
+	}
+	if results[0].Latency < 20*time.Millisecond {
+		t.Errorf("expected latency of at least 20ms, got %v", results[0].Latency)
+	}
+	if results[1].Channel != "failing" || results[1].Err == nil {
+		t.Errorf("expected second channel 'failing' to report an error, got %+v", results[1])
+	}
+	if ok.received != "hello" || failing.received != "hello" {
+		t.Errorf("expected every notifier to receive the message, got %q and %q", ok.received, failing.received)
+	}
+	if displayNotifyResults(results) {
+		t.Errorf("expected displayNotifyResults to report a failure")
+	}
+}
@@ -0,0 +1,52 @@
+// File: observability.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+const ObservabilityCategoryLogsIngestion = "CloudWatch Logs: ingestion"
+
+// observabilityDefinition gathers CloudWatch, X-Ray and the managed Grafana/Prometheus services.
+// CloudWatch bills log ingestion as DataProcessing-Bytes and log storage as TimedStorage-ByteHrs.
+var observabilityDefinition = SpotlightDefinition{
+	Title:    "Observability cost breakdown",
+	Services: []string{"AmazonCloudWatch", "AWS X-Ray", "Amazon Managed Grafana", "Amazon Managed Service for Prometheus"},
+	Categories: []SpotlightCategory{
+		{Name: ObservabilityCategoryLogsIngestion, Pattern: regexp.MustCompile(`DataProcessing(IA)?-Bytes|VendedLog-Bytes`)},
+		{Name: "CloudWatch Logs: storage", Pattern: regexp.MustCompile(`TimedStorage-ByteHrs`)},
+		{Name: "CloudWatch Logs: Insights queries", Pattern: regexp.MustCompile(`DataScanned-Bytes`)},
+		{Name: "CloudWatch: metrics", Pattern: regexp.MustCompile(`CW:(MetricMonitorUsage|MetricStreamUsage)`)},
+		{Name: "CloudWatch: API requests", Pattern: regexp.MustCompile(`CW:(Requests|GMD-Metrics|GMWI-Metrics)`)},
+		{Name: "CloudWatch: alarms", Pattern: regexp.MustCompile(`CW:AlarmMonitorUsage|CW:HighResAlarmMonitorUsage|CW:CompositeAlarmMonitorUsage`)},
+		{Name: "CloudWatch: dashboards", Pattern: regexp.MustCompile(`DashboardsUsageHour`)},
+		{Name: "CloudWatch: Synthetics and RUM", Pattern: regexp.MustCompile(`CW:Canary-runs|RUM-event`)},
+		{Name: "X-Ray", Pattern: regexp.MustCompile(`XRay-`)},
+		{Name: "Managed Grafana", Pattern: regexp.MustCompile(`(Editor|Viewer)License|Grafana`)},
+		{Name: "Managed Prometheus", Pattern: regexp.MustCompile(`AMP:`)},
+	},
+	Other: "Other observability usage",
+}
+
+// annotateLogsIngestionShare notes how much of observability spend is log ingestion, usually the line that balloons.
+func annotateLogsIngestionShare(ctx context.Context, tracker *CostTracker, q CostQuery, report *SpotlightReport) error {
+	line := report.Line(ObservabilityCategoryLogsIngestion)
+	total := report.Total()
+	if line == nil || total == 0 {
+		return nil
+	}
+	report.Notes = append(report.Notes, fmt.Sprintf(
+		"Log ingestion is %.1f%% of observability spend (~%.2f %s per month). Check log levels and retention on the noisiest log groups first.",
+		line.Cost/total*100, line.Cost/report.Days*DaysPerMonth, report.Unit))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(newSpotlightCommand("observability",
+		"Break down CloudWatch, X-Ray and managed Grafana/Prometheus costs.",
+		`Isolates observability spend that is otherwise scattered across the service list: CloudWatch Logs ingestion,
+storage and queries, metrics, alarms, dashboards, X-Ray, and Amazon Managed Grafana and Prometheus.`,
+		observabilityDefinition, annotateLogsIngestionShare))
+}
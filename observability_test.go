@@ -0,0 +1,62 @@
+// File: observability_test.go
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestObservabilityDefinition(t *testing.T) {
+	testCases := map[string]string{
+		"USE1-DataProcessing-Bytes":   "CloudWatch Logs: ingestion",
+		"VendedLog-Bytes":             "CloudWatch Logs: ingestion",
+		"TimedStorage-ByteHrs":        "CloudWatch Logs: storage",
+		"APS2-DataScanned-Bytes":      "CloudWatch Logs: Insights queries",
+		"CW:MetricMonitorUsage":       "CloudWatch: metrics",
+		"EUC1-CW:MetricStreamUsage":   "CloudWatch: metrics",
+		"CW:Requests":                 "CloudWatch: API requests",
+		"CW:AlarmMonitorUsage":        "CloudWatch: alarms",
+		"DashboardsUsageHour-Basic":   "CloudWatch: dashboards",
+		"USE1-CW:Canary-runs":         "CloudWatch: Synthetics and RUM",
+		"XRay-TracesStored":           "X-Ray",
+		"EditorLicense":               "Managed Grafana",
+		"USE1-AMP:MetricSampleCount":  "Managed Prometheus",
+		"USE1-DataTransfer-Out-Bytes": "Other observability usage",
+	}
+	for usageType, expected := range testCases {
+		lines := classifyUsage([]UsageTypeCost{{UsageType: usageType, Cost: 1}}, observabilityDefinition)
+		got := ""
+		if len(lines) == 1 {
+			got = lines[0].Category
+		}
+		if got != expected {
+			t.Errorf("usage type %q classified as %q, expected %q", usageType, got, expected)
+		}
+	}
+}
+
+func TestAnnotateLogsIngestionShare(t *testing.T) {
+	report := &SpotlightReport{
+		Days: 15,
+		Unit: "USD",
+		Lines: []SpotlightLine{
+			{Category: ObservabilityCategoryLogsIngestion, Cost: 30},
+			{Category: "CloudWatch: metrics", Cost: 10},
+		},
+	}
+	if err := annotateLogsIngestionShare(context.Background(), nil, CostQuery{}, report); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(report.Notes) != 1 || !strings.Contains(report.Notes[0], "75.0%") || !strings.Contains(report.Notes[0], "~60.00 USD per month") {
+		t.Errorf("unexpected ingestion note: %v", report.Notes)
+	}
+
+	empty := &SpotlightReport{Days: 15}
+	if err := annotateLogsIngestionShare(context.Background(), nil, CostQuery{}, empty); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(empty.Notes) != 0 {
+		t.Errorf("expected no note without log ingestion, got %v", empty.Notes)
+	}
+}
@@ -0,0 +1,206 @@
+// File: offhours.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	HoursPerWeek  = 7 * 24       // Hours in a week, the period an off-hours schedule repeats over
+	UntaggedLabel = "(untagged)" // Label for costs without a value for the grouping tag
+	DaysPerMonth  = 30           // Days used to project window totals to a monthly figure
+	tagValueSep   = "$"          // Separator Cost Explorer uses between tag key and value in group keys
+)
+
+// OffHoursConfig describes which non-production resources to model and the schedule they would follow.
+type OffHoursConfig struct {
+	EnvironmentTag    string   // Tag that identifies the environment, e.g. "env"
+	Environments      []string // Non-production values of EnvironmentTag, e.g. ["dev", "test"]
+	TeamTag           string   // Tag used to attribute savings, e.g. "team"
+	Services          []string // Services whose usage can be stopped out of hours
+	BusinessStartHour int      // First hour of the working day, 0-23
+	BusinessEndHour   int      // Hour the working day ends, 1-24
+	BusinessDays      int      // Working days per week, 1-7
+}
+
+// OffHoursConfigFromViper reads the offhours.* configuration keys.
+func OffHoursConfigFromViper() OffHoursConfig {
+	return OffHoursConfig{
+		EnvironmentTag:    viper.GetString("offhours.environment_tag"),
+		Environments:      configStringSlice("offhours.environments"),
+		TeamTag:           viper.GetString("offhours.team_tag"),
+		Services:          configStringSlice("offhours.services"),
+		BusinessStartHour: viper.GetInt("offhours.business_start_hour"),
+		BusinessEndHour:   viper.GetInt("offhours.business_end_hour"),
+		BusinessDays:      viper.GetInt("offhours.business_days"),
+	}
+}
+
+// Validate checks that the schedule and tags describe something that can be modelled.
+func (c OffHoursConfig) Validate() error {
+	if c.EnvironmentTag == "" || len(c.Environments) == 0 {
+		return fmt.Errorf("offhours.environment_tag and offhours.environments must be set")
+	}
+	if c.TeamTag == "" {
+		return fmt.Errorf("offhours.team_tag must be set")
+	}
+	if len(c.Services) == 0 {
+		return fmt.Errorf("offhours.services must list at least one service")
+	}
+	if c.BusinessStartHour < 0 || c.BusinessEndHour > 24 || c.BusinessStartHour >= c.BusinessEndHour {
+		return fmt.Errorf("business hours %d-%d are invalid: start must be before end, within 0-24", c.BusinessStartHour, c.BusinessEndHour)
+	}
+	if c.BusinessDays < 1 || c.BusinessDays > 7 {
+		return fmt.Errorf("business days must be between 1 and 7, got %d", c.BusinessDays)
+	}
+	return nil
+}
+
+// OffHoursFraction returns the share of the week that falls outside business hours.
+func (c OffHoursConfig) OffHoursFraction() float64 {
+	businessHours := (c.BusinessEndHour - c.BusinessStartHour) * c.BusinessDays
+	return 1 - float64(businessHours)/HoursPerWeek
+}
+
+// TeamOffHoursEstimate is the modelled saving for a single team.
+type TeamOffHoursEstimate struct {
+	Team           string
+	Cost           float64 // Non-production cost over the query window
+	Savings        float64 // Portion of Cost incurred outside business hours
+	MonthlySavings float64 // Savings projected to a 30-day month
+}
+
+// OffHoursReport is the result of EstimateOffHoursSavings.
+type OffHoursReport struct {
+	Config           OffHoursConfig
+	Days             float64
+	Unit             string
+	OffHoursFraction float64
+	Teams            []TeamOffHoursEstimate
+}
+
+// tagValue strips the "key$" prefix Cost Explorer puts on tag group keys.
+func tagValue(groupKey string) string {
+	if _, value, ok := strings.Cut(groupKey, tagValueSep); ok {
+		groupKey = value
+	}
+	if groupKey == "" {
+		return UntaggedLabel
+	}
+	return groupKey
+}
+
+// EstimateOffHoursSavings estimates what stopping non-production resources outside business hours would save.
+// Cost Explorer cost over the window is treated as 24/7 runtime, so resources that are already
+// scheduled will show a higher estimate than they would actually yield.
+func (ct *CostTracker) EstimateOffHoursSavings(ctx context.Context, q CostQuery, cfg OffHoursConfig) (*OffHoursReport, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid off-hours configuration: %w", err)
+	}
+
+	q.Filter = andExpression(q.Filter, &types.Expression{
+		And: []types.Expression{
+			{Dimensions: &types.DimensionValues{Key: types.DimensionService, Values: cfg.Services}},
+			{Tags: &types.TagValues{Key: aws.String(cfg.EnvironmentTag), Values: cfg.Environments}},
+		},
+	})
+	q.GroupBy = []types.GroupDefinition{
+		{Type: types.GroupDefinitionTypeTag, Key: aws.String(cfg.TeamTag)},
+	}
+	costs, err := ct.GetCosts(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &OffHoursReport{
+		Config:           cfg,
+		Days:             q.End.Sub(q.Start).Hours() / 24,
+		OffHoursFraction: cfg.OffHoursFraction(),
+	}
+	totals, unit := totalsByService(costs)
+	report.Unit = unit
+	for groupKey, cost := range totals {
+		savings := cost * report.OffHoursFraction
+		report.Teams = append(report.Teams, TeamOffHoursEstimate{
+			Team:           tagValue(groupKey),
+			Cost:           cost,
+			Savings:        savings,
+			MonthlySavings: savings / report.Days * DaysPerMonth,
+		})
+	}
+	sort.Slice(report.Teams, func(i, j int) bool {
+		return report.Teams[i].Savings > report.Teams[j].Savings
+	})
+	return report, nil
+}
+
+// displayOffHoursReport writes the off-hours savings estimate to w, largest saving first.
+func displayOffHoursReport(w io.Writer, report *OffHoursReport) {
+	cfg := report.Config
+	fmt.Fprintf(w, "Estimated off-hours savings for %s=%s over the last %.0f days:\n",
+		cfg.EnvironmentTag, strings.Join(cfg.Environments, "|"), report.Days)
+	fmt.Fprintf(w, "Schedule: %02d:00-%02d:00, %d days/week (%.1f%% of the week is off-hours)\n",
+		cfg.BusinessStartHour, cfg.BusinessEndHour, cfg.BusinessDays, report.OffHoursFraction*100)
+	fmt.Fprintln(w, "=====================================")
+	if len(report.Teams) == 0 {
+		fmt.Fprintln(w, "No non-production costs found for the specified period.")
+		return
+	}
+
+	fmt.Fprintf(w, "%-30s %14s %14s %16s\n", "Team", "Cost", "Savings", "Monthly savings")
+	var cost, savings, monthly float64
+	for _, team := range report.Teams {
+		fmt.Fprintf(w, "%-30s %14.2f %14.2f %16.2f\n", team.Team, team.Cost, team.Savings, team.MonthlySavings)
+		cost += team.Cost
+		savings += team.Savings
+		monthly += team.MonthlySavings
+	}
+	fmt.Fprintf(w, "%-30s %14.2f %14.2f %16.2f\n", "Total", cost, savings, monthly)
+	fmt.Fprintf(w, "Amounts in %s. Resources already stopped out of hours will save less than estimated.\n", report.Unit)
+}
+
+var offHoursCmd = &cobra.Command{
+	Use:   "offhours",
+	Short: "Estimate savings from stopping dev/test resources outside business hours.",
+	Long: `Models an off-hours schedule for non-production resources (selected by an environment tag) and
+estimates the savings per team from stopping them outside business hours.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		tracker, query, _ := setupReport(ctx)
+		report, err := tracker.EstimateOffHoursSavings(ctx, query, OffHoursConfigFromViper())
+		if err != nil {
+			errMsg := fmt.Sprintf("Error estimating off-hours savings: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error estimating off-hours savings", "error", err)
+		}
+
+		logger.Info("Displaying off-hours savings estimate to console.")
+		out, done := consoleWriter()
+		displayOffHoursReport(out, report)
+		done()
+	},
+}
+
+func init() {
+	viper.SetDefault("offhours.environment_tag", "env")
+	viper.SetDefault("offhours.environments", []string{"dev", "test"})
+	viper.SetDefault("offhours.team_tag", "team")
+	viper.SetDefault("offhours.services", []string{"Amazon Elastic Compute Cloud - Compute"})
+	viper.SetDefault("offhours.business_start_hour", 8)
+	viper.SetDefault("offhours.business_end_hour", 18)
+	viper.SetDefault("offhours.business_days", 5)
+
+	rootCmd.AddCommand(offHoursCmd)
+}
@@ -0,0 +1,101 @@
+// File: offhours_test.go
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestEstimateOffHoursSavings(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	mock := newTestMockProvider()
+	mock.Services = map[string]float64{"Amazon Elastic Compute Cloud - Compute": 24, "Amazon S3": 5}
+	mock.Tags = []MockTag{
+		{Key: "env", Values: []string{"dev"}},
+		{Key: "team", Values: []string{"platform"}},
+	}
+	tracker := &CostTracker{client: mock}
+
+	cfg := OffHoursConfig{
+		EnvironmentTag:    "env",
+		Environments:      []string{"dev", "test"},
+		TeamTag:           "team",
+		Services:          []string{"Amazon Elastic Compute Cloud - Compute"},
+		BusinessStartHour: 8,
+		BusinessEndHour:   18,
+		BusinessDays:      5,
+	}
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC),
+	}
+
+	report, err := tracker.EstimateOffHoursSavings(context.Background(), q, cfg)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(report.Teams) != 1 || report.Teams[0].Team != "platform" {
+		t.Fatalf("expected a single 'platform' team, got %+v", report.Teams)
+	}
+
+	expectedFraction := 1 - 50.0/168
+	if math.Abs(report.OffHoursFraction-expectedFraction) > 1e-9 {
+		t.Errorf("expected off-hours fraction %.4f, got %.4f", expectedFraction, report.OffHoursFraction)
+	}
+	team := report.Teams[0]
+	if math.Abs(team.Cost-240) > 1e-6 {
+		t.Errorf("expected cost 240.00 (S3 excluded), got %.2f", team.Cost)
+	}
+	if math.Abs(team.Savings-240*expectedFraction) > 1e-6 {
+		t.Errorf("expected savings %.2f, got %.2f", 240*expectedFraction, team.Savings)
+	}
+	if math.Abs(team.MonthlySavings-team.Savings*3) > 1e-6 {
+		t.Errorf("expected monthly savings to be 3x the 10-day savings, got %.2f", team.MonthlySavings)
+	}
+}
+
+func TestOffHoursConfigValidate(t *testing.T) {
+	valid := OffHoursConfig{
+		EnvironmentTag: "env", Environments: []string{"dev"}, TeamTag: "team",
+		Services: []string{"Amazon EC2"}, BusinessStartHour: 8, BusinessEndHour: 18, BusinessDays: 5,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid configuration, got: %v", err)
+	}
+
+	invalid := map[string]func(c *OffHoursConfig){
+		"no environments":    func(c *OffHoursConfig) { c.Environments = nil },
+		"no services":        func(c *OffHoursConfig) { c.Services = nil },
+		"inverted hours":     func(c *OffHoursConfig) { c.BusinessStartHour, c.BusinessEndHour = 18, 8 },
+		"too many days":      func(c *OffHoursConfig) { c.BusinessDays = 8 },
+		"missing team tag":   func(c *OffHoursConfig) { c.TeamTag = "" },
+		"hours beyond a day": func(c *OffHoursConfig) { c.BusinessEndHour = 25 },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			c := valid
+			mutate(&c)
+			if err := c.Validate(); err == nil {
+				t.Errorf("expected an error, but got nil")
+			}
+		})
+	}
+}
+
+func TestTagValue(t *testing.T) {
+	testCases := map[string]string{
+		"team$platform": "platform",
+		"team$":         UntaggedLabel,
+		"plain":         "plain",
+	}
+	for input, expected := range testCases {
+		if got := tagValue(input); got != expected {
+			t.Errorf("tagValue(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
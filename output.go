@@ -0,0 +1,200 @@
+// File: output.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	ServiceNameWidth = 30  // Width of the service name column in console tables
+	truncationMarker = "…" // Appended to names cut to fit their column
+)
+
+// Formats of the get command's --output flag.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+// CostsSchemaVersion is the schema_version of the get command's JSON output. It is increased when a
+// field is renamed or removed; fields may be added without a new version.
+const CostsSchemaVersion = 2
+
+// CostRenderer writes the costs of the last days days to w in one --output format.
+type CostRenderer func(w io.Writer, costs []CostByTime, days int) error
+
+// costRenderers holds the renderer of each --output format.
+var costRenderers = map[string]CostRenderer{
+	OutputTable: func(w io.Writer, costs []CostByTime, days int) error {
+		displayCosts(w, costs, days)
+		return nil
+	},
+	OutputJSON: renderCostsJSON,
+}
+
+// costRendererFor returns the renderer of an --output format.
+func costRendererFor(format string) (CostRenderer, error) {
+	render, ok := costRenderers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q, expected %s or %s", format, OutputTable, OutputJSON)
+	}
+	return render, nil
+}
+
+// CostsDocument is the get command's JSON output.
+type CostsDocument struct {
+	SchemaVersion int          `json:"schema_version"`
+	Days          int          `json:"days"`
+	Costs         []CostByTime `json:"costs"`
+}
+
+// renderCostsJSON writes costs as an indented CostsDocument.
+func renderCostsJSON(w io.Writer, costs []CostByTime, days int) error {
+	if costs == nil {
+		costs = []CostByTime{} // An empty list rather than null, for jq
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(CostsDocument{SchemaVersion: CostsSchemaVersion, Days: days, Costs: costs})
+}
+
+// outputWriter returns where a report in format is written. Tables go to consoleWriter; other formats
+// are for scripts, so they go to stdout as is, without a pager or --plain rewriting.
+func outputWriter(format string) (io.Writer, func()) {
+	if format == OutputTable {
+		return consoleWriter()
+	}
+	if explaining() {
+		return io.Discard, func() {}
+	}
+	return os.Stdout, func() {}
+}
+
+// plainReplacer spells out the symbols used in console output for --plain.
+var plainReplacer = strings.NewReplacer("→", "to", "×", "by", truncationMarker, "...")
+
+// truncateName shortens name to width characters unless --no-trunc or --plain is set.
+func truncateName(name string, width int) string {
+	if viper.GetBool("no_trunc") || viper.GetBool("plain") || utf8.RuneCountInString(name) <= width {
+		return name
+	}
+	return string([]rune(name)[:width-1]) + truncationMarker
+}
+
+// rowLimit returns how many of n rows to print under --max-rows and how many are left out.
+func rowLimit(n int) (shown, hidden int) {
+	maxRows := viper.GetInt("max_rows")
+	if maxRows <= 0 || n <= maxRows {
+		return n, 0
+	}
+	return maxRows, n - maxRows
+}
+
+// writeHiddenRows notes rows left out by --max-rows.
+func writeHiddenRows(w io.Writer, hidden int) {
+	switch {
+	case hidden == 1:
+		fmt.Fprintln(w, "  ... 1 more row (use --max-rows 0 to show all)")
+	case hidden > 1:
+		fmt.Fprintf(w, "  ... %d more rows (use --max-rows 0 to show all)\n", hidden)
+	}
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// plainWriter rewrites console output line by line for screen readers: separator lines are dropped
+// and symbols are spelled out.
+type plainWriter struct {
+	w    io.Writer
+	line []byte
+}
+
+func (p *plainWriter) Write(b []byte) (int, error) {
+	p.line = append(p.line, b...)
+	for {
+		i := bytes.IndexByte(p.line, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		if err := p.writeLine(string(p.line[:i+1])); err != nil {
+			return 0, err
+		}
+		p.line = p.line[i+1:]
+	}
+}
+
+// writeLine writes one line, including its newline, unless it is a separator.
+func (p *plainWriter) writeLine(line string) error {
+	if content := strings.TrimSpace(line); content != "" && strings.Trim(content, "=") == "" {
+		return nil
+	}
+	_, err := io.WriteString(p.w, plainReplacer.Replace(line))
+	return err
+}
+
+// Flush writes any final line that has no newline.
+func (p *plainWriter) Flush() {
+	if len(p.line) > 0 {
+		p.writeLine(string(p.line))
+		p.line = nil
+	}
+}
+
+// consoleWriter returns where report commands write their output: a $PAGER process when stdout is a
+// terminal and --no-pager is not set, otherwise stdout. With --plain the output is rewritten by a
+// plainWriter. The returned function flushes the output, closes the pager's input and waits for it to
+// exit; it must be called once output is complete. Under --explain the report is discarded, since
+// it was built from empty results.
+func consoleWriter() (io.Writer, func()) {
+	if explaining() {
+		return io.Discard, func() {}
+	}
+	w, done := pagerWriter()
+	if !viper.GetBool("plain") {
+		return w, done
+	}
+	plain := &plainWriter{w: w}
+	return plain, func() {
+		plain.Flush()
+		done()
+	}
+}
+
+// pagerWriter returns the $PAGER process or stdout, as described for consoleWriter.
+func pagerWriter() (io.Writer, func()) {
+	pager := os.Getenv("PAGER")
+	if pager == "" || viper.GetBool("no_pager") || !isTerminal(os.Stdout) {
+		return os.Stdout, func() {}
+	}
+
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		logger.Warnw("Failed to start pager, writing to stdout", "pager", pager, "error", err)
+		return os.Stdout, func() {}
+	}
+	return stdin, func() {
+		stdin.Close()
+		if err := cmd.Wait(); err != nil {
+			logger.Warnw("Pager exited with an error", "pager", pager, "error", err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// File: payer.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const MaxConcurrentQueries = 5 // Upper bound on payers queried at once by GetCostsPerPayer
+
+// Payer is a management (payer) account whose organization's costs are queried by assuming RoleARN,
+// typically a read-only role in that account.
+type Payer struct {
+	Name    string `mapstructure:"name"`
+	RoleARN string `mapstructure:"role_arn"`
+}
+
+// PayersFromViper reads the payers configuration key. It is empty for a single-payer setup, which
+// queries aws.role_arn or the default credentials.
+func PayersFromViper() ([]Payer, error) {
+	var payers []Payer
+	if err := unmarshalConfigKey("payers", &payers); err != nil {
+		return nil, fmt.Errorf("invalid payers: %w", err)
+	}
+	seen := make(map[string]bool)
+	for i, payer := range payers {
+		if payer.Name == "" {
+			return nil, fmt.Errorf("payers[%d] has no name", i)
+		}
+		if seen[payer.Name] {
+			return nil, fmt.Errorf("payer %q is configured more than once", payer.Name)
+		}
+		seen[payer.Name] = true
+		if payer.RoleARN == "" {
+			return nil, fmt.Errorf("payer %q has no role_arn", payer.Name)
+		}
+	}
+	return payers, nil
+}
+
+// selectPayers returns the payers named in names, in configuration order, or every payer if names is empty.
+func selectPayers(payers []Payer, names []string) ([]Payer, error) {
+	if len(names) == 0 {
+		return payers, nil
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var selected []Payer
+	for _, payer := range payers {
+		if wanted[payer.Name] {
+			selected = append(selected, payer)
+			delete(wanted, payer.Name)
+		}
+	}
+	if len(wanted) > 0 {
+		var unknown []string
+		for name := range wanted {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown payer(s) %s", strings.Join(unknown, ", "))
+	}
+	return selected, nil
+}
+
+// newPayerTracker creates a cost tracker for the payer's organization. The session is attributed like
+// aws.role_arn's. With the mock provider each payer gets its own seed, so their data differs.
+func newPayerTracker(ctx context.Context, provider string, payer Payer) (*CostTracker, error) {
+	var tracker *CostTracker
+	switch provider {
+	case ProviderMock:
+		mock, err := NewMockProviderFromConfig()
+		if err != nil {
+			return nil, err
+		}
+		h := fnv.New64a()
+		h.Write([]byte(payer.Name))
+		mock.Seed ^= int64(h.Sum64())
+		tracker = &CostTracker{client: mock}
+	case ProviderAWS, "":
+		role, err := AssumeRoleConfigFromViper()
+		if err != nil {
+			return nil, err
+		}
+		role.RoleARN = payer.RoleARN
+		if tracker, err = newCostTrackerAssuming(ctx, role); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown provider %q (supported: %s, %s)", provider, ProviderAWS, ProviderMock)
+	}
+	instrument(tracker)
+	return tracker, nil
+}
+
+// PayerCostMatrix holds the cost of each service under each payer, summed over the whole query range.
+type PayerCostMatrix struct {
+	Payers     []string
+	Services   []string
+	Unit       string
+	Amounts    map[string]map[string]float64 // service -> payer -> amount, in Unit
+	Currencies map[string]float64            // Original currency -> total before conversion
+	Conversion CurrencyConversion
+	Failed     []PayerFailure // Payers left out of the matrix
+}
+
+// PayerFailure is a payer whose costs could not be fetched.
+type PayerFailure struct {
+	Payer string
+	Err   error
+}
+
+// GetCostsPerPayer queries each payer's tracker concurrently and assembles the results into a
+// payer×service matrix. trackers[i] queries payers[i]. A payer whose query fails is left out of the
+// matrix and listed in its Failed payers, so one missing permission does not lose the others; the
+// call only fails if every payer fails. Payers reporting in different currencies are converted to
+// conversion's target, and the call fails if there is no target or no rate for a currency.
+func GetCostsPerPayer(ctx context.Context, q CostQuery, payers []Payer, trackers []*CostTracker, conversion CurrencyConversion) (*PayerCostMatrix, error) {
+	results := make([][]CostByTime, len(payers))
+	errs := make([]error, len(payers))
+	sem := make(chan struct{}, MaxConcurrentQueries)
+	var wg sync.WaitGroup
+	for i := range payers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i], errs[i] = trackers[i].GetCosts(ctx, q)
+		}(i)
+	}
+	wg.Wait()
+
+	matrix := &PayerCostMatrix{Amounts: make(map[string]map[string]float64), Currencies: make(map[string]float64), Conversion: conversion}
+	matrix.Unit = conversion.Target
+	for i, payer := range payers {
+		if errs[i] != nil {
+			logger.Warnw("Failed to get costs for payer, continuing with the others", "payer", payer.Name, "error", errs[i])
+			matrix.Failed = append(matrix.Failed, PayerFailure{Payer: payer.Name, Err: errs[i]})
+			continue
+		}
+		matrix.Payers = append(matrix.Payers, payer.Name)
+		for _, period := range results[i] {
+			for _, serviceCost := range period.Groups {
+				amount, err := strconv.ParseFloat(serviceCost.Amount, 64)
+				if err != nil {
+					logger.Warnw("Skipping unparseable cost amount",
+						"service", serviceCost.Key,
+						"payer", payer.Name,
+						"amount", serviceCost.Amount)
+					continue
+				}
+				if conversion.Target == "" && matrix.Unit != "" && serviceCost.Unit != matrix.Unit {
+					return nil, fmt.Errorf("payer %s reports costs in %s, but other payers in %s; set currency.target and currency.rates to convert them",
+						payer.Name, serviceCost.Unit, matrix.Unit)
+				}
+				converted, err := conversion.Convert(amount, serviceCost.Unit)
+				if err != nil {
+					return nil, fmt.Errorf("payer %s reports costs in %s: %w", payer.Name, serviceCost.Unit, err)
+				}
+				if _, ok := matrix.Amounts[serviceCost.Key]; !ok {
+					matrix.Amounts[serviceCost.Key] = make(map[string]float64)
+					matrix.Services = append(matrix.Services, serviceCost.Key)
+				}
+				matrix.Amounts[serviceCost.Key][payer.Name] += converted
+				matrix.Currencies[serviceCost.Unit] += amount
+				if conversion.Target == "" {
+					matrix.Unit = serviceCost.Unit
+				}
+			}
+		}
+	}
+	if len(payers) > 0 && len(matrix.Failed) == len(payers) {
+		return nil, fmt.Errorf("failed to get costs for every payer, e.g. %s: %w", matrix.Failed[0].Payer, matrix.Failed[0].Err)
+	}
+	sort.Strings(matrix.Services)
+	return matrix, nil
+}
+
+// failedPayerNames returns the names of the payers in failures.
+func failedPayerNames(failures []PayerFailure) []string {
+	names := make([]string, len(failures))
+	for i, f := range failures {
+		names[i] = f.Payer
+	}
+	return names
+}
+
+// displayPayerMatrix writes the payer×service matrix to w, one row per service.
+func displayPayerMatrix(w io.Writer, matrix *PayerCostMatrix, days int) {
+	fmt.Fprintf(w, "AWS Costs per payer for the last %d days (%s):\n", days, matrix.Unit)
+	fmt.Fprintln(w, "=====================================")
+	if len(matrix.Services) == 0 {
+		fmt.Fprintln(w, "No cost data found for the specified period.")
+	} else {
+		writeCostMatrix(w, matrix.Payers, matrix.Services, matrix.Amounts)
+		if _, only := matrix.Currencies[matrix.Unit]; !only || len(matrix.Currencies) > 1 {
+			fmt.Fprintln(w, "Totals by original currency:")
+			for _, line := range currencyBreakdown(matrix.Currencies, matrix.Unit, matrix.Conversion) {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+	if len(matrix.Failed) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nFailed payers (%d of %d, not included above):\n", len(matrix.Failed), len(matrix.Failed)+len(matrix.Payers))
+	for _, f := range matrix.Failed {
+		fmt.Fprintf(w, "  %s: %v\n", f.Payer, f.Err)
+	}
+}
@@ -0,0 +1,158 @@
+// File: payer_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+// payerTracker returns a tracker whose single period reports the given service amounts in unit.
+func payerTracker(unit string, amounts map[string]string) *CostTracker {
+	return &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			var groups []types.Group
+			for service, amount := range amounts {
+				groups = append(groups, types.Group{
+					Keys:    []string{service},
+					Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String(amount), Unit: aws.String(unit)}},
+				})
+			}
+			return &costexplorer.GetCostAndUsageOutput{
+				ResultsByTime: []types.ResultByTime{{
+					TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-01-31")},
+					Groups:     groups,
+				}},
+			}, nil
+		},
+	}}
+}
+
+func TestGetCostsPerPayer(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	payers := []Payer{{Name: "main"}, {Name: "acquired"}}
+
+	matrix, err := GetCostsPerPayer(context.Background(), q, payers, []*CostTracker{
+		payerTracker("USD", map[string]string{"Amazon EC2": "10", "Amazon S3": "2"}),
+		payerTracker("USD", map[string]string{"Amazon EC2": "5"}),
+	}, CurrencyConversion{})
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if strings.Join(matrix.Payers, ",") != "main,acquired" || strings.Join(matrix.Services, ",") != "Amazon EC2,Amazon S3" {
+		t.Errorf("unexpected payers %v or services %v", matrix.Payers, matrix.Services)
+	}
+	if matrix.Amounts["Amazon EC2"]["acquired"] != 5 || matrix.Amounts["Amazon S3"]["main"] != 2 {
+		t.Errorf("unexpected amounts: %v", matrix.Amounts)
+	}
+
+	_, err = GetCostsPerPayer(context.Background(), q, payers, []*CostTracker{
+		payerTracker("USD", map[string]string{"Amazon EC2": "10"}),
+		payerTracker("EUR", map[string]string{"Amazon EC2": "5"}),
+	}, CurrencyConversion{})
+	if err == nil || !strings.Contains(err.Error(), "EUR") {
+		t.Errorf("expected an error for payers in different currencies, got %v", err)
+	}
+}
+
+func TestGetCostsPerPayerConvertsCurrencies(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	payers := []Payer{{Name: "main"}, {Name: "emea"}}
+	trackers := []*CostTracker{
+		payerTracker("USD", map[string]string{"Amazon EC2": "10"}),
+		payerTracker("EUR", map[string]string{"Amazon EC2": "5", "Amazon S3": "1"}),
+	}
+
+	conversion := CurrencyConversion{Target: "USD", Rates: map[string]float64{"EUR": 1.1}}
+	matrix, err := GetCostsPerPayer(context.Background(), q, payers, trackers, conversion)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if matrix.Unit != "USD" || math.Abs(matrix.Amounts["Amazon EC2"]["emea"]-5.5) > 1e-9 || matrix.Amounts["Amazon EC2"]["main"] != 10 {
+		t.Errorf("expected EUR amounts converted to USD, got %s %v", matrix.Unit, matrix.Amounts)
+	}
+	if matrix.Currencies["EUR"] != 6 || matrix.Currencies["USD"] != 10 {
+		t.Errorf("expected totals in the original currencies, got %v", matrix.Currencies)
+	}
+	var buf bytes.Buffer
+	displayPayerMatrix(&buf, matrix, 30)
+	if !strings.Contains(buf.String(), "EUR 6.00 (6.60 USD at 1.1)") {
+		t.Errorf("expected a breakdown by original currency, got:\n%s", buf.String())
+	}
+
+	_, err = GetCostsPerPayer(context.Background(), q, payers, trackers, CurrencyConversion{Target: "GBP", Rates: map[string]float64{"EUR": 0.85}})
+	if err == nil || !strings.Contains(err.Error(), "convert USD to GBP") {
+		t.Errorf("expected an error for a currency without a rate, got %v", err)
+	}
+}
+
+func TestSelectPayers(t *testing.T) {
+	payers := []Payer{{Name: "main"}, {Name: "acquired"}, {Name: "emea"}}
+
+	selected, err := selectPayers(payers, []string{"emea", "main"})
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(selected) != 2 || selected[0].Name != "main" || selected[1].Name != "emea" {
+		t.Errorf("expected main and emea in configuration order, got %+v", selected)
+	}
+	if _, err := selectPayers(payers, []string{"apac"}); err == nil {
+		t.Error("expected an error for an unknown payer")
+	}
+}
+
+func TestGetCostsPerPayerContinuesPastFailures(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	denied := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			return nil, errors.New("AccessDeniedException: not authorized to perform ce:GetCostAndUsage")
+		},
+	}}
+	payers := []Payer{{Name: "main"}, {Name: "acquired"}, {Name: "emea"}}
+
+	matrix, err := GetCostsPerPayer(context.Background(), q, payers, []*CostTracker{
+		payerTracker("USD", map[string]string{"Amazon EC2": "10"}),
+		denied,
+		payerTracker("USD", map[string]string{"Amazon EC2": "5"}),
+	}, CurrencyConversion{})
+	if err != nil {
+		t.Fatalf("did not expect an error when only some payers fail, but got: %v", err)
+	}
+	if strings.Join(matrix.Payers, ",") != "main,emea" || matrix.Amounts["Amazon EC2"]["emea"] != 5 {
+		t.Errorf("expected the payers that succeeded, got %v %v", matrix.Payers, matrix.Amounts)
+	}
+	if len(matrix.Failed) != 1 || matrix.Failed[0].Payer != "acquired" {
+		t.Fatalf("expected acquired to be listed as failed, got %+v", matrix.Failed)
+	}
+	var buf bytes.Buffer
+	displayPayerMatrix(&buf, matrix, 30)
+	if !strings.Contains(buf.String(), "Failed payers (1 of 3") || !strings.Contains(buf.String(), "acquired: failed to get cost data") {
+		t.Errorf("expected a failed payers section, got:\n%s", buf.String())
+	}
+
+	_, err = GetCostsPerPayer(context.Background(), q, payers[:2], []*CostTracker{denied, denied}, CurrencyConversion{})
+	if err == nil || !strings.Contains(err.Error(), "every payer") {
+		t.Errorf("expected an error when every payer fails, got %v", err)
+	}
+}
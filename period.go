@@ -0,0 +1,234 @@
+// File: period.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	PeriodLastWeek  = "last-week"  // The previous full ISO week, Monday to Monday
+	PeriodThisMonth = "this-month" // The calendar month to date
+	PeriodLastMonth = "last-month" // The previous calendar month
+	PeriodMTD       = "mtd"        // Same as this-month
+	PeriodQTD       = "qtd"        // The calendar quarter to date
+	PeriodYTD       = "ytd"        // The calendar year to date
+
+	// GranularityWeekly is not a Cost Explorer granularity. It is emulated by querying daily data and
+	// summing it into ISO weeks, which start on Monday.
+	GranularityWeekly types.Granularity = "WEEKLY"
+
+	// MaxHourlyDays is how far back Cost Explorer keeps hourly data.
+	MaxHourlyDays = 14
+)
+
+// isoWeekStart returns midnight UTC on the Monday of the ISO week containing t.
+func isoWeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+	return day.AddDate(0, 0, -offset)
+}
+
+// calendarPeriods maps the calendar --period names to a fiscal period of the calendar year.
+var calendarPeriods = map[string]struct{ when, unit string }{
+	PeriodThisMonth: {"this", FiscalMonth},
+	PeriodMTD:       {"this", FiscalMonth},
+	PeriodLastMonth: {"last", FiscalMonth},
+	PeriodQTD:       {"this", FiscalQuarter},
+	PeriodYTD:       {"this", FiscalYear},
+}
+
+// lastNDaysPeriod matches last-<N>d, the N complete days before today.
+var lastNDaysPeriod = regexp.MustCompile(`^last-([1-9][0-9]*)d$`)
+
+// periodRange resolves a named --period to its start (inclusive) and end (exclusive) relative to now.
+// Periods to date end at the start of today, in UTC. Fiscal periods, e.g. this-fiscal-quarter, follow cal.
+func periodRange(period string, now time.Time, cal FiscalCalendar) (time.Time, time.Time, error) {
+	period = strings.ToLower(period)
+	if period == PeriodLastWeek {
+		end := isoWeekStart(now)
+		return end.AddDate(0, 0, -7), end, nil
+	}
+	if p, ok := calendarPeriods[period]; ok {
+		return fiscalPeriodRange(p.when, p.unit, now, FiscalCalendar{StartMonth: time.January})
+	}
+	if m := lastNDaysPeriod.FindStringSubmatch(period); m != nil {
+		days, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q: %w", period, err)
+		}
+		end := now.UTC().Truncate(24 * time.Hour)
+		return end.AddDate(0, 0, -days), end, nil
+	}
+	if when, unit, ok := strings.Cut(period, "-fiscal-"); ok && (when == "this" || when == "last") {
+		return fiscalPeriodRange(when, unit, now, cal)
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unknown period %q (expected %s, %s, %s, %s, %s, %s, last-<N>d, or this-/last-fiscal-month, -quarter or -year)",
+		period, PeriodLastWeek, PeriodThisMonth, PeriodLastMonth, PeriodMTD, PeriodQTD, PeriodYTD)
+}
+
+// explicitRange resolves --start and --end, dates in UTC with end exclusive like Cost Explorer's. With
+// only start the range runs to now; with only end it covers the days before end.
+func explicitRange(start, end string, days int, now time.Time) (time.Time, time.Time, error) {
+	to := now
+	if end != "" {
+		var err error
+		if to, err = time.Parse(AWSDateFormat, end); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end date %q, expected YYYY-MM-DD", end)
+		}
+	}
+	from := to.AddDate(0, 0, -days)
+	if start != "" {
+		var err error
+		if from, err = time.Parse(AWSDateFormat, start); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start date %q, expected YYYY-MM-DD", start)
+		}
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start %s must be before end %s", from.Format(AWSDateFormat), to.Format(AWSDateFormat))
+	}
+	return from, to, nil
+}
+
+// parseGranularity parses the --granularity flag. An empty value is monthly.
+func parseGranularity(granularity string) (types.Granularity, error) {
+	switch strings.ToLower(granularity) {
+	case "", "monthly":
+		return types.GranularityMonthly, nil
+	case "daily":
+		return types.GranularityDaily, nil
+	case "weekly":
+		return GranularityWeekly, nil
+	case "hourly":
+		return types.GranularityHourly, nil
+	}
+	return "", fmt.Errorf("unknown granularity %q (expected monthly, daily, weekly or hourly)", granularity)
+}
+
+// checkHourlyRange truncates an hourly query's range to whole UTC hours and checks that it lies within
+// the last MaxHourlyDays days, the only hourly data Cost Explorer keeps.
+func checkHourlyRange(q *CostQuery, now time.Time) error {
+	q.Start, q.End = q.Start.UTC().Truncate(time.Hour), q.End.UTC().Truncate(time.Hour)
+	if earliest := now.UTC().Truncate(time.Hour).AddDate(0, 0, -MaxHourlyDays); q.Start.Before(earliest) {
+		return fmt.Errorf("hourly granularity only covers the last %d days, but the range starts %s; use --days %d or fewer, and enable hourly granularity in the Cost Explorer settings",
+			MaxHourlyDays, q.Start.Format(AWSHourFormat), MaxHourlyDays)
+	}
+	return nil
+}
+
+// fallBackToDaily turns an hourly query into a daily one covering the same hours, in whole UTC days.
+func fallBackToDaily(q *CostQuery) {
+	end := q.End.UTC().Truncate(24 * time.Hour)
+	if end.Before(q.End) {
+		end = end.AddDate(0, 0, 1)
+	}
+	q.Start, q.End = q.Start.UTC().Truncate(24*time.Hour), end
+	q.Granularity = types.GranularityDaily
+}
+
+// hourlyRejected reports whether err is Cost Explorer refusing an hourly query: hourly data is not
+// enabled or kept for the range, or the many pages of hourly results exceeded the request limit. A
+// ValidationException only counts when its message is about hourly data; one about a filter, grouping
+// or metric would fail at daily granularity too.
+func hourlyRejected(err error) bool {
+	var unavailable *types.DataUnavailableException
+	var limited *types.LimitExceededException
+	var apiErr smithy.APIError
+	return errors.As(err, &unavailable) || errors.As(err, &limited) ||
+		errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationException" && // Not modelled by the SDK
+			strings.Contains(strings.ToLower(apiErr.ErrorMessage()), "hourly")
+}
+
+// aggregateWeeks sums daily periods into ISO weeks. Each week's range is clipped to the days present,
+// so a partial first or last week starts or ends mid-week. Services keep their first-seen order.
+func aggregateWeeks(daily []CostByTime) ([]CostByTime, error) {
+	var weeks []CostByTime
+	var totals []map[string]float64
+	var order [][]string
+	var units []map[string]string
+	var metrics []map[string]map[string]float64 // Per week, group and metric, for queries with several metrics
+	metricUnits := make(map[string]map[string]string)
+	groupKeys := make(map[string][]string) // Keys of groups with several group definitions
+	var current time.Time
+	for _, day := range daily {
+		start, err := time.Parse(AWSDateFormat, day.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid period start %q: %w", day.Start, err)
+		}
+		if week := isoWeekStart(start); len(weeks) == 0 || !week.Equal(current) {
+			current = week
+			weeks = append(weeks, CostByTime{Start: day.Start})
+			totals = append(totals, make(map[string]float64))
+			order = append(order, nil)
+			units = append(units, make(map[string]string))
+			metrics = append(metrics, make(map[string]map[string]float64))
+		}
+		i := len(weeks) - 1
+		weeks[i].End = day.End
+		for _, serviceCost := range day.Groups {
+			amount, err := strconv.ParseFloat(serviceCost.Amount, 64)
+			if err != nil {
+				logger.Warnw("Skipping unparseable cost amount",
+					"service", serviceCost.Key,
+					"periodStart", day.Start,
+					"amount", serviceCost.Amount)
+				continue
+			}
+			if _, ok := totals[i][serviceCost.Key]; !ok {
+				order[i] = append(order[i], serviceCost.Key)
+			}
+			totals[i][serviceCost.Key] += amount
+			units[i][serviceCost.Key] = serviceCost.Unit
+			if serviceCost.Keys != nil {
+				groupKeys[serviceCost.Key] = serviceCost.Keys
+			}
+			for name, m := range serviceCost.Metrics {
+				value, err := strconv.ParseFloat(m.Amount, 64)
+				if err != nil {
+					continue // Logged above if it is the first metric
+				}
+				if metrics[i][serviceCost.Key] == nil {
+					metrics[i][serviceCost.Key] = make(map[string]float64)
+				}
+				if metricUnits[serviceCost.Key] == nil {
+					metricUnits[serviceCost.Key] = make(map[string]string)
+				}
+				metrics[i][serviceCost.Key][name] += value
+				metricUnits[serviceCost.Key][name] = m.Unit
+			}
+		}
+	}
+
+	for i := range weeks {
+		for _, service := range order[i] {
+			// Round away float noise from summing so amounts print like Cost Explorer's own
+			amount := math.Round(totals[i][service]*1e8) / 1e8
+			cost := GroupedCost{
+				Key:    service,
+				Keys:   groupKeys[service],
+				Amount: strconv.FormatFloat(amount, 'f', -1, 64),
+				Unit:   units[i][service],
+			}
+			if sums := metrics[i][service]; sums != nil {
+				cost.Metrics = make(map[string]MetricAmount, len(sums))
+				for name, sum := range sums {
+					cost.Metrics[name] = MetricAmount{
+						Amount: strconv.FormatFloat(math.Round(sum*1e8)/1e8, 'f', -1, 64),
+						Unit:   metricUnits[service][name],
+					}
+				}
+			}
+			weeks[i].Groups = append(weeks[i].Groups, cost)
+		}
+	}
+	return weeks, nil
+}
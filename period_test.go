@@ -0,0 +1,287 @@
+// File: period_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/aws/smithy-go"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPeriodRangeLastWeek(t *testing.T) {
+	testCases := []struct {
+		name          string
+		now           time.Time
+		expectedStart string
+		expectedEnd   string
+	}{
+		{name: "midweek", now: time.Date(2024, 1, 17, 15, 0, 0, 0, time.UTC), expectedStart: "2024-01-08", expectedEnd: "2024-01-15"},
+		{name: "monday", now: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), expectedStart: "2024-01-08", expectedEnd: "2024-01-15"},
+		{name: "sunday belongs to the ending week", now: time.Date(2024, 1, 14, 23, 0, 0, 0, time.UTC), expectedStart: "2024-01-01", expectedEnd: "2024-01-08"},
+		{name: "across a year boundary", now: time.Date(2021, 1, 6, 0, 0, 0, 0, time.UTC), expectedStart: "2020-12-28", expectedEnd: "2021-01-04"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start, end, err := periodRange(PeriodLastWeek, tc.now, FiscalCalendar{StartMonth: time.January})
+			if err != nil {
+				t.Fatalf("did not expect an error, but got: %v", err)
+			}
+			if start.Format(AWSDateFormat) != tc.expectedStart || end.Format(AWSDateFormat) != tc.expectedEnd {
+				t.Errorf("expected %s - %s, got %s - %s", tc.expectedStart, tc.expectedEnd, start.Format(AWSDateFormat), end.Format(AWSDateFormat))
+			}
+			if start.Weekday() != time.Monday {
+				t.Errorf("expected the period to start on an ISO week Monday, got %s", start.Weekday())
+			}
+		})
+	}
+
+	if _, _, err := periodRange("last-fortnight", time.Now(), FiscalCalendar{StartMonth: time.January}); err == nil {
+		t.Errorf("expected an error for an unknown period, but got nil")
+	}
+}
+
+func TestParseGranularity(t *testing.T) {
+	testCases := map[string]types.Granularity{
+		"":        types.GranularityMonthly,
+		"monthly": types.GranularityMonthly,
+		"DAILY":   types.GranularityDaily,
+		"weekly":  GranularityWeekly,
+	}
+	for input, expected := range testCases {
+		got, err := parseGranularity(input)
+		if err != nil || got != expected {
+			t.Errorf("parseGranularity(%q) = %q, %v; expected %q", input, got, err, expected)
+		}
+	}
+	if _, err := parseGranularity("minutely"); err == nil {
+		t.Errorf("expected an error for an unknown granularity, but got nil")
+	}
+}
+
+func TestCheckHourlyRange(t *testing.T) {
+	now := time.Date(2024, 3, 20, 9, 45, 0, 0, time.UTC)
+	q := CostQuery{Start: now.AddDate(0, 0, -MaxHourlyDays), End: now, Granularity: types.GranularityHourly}
+	if err := checkHourlyRange(&q, now); err != nil {
+		t.Fatalf("did not expect an error for the last %d days, but got: %v", MaxHourlyDays, err)
+	}
+	if q.Start.Format(AWSHourFormat) != "2024-03-06T09:00:00Z" || q.End.Format(AWSHourFormat) != "2024-03-20T09:00:00Z" {
+		t.Errorf("expected the range truncated to whole hours, got %s to %s", q.Start, q.End)
+	}
+
+	q = CostQuery{Start: now.AddDate(0, 0, -30), End: now, Granularity: types.GranularityHourly}
+	if err := checkHourlyRange(&q, now); err == nil || !strings.Contains(err.Error(), "--days 14") {
+		t.Errorf("expected an error suggesting --days 14 for a 30-day range, got %v", err)
+	}
+}
+
+func TestHourlyFallback(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	t.Cleanup(func() {
+		viper.Set("granularity", "")
+		viper.Set("strict_granularity", false)
+	})
+
+	viper.Set("granularity", "hourly")
+	q, err := costQueryFromConfig(30)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if q.Granularity != types.GranularityDaily || !q.Start.Equal(q.Start.Truncate(24*time.Hour)) || !q.End.Equal(q.End.Truncate(24*time.Hour)) {
+		t.Errorf("expected 30 hourly days to fall back to whole days at daily granularity, got %s from %s to %s", q.Granularity, q.Start, q.End)
+	}
+	viper.Set("strict_granularity", true)
+	if _, err := costQueryFromConfig(30); err == nil {
+		t.Error("expected an error for 30 hourly days with --strict-granularity")
+	}
+
+	var granularities []types.Granularity
+	var hourlyErr error = &types.DataUnavailableException{Message: aws.String("Hourly data is not enabled.")}
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			granularities = append(granularities, params.Granularity)
+			if params.Granularity == types.GranularityHourly {
+				return nil, hourlyErr
+			}
+			if aws.ToString(params.TimePeriod.Start) != "2024-03-20" || aws.ToString(params.TimePeriod.End) != "2024-03-21" {
+				t.Errorf("expected the hours rounded out to whole days, got %s to %s", aws.ToString(params.TimePeriod.Start), aws.ToString(params.TimePeriod.End))
+			}
+			return &costexplorer.GetCostAndUsageOutput{}, nil
+		},
+	}}
+	hourly := CostQuery{
+		Start:       time.Date(2024, 3, 20, 3, 0, 0, 0, time.UTC),
+		End:         time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC),
+		Granularity: types.GranularityHourly,
+	}
+	if _, err := tracker.GetCosts(context.Background(), hourly); err == nil {
+		t.Error("expected the error of a rejected hourly query without Fallback")
+	}
+	hourly.Fallback = true
+	granularities = nil
+	if _, err := tracker.GetCosts(context.Background(), hourly); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(granularities, []types.Granularity{types.GranularityHourly, types.GranularityDaily}) {
+		t.Errorf("expected an hourly query retried daily, got %v", granularities)
+	}
+
+	validationCases := []struct {
+		message  string
+		fallback bool
+	}{
+		{message: "Hourly granularity is only available for the last 14 days", fallback: true},
+		{message: "Invalid group by dimension: REGIONS", fallback: false},
+	}
+	for _, tc := range validationCases {
+		hourlyErr = &smithy.GenericAPIError{Code: "ValidationException", Message: tc.message}
+		granularities = nil
+		_, err := tracker.GetCosts(context.Background(), hourly)
+		if tc.fallback {
+			if err != nil || len(granularities) != 2 {
+				t.Errorf("%s: expected a fallback to daily, got %v after %v", tc.message, err, granularities)
+			}
+		} else if !errors.Is(err, hourlyErr) || len(granularities) != 1 {
+			t.Errorf("%s: expected the error returned unchanged without a retry, got %v after %v", tc.message, err, granularities)
+		}
+	}
+}
+
+func TestGetCostsWeekly(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	// Daily data from Saturday 2024-01-06 to Wednesday 2024-01-10: two days of one ISO week, three of the next.
+	var daily []types.ResultByTime
+	for day := time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC); day.Before(time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)); day = day.AddDate(0, 0, 1) {
+		daily = append(daily, types.ResultByTime{
+			TimePeriod: &types.DateInterval{Start: aws.String(day.Format(AWSDateFormat)), End: aws.String(day.AddDate(0, 0, 1).Format(AWSDateFormat))},
+			Groups: []types.Group{
+				{Keys: []string{"AmazonEC2"}, Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("10.1"), Unit: aws.String("USD")}}},
+			},
+		})
+	}
+	mockClient := &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			if params.Granularity != types.GranularityDaily {
+				t.Errorf("expected weekly granularity to query daily data, got %s", params.Granularity)
+			}
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: daily}, nil
+		},
+	}
+	tracker := &CostTracker{client: mockClient}
+
+	costs, err := tracker.GetCosts(context.Background(), CostQuery{
+		Start:       time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC),
+		Granularity: GranularityWeekly,
+	})
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	expected := []CostByTime{
+		{Start: "2024-01-06", End: "2024-01-08", Groups: []GroupedCost{{Key: "AmazonEC2", Amount: "20.2", Unit: "USD"}}},
+		{Start: "2024-01-08", End: "2024-01-11", Groups: []GroupedCost{{Key: "AmazonEC2", Amount: "30.3", Unit: "USD"}}},
+	}
+	if len(costs) != len(expected) {
+		t.Fatalf("expected %d weeks, got %+v", len(expected), costs)
+	}
+	for i, week := range expected {
+		got := costs[i]
+		if got.Start != week.Start || got.End != week.End || len(got.Groups) != 1 || !reflect.DeepEqual(got.Groups[0], week.Groups[0]) {
+			t.Errorf("week %d: expected %+v, got %+v", i, week, got)
+		}
+	}
+}
+
+func TestExplicitRange(t *testing.T) {
+	now := time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		start, end         string
+		wantStart, wantEnd string
+	}{
+		{"2024-05-01", "2024-06-01", "2024-05-01", "2024-06-01"},
+		{"2024-06-01", "", "2024-06-01", "2024-06-15"},
+		{"", "2024-06-01", "2024-05-02", "2024-06-01"}, // --days 30 before the end
+	}
+	for _, tc := range testCases {
+		start, end, err := explicitRange(tc.start, tc.end, 30, now)
+		if err != nil {
+			t.Errorf("explicitRange(%q, %q) returned an error: %v", tc.start, tc.end, err)
+			continue
+		}
+		if start.Format(AWSDateFormat) != tc.wantStart || end.Format(AWSDateFormat) != tc.wantEnd {
+			t.Errorf("explicitRange(%q, %q) = %s to %s, expected %s to %s", tc.start, tc.end,
+				start.Format(AWSDateFormat), end.Format(AWSDateFormat), tc.wantStart, tc.wantEnd)
+		}
+	}
+
+	for _, bad := range [][2]string{{"2024-06-01", "2024-06-01"}, {"2024-07-01", "2024-06-01"}, {"05/01/2024", ""}, {"", "June"}} {
+		if _, _, err := explicitRange(bad[0], bad[1], 30, now); err == nil {
+			t.Errorf("expected an error for start %q and end %q", bad[0], bad[1])
+		}
+	}
+}
+
+func TestCostQueryFromConfigStartEnd(t *testing.T) {
+	viper.Set("start", "2024-05-01")
+	viper.Set("end", "2024-06-01")
+	t.Cleanup(func() {
+		viper.Set("start", "")
+		viper.Set("end", "")
+		viper.Set("period", "")
+	})
+
+	q, err := costQueryFromConfig(7)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if q.Days() != 31 {
+		t.Errorf("expected --start and --end to override --days, got %d days", q.Days())
+	}
+
+	viper.Set("period", PeriodLastWeek)
+	if _, err := costQueryFromConfig(7); err == nil {
+		t.Error("expected an error for --period with --start and --end")
+	}
+}
+
+func TestPeriodRangeCalendar(t *testing.T) {
+	now := time.Date(2024, 5, 15, 18, 30, 0, 0, time.UTC)
+	// A fiscal year starting in April must not affect calendar periods.
+	cal := FiscalCalendar{StartMonth: time.April}
+	testCases := map[string][2]string{
+		"this-month": {"2024-05-01", "2024-05-15"},
+		"mtd":        {"2024-05-01", "2024-05-15"},
+		"last-month": {"2024-04-01", "2024-05-01"},
+		"qtd":        {"2024-04-01", "2024-05-15"},
+		"ytd":        {"2024-01-01", "2024-05-15"},
+		"last-7d":    {"2024-05-08", "2024-05-15"},
+		"LAST-90D":   {"2024-02-15", "2024-05-15"},
+	}
+	for period, expected := range testCases {
+		start, end, err := periodRange(period, now, cal)
+		if err != nil {
+			t.Errorf("periodRange(%q) returned an error: %v", period, err)
+			continue
+		}
+		if start.Format(AWSDateFormat) != expected[0] || end.Format(AWSDateFormat) != expected[1] {
+			t.Errorf("periodRange(%q) = %s - %s, expected %s - %s", period, start.Format(AWSDateFormat), end.Format(AWSDateFormat), expected[0], expected[1])
+		}
+	}
+
+	if _, _, err := periodRange("mtd", time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC), cal); err == nil {
+		t.Error("expected an error for the month to date on its first day")
+	}
+	if _, _, err := periodRange("last-0d", now, cal); err == nil {
+		t.Error("expected an error for last-0d")
+	}
+}
@@ -0,0 +1,156 @@
+// File: quality.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+)
+
+// Data quality checks run on every GetCosts result.
+const (
+	CheckNegativeAmount  = "negative_amount"  // A group costs less than nothing, outside of credits and refunds
+	CheckTotalMismatch   = "total_mismatch"   // A period's groups do not add up to its ungrouped total (--verify-totals)
+	CheckMissingDay      = "missing_day"      // A day of a daily series has no data
+	CheckDuplicatePeriod = "duplicate_period" // A period, or a group within one, was returned more than once
+)
+
+// TotalTolerance is how far, in the report's unit, the groups of a period may add up from its total
+// before they are flagged, allowing for Cost Explorer rounding each amount.
+const TotalTolerance = 0.01
+
+// QualityIssue is a problem found in fetched cost data that makes its numbers suspect.
+type QualityIssue struct {
+	Check  string `json:"check"`
+	Period string `json:"period"` // Start of the period
+	Detail string `json:"detail"`
+}
+
+// dataQuality collects the quality issues found during the run, for the run summary.
+var dataQuality struct {
+	mu     sync.Mutex
+	issues []QualityIssue
+}
+
+// recordQualityIssues logs issues and adds them to the run summary and the run manifest. Nothing is
+// recorded while explaining, since no data is fetched.
+func recordQualityIssues(issues []QualityIssue) {
+	if len(issues) == 0 || explaining() {
+		return
+	}
+	for _, issue := range issues {
+		logger.Warnw("Data quality issue", "check", issue.Check, "period", issue.Period, "detail", issue.Detail)
+	}
+	dataQuality.mu.Lock()
+	dataQuality.issues = append(dataQuality.issues, issues...)
+	dataQuality.mu.Unlock()
+	if activeManifest != nil {
+		activeManifest.addQualityIssues(issues)
+	}
+}
+
+// checkCostQuality looks for negative amounts, duplicate groups and, in a daily series, missing days
+// in costs fetched for q at the given granularity, before any weekly aggregation. Negative amounts are
+// expected when grouping by record type, which separates credits and refunds, so they are not flagged then.
+func checkCostQuality(q CostQuery, granularity types.Granularity, costs []CostByTime) []QualityIssue {
+	var issues []QualityIssue
+	byRecordType := false
+	for _, g := range q.GroupBy {
+		byRecordType = byRecordType || aws.ToString(g.Key) == string(types.DimensionRecordType)
+	}
+
+	seen := make(map[string]bool, len(costs))
+	for _, period := range costs {
+		seen[period.Start] = true
+		keys := make(map[string]bool, len(period.Groups))
+		for _, group := range period.Groups {
+			if keys[group.Key] {
+				issues = append(issues, QualityIssue{CheckDuplicatePeriod, period.Start, fmt.Sprintf("group %q was returned more than once", group.Key)})
+			}
+			keys[group.Key] = true
+			if byRecordType {
+				continue
+			}
+			if amount, err := strconv.ParseFloat(group.Amount, 64); err == nil && amount < -TotalTolerance {
+				issues = append(issues, QualityIssue{CheckNegativeAmount, period.Start, fmt.Sprintf("%s is %s %s", group.Key, group.Amount, group.Unit)})
+			}
+		}
+	}
+
+	if granularity == types.GranularityDaily {
+		for day := q.Start; day.Before(q.End); day = day.AddDate(0, 0, 1) {
+			if start := day.Format(AWSDateFormat); !seen[start] {
+				issues = append(issues, QualityIssue{CheckMissingDay, start, "no data was returned for this day"})
+			}
+		}
+	}
+	return issues
+}
+
+// checkTotals compares the sum of each period's groups in costs with the period's total, which it
+// fetches with an extra ungrouped request. input is the grouped request costs were fetched with.
+func (ct *CostTracker) checkTotals(ctx context.Context, input costexplorer.GetCostAndUsageInput, metric string, costs []CostByTime) ([]QualityIssue, error) {
+	input.GroupBy = nil
+	input.NextPageToken = nil
+	totals := make(map[string]float64)
+	for {
+		result, err := ct.client.GetCostAndUsage(ctx, &input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get totals from AWS Cost Explorer: %w", err)
+		}
+		for _, resultByTime := range result.ResultsByTime {
+			if m, ok := resultByTime.Total[metric]; ok {
+				amount, err := strconv.ParseFloat(aws.ToString(m.Amount), 64)
+				if err != nil {
+					return nil, fmt.Errorf("unparseable total %q for %s", aws.ToString(m.Amount), aws.ToString(resultByTime.TimePeriod.Start))
+				}
+				totals[aws.ToString(resultByTime.TimePeriod.Start)] += amount
+			}
+		}
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			break
+		}
+		input.NextPageToken = result.NextPageToken
+	}
+
+	var issues []QualityIssue
+	for _, period := range costs {
+		var sum float64
+		for _, group := range period.Groups {
+			if amount, err := strconv.ParseFloat(group.Amount, 64); err == nil {
+				sum += amount
+			}
+		}
+		if total := totals[period.Start]; math.Abs(sum-total) > TotalTolerance {
+			issues = append(issues, QualityIssue{CheckTotalMismatch, period.Start, fmt.Sprintf("groups add up to %.2f, but the total is %.2f", sum, total)})
+		}
+	}
+	return issues, nil
+}
+
+// displayQualityIssues writes the data quality issues found during the run.
+func displayQualityIssues(w io.Writer, issues []QualityIssue) {
+	fmt.Fprintf(w, "Data quality: %d issue(s) found in the fetched cost data; check them before trusting the numbers:\n", len(issues))
+	for _, issue := range issues {
+		fmt.Fprintf(w, "  %-16s %s: %s\n", issue.Check, issue.Period, issue.Detail)
+	}
+}
+
+// reportDataQuality writes the quality issues found during the run to stderr after a command completes,
+// so they follow the report without mixing into machine-readable output on stdout.
+func reportDataQuality(cmd *cobra.Command, args []string) {
+	dataQuality.mu.Lock()
+	defer dataQuality.mu.Unlock()
+	if len(dataQuality.issues) == 0 {
+		return
+	}
+	displayQualityIssues(cmd.ErrOrStderr(), dataQuality.issues)
+}
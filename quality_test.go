@@ -0,0 +1,119 @@
+// File: quality_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGetCostsChecksDataQuality(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	dataQuality.issues = nil
+	t.Cleanup(func() { dataQuality.issues = nil })
+
+	day := func(start, end string, amounts ...string) types.ResultByTime {
+		result := types.ResultByTime{TimePeriod: &types.DateInterval{Start: aws.String(start), End: aws.String(end)}}
+		for i, amount := range amounts {
+			result.Groups = append(result.Groups, types.Group{
+				Keys:    []string{[]string{"Amazon EC2", "Amazon S3"}[i]},
+				Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String(amount), Unit: aws.String("USD")}},
+			})
+		}
+		return result
+	}
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			if params.GroupBy == nil {
+				total := func(start, amount string) types.ResultByTime {
+					return types.ResultByTime{
+						TimePeriod: &types.DateInterval{Start: aws.String(start)},
+						Total:      map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String(amount), Unit: aws.String("USD")}},
+					}
+				}
+				return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{
+					total("2024-06-01", "12"), total("2024-06-02", "9"), total("2024-06-04", "5"),
+				}}, nil
+			}
+			// 2024-06-03 is missing, 2024-06-02 is repeated and S3 is negative on 2024-06-04.
+			return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{
+				day("2024-06-01", "2024-06-02", "10", "2"),
+				day("2024-06-02", "2024-06-03", "8", "1"),
+				day("2024-06-02", "2024-06-03", "8", "1"),
+				day("2024-06-04", "2024-06-05", "6", "-1"),
+			}}, nil
+		},
+	}}
+	q := CostQuery{
+		Start:        time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		End:          time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC),
+		Granularity:  types.GranularityDaily,
+		VerifyTotals: true,
+	}
+
+	costs, err := tracker.GetCosts(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(costs) != 3 || len(costs[1].Groups) != 2 {
+		t.Fatalf("expected the repeated period to be skipped, got %+v", costs)
+	}
+	var got []string
+	for _, issue := range dataQuality.issues {
+		got = append(got, issue.Check+" "+issue.Period)
+	}
+	want := "duplicate_period 2024-06-02,negative_amount 2024-06-04,missing_day 2024-06-03"
+	if strings.Join(got, ",") != want {
+		t.Errorf("expected issues %s, got %s", want, strings.Join(got, ","))
+	}
+
+	// The totals match, so a total that does not is the only new issue.
+	dataQuality.issues = nil
+	tracker.client.(*mockCostExplorerClient).GetCostAndUsageFunc = func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		result := day("2024-06-01", "2024-06-02", "10", "2")
+		if params.GroupBy == nil {
+			result = types.ResultByTime{
+				TimePeriod: result.TimePeriod,
+				Total:      map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("13.5"), Unit: aws.String("USD")}},
+			}
+		}
+		return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{result}}, nil
+	}
+	q.End = q.Start.AddDate(0, 0, 1)
+	if _, err := tracker.GetCosts(context.Background(), q); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(dataQuality.issues) != 1 || dataQuality.issues[0].Check != CheckTotalMismatch {
+		t.Fatalf("expected a total mismatch, got %+v", dataQuality.issues)
+	}
+
+	var buf bytes.Buffer
+	displayQualityIssues(&buf, dataQuality.issues)
+	if !strings.Contains(buf.String(), "groups add up to 12.00, but the total is 13.50") {
+		t.Errorf("unexpected run summary:\n%s", buf.String())
+	}
+}
+
+func TestCheckCostQualityAllowsCreditsByRecordType(t *testing.T) {
+	q := CostQuery{
+		Start:   time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		End:     time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		GroupBy: []types.GroupDefinition{{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionRecordType))}},
+	}
+	costs := []CostByTime{{Start: "2024-06-01", Groups: []GroupedCost{{Key: "Credit", Amount: "-50", Unit: "USD"}, {Key: "Usage", Amount: "80", Unit: "USD"}}}}
+
+	if issues := checkCostQuality(q, GranularityMonthly, costs); len(issues) != 0 {
+		t.Errorf("expected no issues for credits grouped by record type, got %+v", issues)
+	}
+	q.GroupBy = nil
+	if issues := checkCostQuality(q, GranularityMonthly, costs); len(issues) != 1 || issues[0].Check != CheckNegativeAmount {
+		t.Errorf("expected a negative amount, got %+v", issues)
+	}
+}
@@ -0,0 +1,196 @@
+// File: rds.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+const (
+	RDSServiceName      = "Amazon Relational Database Service"
+	RDSCategoryInstance = "Instances"
+	UnknownLabel        = "(unknown)" // Label for group keys Cost Explorer leaves empty
+)
+
+// rdsDefinition splits RDS and Aurora spend by instance, storage, IOPS, backup and data transfer usage.
+// Usage types carry an optional region prefix (e.g. "EUC1-InstanceUsage:db.r5.large"), hence the unanchored start.
+var rdsDefinition = SpotlightDefinition{
+	Title:    "RDS/Aurora cost breakdown",
+	Services: []string{RDSServiceName},
+	Categories: []SpotlightCategory{
+		{Name: "Aurora Serverless", Pattern: regexp.MustCompile(`Aurora:Serverless|ServerlessV2Usage`)},
+		{Name: RDSCategoryInstance, Pattern: regexp.MustCompile(`(InstanceUsage|Multi-AZUsage|Multi-AZClusterUsage)[A-Za-z]*:db\.`)},
+		{Name: "Provisioned IOPS", Pattern: regexp.MustCompile(`RDS:(Multi-AZ-)?(PIOPS|GP3-PIOPS|GP3-Throughput|IO2-PIOPS)$|Aurora:StorageIOUsage`)},
+		{Name: "Storage", Pattern: regexp.MustCompile(`RDS:[A-Za-z0-9-]*Storage|Aurora:[A-Za-z-]*StorageUsage`)},
+		{Name: "Backup and snapshots", Pattern: regexp.MustCompile(`ChargedBackupUsage|BackupUsage|SnapshotExport|BacktrackUsage`)},
+		{Name: "Data transfer", Pattern: regexp.MustCompile(`DataTransfer|-Bytes$`)},
+		{Name: "Performance Insights", Pattern: regexp.MustCompile(`PI_`)},
+		{Name: "RDS Proxy", Pattern: regexp.MustCompile(`RDS:Proxy`)},
+	},
+	Other: "Other RDS usage",
+}
+
+// instanceClass extracts the DB instance class from an instance usage type, e.g. "db.r5.large".
+func instanceClass(usageType string) string {
+	if i := strings.Index(usageType, ":db."); i >= 0 {
+		return usageType[i+1:]
+	}
+	return UnknownLabel
+}
+
+// ReservationCoverage is the reserved and total running hours of a group, summed over a query range.
+type ReservationCoverage struct {
+	ReservedHours     float64
+	TotalRunningHours float64
+}
+
+// Percent returns the share of running hours covered by reservations, or false if nothing ran.
+func (c ReservationCoverage) Percent() (float64, bool) {
+	if c.TotalRunningHours == 0 {
+		return 0, false
+	}
+	return c.ReservedHours / c.TotalRunningHours * 100, true
+}
+
+// parseHours parses an hour count reported by Cost Explorer, treating missing or malformed values as zero.
+func parseHours(hours *string) float64 {
+	value, err := strconv.ParseFloat(aws.ToString(hours), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// GetReservationCoverage retrieves reserved instance coverage over the query range, grouped by q.GroupBy.
+// The result is keyed by the value of the group's attribute, with UnknownLabel for groups without one.
+func (ct *CostTracker) GetReservationCoverage(ctx context.Context, q CostQuery) (map[string]ReservationCoverage, error) {
+	if !q.Start.Before(q.End) {
+		return nil, fmt.Errorf("start date %s must be before end date %s", q.Start.Format(AWSDateFormat), q.End.Format(AWSDateFormat))
+	}
+
+	coverage := make(map[string]ReservationCoverage)
+	var nextPageToken *string
+	for {
+		result, err := ct.client.GetReservationCoverage(ctx, &costexplorer.GetReservationCoverageInput{
+			TimePeriod: &types.DateInterval{
+				Start: aws.String(q.Start.Format(AWSDateFormat)),
+				End:   aws.String(q.End.Format(AWSDateFormat)),
+			},
+			Filter:        q.Filter,
+			Granularity:   GranularityMonthly,
+			GroupBy:       q.GroupBy,
+			NextPageToken: nextPageToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reservation coverage from AWS Cost Explorer: %w", err)
+		}
+		for _, coverageByTime := range result.CoveragesByTime {
+			for _, group := range coverageByTime.Groups {
+				if group.Coverage == nil || group.Coverage.CoverageHours == nil {
+					continue
+				}
+				key := UnknownLabel
+				for _, value := range group.Attributes {
+					if value != "" {
+						key = value
+					}
+				}
+				hours := group.Coverage.CoverageHours
+				acc := coverage[key]
+				acc.ReservedHours += parseHours(hours.ReservedHours)
+				acc.TotalRunningHours += parseHours(hours.TotalRunningHours)
+				coverage[key] = acc
+			}
+		}
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			break
+		}
+		nextPageToken = result.NextPageToken
+	}
+	return coverage, nil
+}
+
+// annotateRDS adds cost by engine with reserved coverage, and instance cost by class.
+func annotateRDS(ctx context.Context, tracker *CostTracker, q CostQuery, report *SpotlightReport) error {
+	q.Filter = andExpression(q.Filter, &types.Expression{
+		Dimensions: &types.DimensionValues{Key: types.DimensionService, Values: rdsDefinition.Services},
+	})
+	q.GroupBy = []types.GroupDefinition{
+		{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionDatabaseEngine))},
+	}
+
+	costs, err := tracker.GetCosts(ctx, q)
+	if err != nil {
+		return err
+	}
+	coverage, err := tracker.GetReservationCoverage(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	totals, _ := totalsByService(costs)
+	engines := make([]string, 0, len(totals))
+	for engine := range totals {
+		engines = append(engines, engine)
+	}
+	sort.Slice(engines, func(i, j int) bool {
+		if totals[engines[i]] != totals[engines[j]] {
+			return totals[engines[i]] > totals[engines[j]]
+		}
+		return engines[i] < engines[j]
+	})
+
+	byEngine := ReportSection{Title: "Cost by engine", Header: []string{"Engine", "Cost", "RI coverage"}}
+	for _, engine := range engines {
+		label := engine
+		if label == "" {
+			label = UnknownLabel
+		}
+		covered := "n/a"
+		if percent, ok := coverage[label].Percent(); ok {
+			covered = fmt.Sprintf("%.1f%%", percent)
+		}
+		byEngine.Rows = append(byEngine.Rows, []string{label, fmt.Sprintf("%.2f", totals[engine]), covered})
+	}
+	report.Sections = append(report.Sections, byEngine)
+
+	if line := report.Line(RDSCategoryInstance); line != nil {
+		classes := make(map[string]float64)
+		for _, u := range line.UsageTypes {
+			classes[instanceClass(u.UsageType)] += u.Cost
+		}
+		names := make([]string, 0, len(classes))
+		for name := range classes {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if classes[names[i]] != classes[names[j]] {
+				return classes[names[i]] > classes[names[j]]
+			}
+			return names[i] < names[j]
+		})
+
+		byClass := ReportSection{Title: "Instance cost by class", Header: []string{"Instance class", "Cost"}}
+		for _, name := range names {
+			byClass.Rows = append(byClass.Rows, []string{name, fmt.Sprintf("%.2f", classes[name])})
+		}
+		report.Sections = append(report.Sections, byClass)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(newSpotlightCommand("rds",
+		"Break down RDS and Aurora costs by engine, instance class and usage.",
+		`Splits database spend into instances, Aurora Serverless, storage, provisioned IOPS, backups and data
+transfer, with cost and reserved instance coverage per engine and instance cost per instance class.`,
+		rdsDefinition, annotateRDS))
+}
@@ -0,0 +1,128 @@
+// File: rds_test.go
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRDSDefinition(t *testing.T) {
+	testCases := map[string]string{
+		"InstanceUsage:db.r5.large":               "Instances",
+		"EUC1-Multi-AZUsage:db.m6g.xlarge":        "Instances",
+		"InstanceUsageIOOptimized:db.r6g.large":   "Instances",
+		"Aurora:ServerlessV2Usage":                "Aurora Serverless",
+		"RDS:GP2-Storage":                         "Storage",
+		"USW2-RDS:Multi-AZ-GP3-Storage":           "Storage",
+		"Aurora:StorageUsage":                     "Storage",
+		"RDS:PIOPS":                               "Provisioned IOPS",
+		"RDS:GP3-PIOPS":                           "Provisioned IOPS",
+		"Aurora:StorageIOUsage":                   "Provisioned IOPS",
+		"RDS:ChargedBackupUsage":                  "Backup and snapshots",
+		"Aurora:BackupUsage":                      "Backup and snapshots",
+		"APS2-DataTransfer-Out-Bytes":             "Data transfer",
+		"APS2-PI_LTR:FreeTier":                    "Performance Insights",
+		"RDS:Proxy-ACU":                           "RDS Proxy",
+		"RDS:SomethingNew":                        "Other RDS usage",
+		"APS2-InstanceUsage:db.t3.micro.reserved": "Instances",
+	}
+	for usageType, expected := range testCases {
+		lines := classifyUsage([]UsageTypeCost{{UsageType: usageType, Cost: 1}}, rdsDefinition)
+		got := ""
+		if len(lines) == 1 {
+			got = lines[0].Category
+		}
+		if got != expected {
+			t.Errorf("usage type %q classified as %q, expected %q", usageType, got, expected)
+		}
+	}
+}
+
+func TestAnnotateRDS(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	mockClient := &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			if len(params.GroupBy) != 1 || aws.ToString(params.GroupBy[0].Key) != string(types.DimensionDatabaseEngine) {
+				t.Errorf("expected grouping by DATABASE_ENGINE, got %+v", params.GroupBy)
+			}
+			return &costexplorer.GetCostAndUsageOutput{
+				ResultsByTime: []types.ResultByTime{
+					{
+						TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-01-15")},
+						Groups: []types.Group{
+							{Keys: []string{"MySQL"}, Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("40"), Unit: aws.String("USD")}}},
+							{Keys: []string{"Aurora PostgreSQL"}, Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String("60"), Unit: aws.String("USD")}}},
+						},
+					},
+				},
+			}, nil
+		},
+		GetReservationCoverageFunc: func(ctx context.Context, params *costexplorer.GetReservationCoverageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error) {
+			coverage := func(reserved, total string) *types.Coverage {
+				return &types.Coverage{CoverageHours: &types.CoverageHours{ReservedHours: aws.String(reserved), TotalRunningHours: aws.String(total)}}
+			}
+			// Two pages to exercise pagination; MySQL hours are summed across them.
+			if params.NextPageToken == nil {
+				return &costexplorer.GetReservationCoverageOutput{
+					CoveragesByTime: []types.CoverageByTime{{Groups: []types.ReservationCoverageGroup{
+						{Attributes: map[string]string{"databaseEngine": "MySQL"}, Coverage: coverage("100", "200")},
+					}}},
+					NextPageToken: aws.String("page-2"),
+				}, nil
+			}
+			return &costexplorer.GetReservationCoverageOutput{
+				CoveragesByTime: []types.CoverageByTime{{Groups: []types.ReservationCoverageGroup{
+					{Attributes: map[string]string{"databaseEngine": "MySQL"}, Coverage: coverage("50", "100")},
+				}}},
+			}, nil
+		},
+	}
+	tracker := &CostTracker{client: mockClient}
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+	report := &SpotlightReport{
+		Lines: []SpotlightLine{{
+			Category: RDSCategoryInstance,
+			Cost:     35,
+			UsageTypes: []UsageTypeCost{
+				{UsageType: "InstanceUsage:db.r5.large", Cost: 10},
+				{UsageType: "EUC1-Multi-AZUsage:db.r5.large", Cost: 5},
+				{UsageType: "InstanceUsage:db.t3.micro", Cost: 20},
+			},
+		}},
+	}
+
+	if err := annotateRDS(context.Background(), tracker, q, report); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(report.Sections) != 2 {
+		t.Fatalf("expected engine and instance class sections, got %d", len(report.Sections))
+	}
+
+	engines := report.Sections[0].Rows
+	expectedEngines := [][]string{{"Aurora PostgreSQL", "60.00", "n/a"}, {"MySQL", "40.00", "50.0%"}}
+	if len(engines) != len(expectedEngines) {
+		t.Fatalf("expected %d engine rows, got %v", len(expectedEngines), engines)
+	}
+	for i, row := range expectedEngines {
+		for j, cell := range row {
+			if engines[i][j] != cell {
+				t.Errorf("engine row %d column %d: expected %q, got %q", i, j, cell, engines[i][j])
+			}
+		}
+	}
+
+	classes := report.Sections[1].Rows
+	if len(classes) != 2 || classes[0][0] != "db.t3.micro" || classes[1][0] != "db.r5.large" || classes[1][1] != "15.00" {
+		t.Errorf("unexpected instance class rows: %v", classes)
+	}
+}
@@ -0,0 +1,164 @@
+// File: redact.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Redaction modes for account IDs and tag values.
+const (
+	RedactKeep   = "keep"   // Leave the value as is
+	RedactHash   = "hash"   // Replace the value with a stable pseudonym, so trends remain comparable
+	RedactRemove = "remove" // Replace the value with a fixed placeholder
+)
+
+// accountIDPattern matches 12-digit AWS account IDs inside names.
+var accountIDPattern = regexp.MustCompile(`\b\d{12}\b`)
+
+// RedactionProfile describes what to strip from cost data before it is shared outside the company.
+type RedactionProfile struct {
+	Accounts  string  `mapstructure:"accounts"`   // RedactKeep, RedactHash or RedactRemove
+	TagValues string  `mapstructure:"tag_values"` // Applied to the value of "key$value" tag group keys
+	RoundTo   float64 `mapstructure:"round_to"`   // Round amounts to the nearest multiple; 0 keeps exact amounts
+}
+
+// builtinRedactionProfiles are available without configuration. redaction.profiles adds to and
+// overrides them.
+var builtinRedactionProfiles = map[string]RedactionProfile{
+	"vendor": {Accounts: RedactHash, TagValues: RedactHash},
+	"public": {Accounts: RedactRemove, TagValues: RedactRemove, RoundTo: 100},
+}
+
+// RedactionProfileByName returns the named profile from redaction.profiles or the built-in profiles.
+func RedactionProfileByName(name string) (RedactionProfile, error) {
+	profiles := make(map[string]RedactionProfile, len(builtinRedactionProfiles))
+	for n, p := range builtinRedactionProfiles {
+		profiles[n] = p
+	}
+	var configured map[string]RedactionProfile
+	if err := unmarshalConfigKey("redaction.profiles", &configured); err != nil {
+		return RedactionProfile{}, fmt.Errorf("invalid redaction.profiles: %w", err)
+	}
+	for n, p := range configured {
+		profiles[n] = p
+	}
+
+	profile, ok := profiles[name]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return RedactionProfile{}, fmt.Errorf("unknown redaction profile %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	for _, mode := range []*string{&profile.Accounts, &profile.TagValues} {
+		switch *mode {
+		case "":
+			*mode = RedactKeep
+		case RedactKeep, RedactHash, RedactRemove:
+		default:
+			return RedactionProfile{}, fmt.Errorf("redaction profile %q: invalid mode %q, expected keep, hash or remove", name, *mode)
+		}
+	}
+	if profile.RoundTo < 0 {
+		return RedactionProfile{}, fmt.Errorf("redaction profile %q: round_to must not be negative, got %g", name, profile.RoundTo)
+	}
+	if profile.hashes() && viper.GetString("redaction.salt") == "" {
+		return RedactionProfile{}, fmt.Errorf("redaction profile %q hashes account IDs or tag values, which can be recovered by brute force without redaction.salt; set it to a long random string", name)
+	}
+	return profile, nil
+}
+
+// hashes reports whether the profile replaces account IDs or tag values with pseudonyms.
+func (p RedactionProfile) hashes() bool {
+	return p.Accounts == RedactHash || p.TagValues == RedactHash
+}
+
+// pseudonym returns a stable, salted stand-in for value. Without redaction.salt, 12-digit account
+// IDs could be recovered by brute force, so profiles that hash are refused without one.
+func pseudonym(value string) string {
+	sum := sha256.Sum256([]byte(viper.GetString("redaction.salt") + value))
+	return hex.EncodeToString(sum[:4])
+}
+
+// redactValue applies mode to value, using placeholder for RedactRemove.
+func redactValue(mode, value, placeholder string) string {
+	switch mode {
+	case RedactHash:
+		return pseudonym(value)
+	case RedactRemove:
+		return placeholder
+	default:
+		return value
+	}
+}
+
+// redactName redacts account IDs and tag values in a group name.
+func (p RedactionProfile) redactName(name string) string {
+	name = accountIDPattern.ReplaceAllStringFunc(name, func(id string) string {
+		return redactValue(p.Accounts, id, "[account]")
+	})
+	if key, value, ok := strings.Cut(name, tagValueSep); ok && value != "" {
+		name = key + tagValueSep + redactValue(p.TagValues, value, "[redacted]")
+	}
+	return name
+}
+
+// redactAmount rounds amount to the nearest multiple of RoundTo. Amounts that cannot be parsed are
+// replaced, since they cannot be rounded.
+func (p RedactionProfile) redactAmount(amount string) string {
+	if p.RoundTo == 0 {
+		return amount
+	}
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return "0"
+	}
+	return strconv.FormatFloat(math.Round(value/p.RoundTo)*p.RoundTo, 'f', -1, 64)
+}
+
+// Apply returns a redacted copy of costs. Services whose names become identical, such as two
+// accounts under RedactRemove, are kept as separate rows.
+func (p RedactionProfile) Apply(costs []CostByTime) []CostByTime {
+	redacted := make([]CostByTime, len(costs))
+	for i, period := range costs {
+		redacted[i] = CostByTime{Start: period.Start, End: period.End, Approximate: period.Approximate, Groups: make([]GroupedCost, len(period.Groups))}
+		for j, serviceCost := range period.Groups {
+			redacted[i].Groups[j] = GroupedCost{
+				Key:    p.redactName(serviceCost.Key),
+				Amount: p.redactAmount(serviceCost.Amount),
+				Unit:   serviceCost.Unit,
+			}
+			if serviceCost.Metrics != nil {
+				metrics := make(map[string]MetricAmount, len(serviceCost.Metrics))
+				for name, m := range serviceCost.Metrics {
+					metrics[name] = MetricAmount{Amount: p.redactAmount(m.Amount), Unit: m.Unit}
+				}
+				redacted[i].Groups[j].Metrics = metrics
+			}
+			if serviceCost.Keys != nil {
+				// Redact each key, so a tag value is found after the first key too
+				keys := make([]string, len(serviceCost.Keys))
+				for k, key := range serviceCost.Keys {
+					keys[k] = p.redactName(key)
+				}
+				redacted[i].Groups[j].Key, redacted[i].Groups[j].Keys = strings.Join(keys, GroupKeySeparator), keys
+			}
+		}
+	}
+	return redacted
+}
+
+func init() {
+	viper.SetDefault("redaction.salt", "") // Salt for hashed account IDs and tag values
+}
@@ -0,0 +1,107 @@
+// File: redact_test.go
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestRedactionProfileApply(t *testing.T) {
+	costs := []CostByTime{{Start: "2024-01-01", End: "2024-02-01", Groups: []GroupedCost{
+		{Key: "111111111111", Amount: "1234.56", Unit: "USD"},
+		{Key: "team$payments", Amount: "49.99", Unit: "USD"},
+		{Key: "Amazon Simple Storage Service", Amount: "150", Unit: "USD"},
+		{Key: "111111111111 / team$payments", Keys: []string{"111111111111", "team$payments"}, Amount: "10", Unit: "USD"},
+	}}}
+
+	tests := []struct {
+		name    string
+		profile RedactionProfile
+		want    []GroupedCost
+	}{
+		{
+			name:    "keep",
+			profile: RedactionProfile{Accounts: RedactKeep, TagValues: RedactKeep},
+			want:    costs[0].Groups,
+		},
+		{
+			name:    "public",
+			profile: builtinRedactionProfiles["public"],
+			want: []GroupedCost{
+				{Key: "[account]", Amount: "1200", Unit: "USD"},
+				{Key: "team$[redacted]", Amount: "0", Unit: "USD"},
+				{Key: "Amazon Simple Storage Service", Amount: "200", Unit: "USD"},
+				{Key: "[account] / team$[redacted]", Keys: []string{"[account]", "team$[redacted]"}, Amount: "0", Unit: "USD"},
+			},
+		},
+		{
+			name:    "vendor",
+			profile: builtinRedactionProfiles["vendor"],
+			want: []GroupedCost{
+				{Key: pseudonym("111111111111"), Amount: "1234.56", Unit: "USD"},
+				{Key: "team$" + pseudonym("payments"), Amount: "49.99", Unit: "USD"},
+				{Key: "Amazon Simple Storage Service", Amount: "150", Unit: "USD"},
+				{Key: pseudonym("111111111111") + " / team$" + pseudonym("payments"), Keys: []string{pseudonym("111111111111"), "team$" + pseudonym("payments")}, Amount: "10", Unit: "USD"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.profile.Apply(costs)
+			if len(got) != 1 || got[0].Start != "2024-01-01" || len(got[0].Groups) != len(tt.want) {
+				t.Fatalf("unexpected redacted costs: %+v", got)
+			}
+			for i, want := range tt.want {
+				if !reflect.DeepEqual(got[0].Groups[i], want) {
+					t.Errorf("row %d: got %+v, want %+v", i, got[0].Groups[i], want)
+				}
+			}
+		})
+	}
+	approximate := []CostByTime{{Start: "2024-01-01", End: "2024-01-02", Approximate: true, Groups: costs[0].Groups[:1]}}
+	if got := builtinRedactionProfiles["public"].Apply(approximate); !got[0].Approximate {
+		t.Errorf("expected redacted estimates to stay marked approximate, got %+v", got)
+	}
+	if costs[0].Groups[0].Key != "111111111111" {
+		t.Errorf("Apply must not modify its input")
+	}
+}
+
+func TestRedactionProfileByName(t *testing.T) {
+	viper.Set("redaction.profiles", map[string]interface{}{
+		"auditor": map[string]interface{}{"accounts": "hash", "round_to": 10},
+		"broken":  map[string]interface{}{"accounts": "scramble"},
+	})
+	t.Cleanup(func() {
+		viper.Set("redaction.profiles", nil)
+		viper.Set("redaction.salt", "")
+	})
+
+	for _, name := range []string{"auditor", "vendor"} {
+		if _, err := RedactionProfileByName(name); err == nil {
+			t.Errorf("expected profile %q, which hashes, to be refused without redaction.salt", name)
+		}
+	}
+	viper.Set("redaction.salt", "s3cret")
+
+	profile, err := RedactionProfileByName("auditor")
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if profile.Accounts != RedactHash || profile.TagValues != RedactKeep || profile.RoundTo != 10 {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+	if _, err := RedactionProfileByName("vendor"); err != nil {
+		t.Errorf("expected the built-in vendor profile with a salt, got: %v", err)
+	}
+	if _, err := RedactionProfileByName("public"); err != nil {
+		t.Errorf("expected the built-in public profile, got: %v", err)
+	}
+	for _, name := range []string{"broken", "missing"} {
+		if _, err := RedactionProfileByName(name); err == nil {
+			t.Errorf("expected an error for profile %q, but got nil", name)
+		}
+	}
+}
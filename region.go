@@ -0,0 +1,134 @@
+// File: region.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+const NoRegionLabel = "global" // Label used when Cost Explorer reports a cost without a region
+
+// RegionCostMatrix holds the cost of each service in each region, summed over the whole query range.
+type RegionCostMatrix struct {
+	Regions  []string
+	Services []string
+	Unit     string
+	Amounts  map[string]map[string]float64 // service -> region -> amount
+}
+
+// GetCostsPerRegion queries costs grouped by region and service, in one paginated request, and
+// assembles the results into a region×service matrix.
+func (ct *CostTracker) GetCostsPerRegion(ctx context.Context, q CostQuery) (*RegionCostMatrix, error) {
+	q.GroupBy = []types.GroupDefinition{
+		{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionRegion))},
+		{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionService))},
+	}
+	costs, err := ct.GetCosts(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get costs per region: %w", err)
+	}
+
+	matrix := &RegionCostMatrix{
+		Amounts: make(map[string]map[string]float64),
+	}
+	seenRegions := make(map[string]bool)
+	for _, period := range costs {
+		for _, cost := range period.Groups {
+			if len(cost.Keys) != 2 {
+				logger.Warnw("Skipping cost without a region and service", "key", cost.Key)
+				continue
+			}
+			label, service := cost.Keys[0], cost.Keys[1]
+			if label == "" || label == "NoRegion" {
+				label = NoRegionLabel
+			}
+			amount, err := strconv.ParseFloat(cost.Amount, 64)
+			if err != nil {
+				logger.Warnw("Skipping unparseable cost amount",
+					"service", service,
+					"region", label,
+					"amount", cost.Amount)
+				continue
+			}
+			if !seenRegions[label] {
+				seenRegions[label] = true
+				matrix.Regions = append(matrix.Regions, label)
+			}
+			if _, ok := matrix.Amounts[service]; !ok {
+				matrix.Amounts[service] = make(map[string]float64)
+				matrix.Services = append(matrix.Services, service)
+			}
+			matrix.Amounts[service][label] += amount
+			matrix.Unit = cost.Unit
+		}
+	}
+	sort.Strings(matrix.Regions)
+	sort.Strings(matrix.Services)
+	return matrix, nil
+}
+
+// andExpression combines two filter expressions with AND, treating nil as "no filter".
+func andExpression(a, b *types.Expression) *types.Expression {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return &types.Expression{And: []types.Expression{*a, *b}}
+}
+
+// displayRegionMatrix writes the region×service matrix to w, one row per service.
+func displayRegionMatrix(w io.Writer, matrix *RegionCostMatrix, days int) {
+	fmt.Fprintf(w, "AWS Costs per region for the last %d days (%s):\n", days, matrix.Unit)
+	fmt.Fprintln(w, "=====================================")
+	if len(matrix.Services) == 0 {
+		fmt.Fprintln(w, "No cost data found for the specified period.")
+		return
+	}
+
+	writeCostMatrix(w, matrix.Regions, matrix.Services, matrix.Amounts)
+}
+
+// writeCostMatrix writes one row per service and one column per region, payer or other label, each
+// with its total.
+func writeCostMatrix(w io.Writer, columns, services []string, amounts map[string]map[string]float64) {
+	fmt.Fprintf(w, "%-*s", ServiceNameWidth, "Service")
+	for _, column := range columns {
+		fmt.Fprintf(w, " %14s", column)
+	}
+	fmt.Fprintf(w, " %14s\n", "Total")
+
+	columnTotals := make(map[string]float64)
+	var grandTotal float64
+	shown, hidden := rowLimit(len(services))
+	for i, service := range services {
+		var serviceTotal float64
+		for _, column := range columns {
+			serviceTotal += amounts[service][column]
+			columnTotals[column] += amounts[service][column]
+		}
+		grandTotal += serviceTotal
+		if i >= shown {
+			continue // Hidden rows still count towards the totals
+		}
+		fmt.Fprintf(w, "%-*s", ServiceNameWidth, truncateName(service, ServiceNameWidth))
+		for _, column := range columns {
+			fmt.Fprintf(w, " %14.2f", amounts[service][column])
+		}
+		fmt.Fprintf(w, " %14.2f\n", serviceTotal)
+	}
+	writeHiddenRows(w, hidden)
+
+	fmt.Fprintf(w, "%-*s", ServiceNameWidth, "Total")
+	for _, column := range columns {
+		fmt.Fprintf(w, " %14.2f", columnTotals[column])
+	}
+	fmt.Fprintf(w, " %14.2f\n", grandTotal)
+}
@@ -0,0 +1,102 @@
+// File: region_test.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGetCostsPerRegion(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	ctx := context.Background()
+
+	// Each page holds the region and service groups Cost Explorer returns for one request.
+	pages := [][][]string{
+		{{"us-east-1", "Amazon EC2", "10.50"}, {"us-east-1", "Amazon S3", "2.00"}},
+		{{"ap-southeast-2", "Amazon EC2", "4.50"}, {"NoRegion", "AWS Support", "29.00"}},
+	}
+
+	testCases := []struct {
+		name          string
+		apiError      error
+		expectedError bool
+	}{
+		{name: "matrix assembled from every page"},
+		{name: "API error fails the run", apiError: fmt.Errorf("simulated AWS API error"), expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			mockClient := &mockCostExplorerClient{
+				GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+					if tc.apiError != nil {
+						return nil, tc.apiError
+					}
+					if len(params.GroupBy) != 2 || aws.ToString(params.GroupBy[0].Key) != string(types.DimensionRegion) || aws.ToString(params.GroupBy[1].Key) != string(types.DimensionService) {
+						t.Errorf("expected a REGION and SERVICE grouping, got %+v", params.GroupBy)
+					}
+					page := 0
+					if params.NextPageToken != nil {
+						page = 1
+					}
+					calls++
+					var groups []types.Group
+					for _, row := range pages[page] {
+						groups = append(groups, types.Group{
+							Keys: row[:2],
+							Metrics: map[string]types.MetricValue{
+								MetricBlendedCost: {Amount: aws.String(row[2]), Unit: aws.String("USD")},
+							},
+						})
+					}
+					out := &costexplorer.GetCostAndUsageOutput{
+						ResultsByTime: []types.ResultByTime{
+							{TimePeriod: params.TimePeriod, Groups: groups},
+						},
+					}
+					if page == 0 {
+						out.NextPageToken = aws.String("page-2")
+					}
+					return out, nil
+				},
+			}
+			tracker := &CostTracker{client: mockClient}
+
+			matrix, err := tracker.GetCostsPerRegion(ctx, lastNDays(30))
+			if tc.expectedError {
+				if err == nil {
+					t.Fatalf("expected an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error, but got: %v", err)
+			}
+
+			if calls != 2 {
+				t.Errorf("expected two paginated requests, got %d", calls)
+			}
+			expectedRegions := []string{"ap-southeast-2", "global", "us-east-1"}
+			if fmt.Sprint(matrix.Regions) != fmt.Sprint(expectedRegions) {
+				t.Errorf("expected regions %v, got %v", expectedRegions, matrix.Regions)
+			}
+			expectedServices := []string{"AWS Support", "Amazon EC2", "Amazon S3"}
+			if fmt.Sprint(matrix.Services) != fmt.Sprint(expectedServices) {
+				t.Errorf("expected services %v, got %v", expectedServices, matrix.Services)
+			}
+			if got := matrix.Amounts["Amazon EC2"]["ap-southeast-2"]; got != 4.50 {
+				t.Errorf("expected EC2 in ap-southeast-2 to be 4.50, got %.2f", got)
+			}
+			if got := matrix.Amounts["AWS Support"]["global"]; got != 29.00 {
+				t.Errorf("expected Support in global to be 29.00, got %.2f", got)
+			}
+		})
+	}
+}
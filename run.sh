@@ -84,7 +84,7 @@ POLICY_DOCUMENT=$(cat <<EOF
   "Statement": [
     {
       "Effect": "Allow",
-      "Action": ["ce:GetCostAndUsage","ce:ListCostCategoryDefinitions"],
+      "Action": ["ce:GetCostAndUsage","ce:GetDimensionValues","ce:GetReservationCoverage","ce:GetCostForecast","ce:GetAnomalies","ce:GetAnomalyMonitors","ce:GetAnomalySubscriptions","ce:ListCostCategoryDefinitions","budgets:ViewBudget"],
       "Resource": "*"
     }
   ]
@@ -0,0 +1,44 @@
+// File: runid.go
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// runID identifies this invocation in every log line, notification and run manifest, so the logs
+// of a failed scheduled run can be found among those of other runs and accounts.
+var runID = newRunID()
+
+// newRunID returns a random (version 4) UUID.
+func newRunID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to generate run ID: %v", err)) // crypto/rand does not fail on supported platforms
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// runContextFields returns the logger fields describing this run: its ID and command, and the
+// account of aws.role_arn and the AWS_PROFILE used, when set.
+func runContextFields(cmd *cobra.Command) []interface{} {
+	fields := []interface{}{"run_id", runID, "command", cmd.Name()}
+	if account, err := accountIDFromTarget(viper.GetString("aws.role_arn")); err == nil {
+		fields = append(fields, "account", account)
+	}
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		fields = append(fields, "profile", profile)
+	}
+	return fields
+}
+
+// withRunID appends the run ID to an outbound notification.
+func withRunID(message string) string {
+	return message + "\n(run " + runID + ")"
+}
@@ -0,0 +1,40 @@
+// File: runid_test.go
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestNewRunID(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	first, second := newRunID(), newRunID()
+	if !uuid.MatchString(first) || first == second {
+		t.Errorf("expected two different version 4 UUIDs, got %s and %s", first, second)
+	}
+	if !strings.HasSuffix(withRunID("Cost Tracker Error: boom"), "(run "+runID+")") {
+		t.Errorf("expected the run ID at the end of notifications, got %q", withRunID("boom"))
+	}
+}
+
+func TestRunContextFields(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "billing")
+	viper.Set("aws.role_arn", "arn:aws:iam::111111111111:role/cost-tracker")
+	t.Cleanup(func() { viper.Set("aws.role_arn", "") })
+
+	fields := runContextFields(&cobra.Command{Use: "get"})
+	want := []interface{}{"run_id", runID, "command", "get", "account", "111111111111", "profile", "billing"}
+	if len(fields) != len(want) {
+		t.Fatalf("expected fields %v, got %v", want, fields)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("expected fields %v, got %v", want, fields)
+			break
+		}
+	}
+}
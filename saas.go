@@ -0,0 +1,358 @@
+// File: saas.go
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// DefaultSubscriptionCurrency is the currency of subscriptions that do not give one.
+const DefaultSubscriptionCurrency = "USD"
+
+// Subscription is a SaaS subscription tracked alongside AWS spend, entered in saas.subscriptions or
+// a CSV file with the same column names.
+type Subscription struct {
+	Name        string  `mapstructure:"name"`
+	Owner       string  `mapstructure:"owner"`        // Team or person responsible, optional
+	MonthlyCost float64 `mapstructure:"monthly_cost"` // Defaults to Seats × SeatPrice for per-seat pricing
+	Currency    string  `mapstructure:"currency"`
+	Renews      string  `mapstructure:"renews"`       // Next renewal date, YYYY-MM-DD; optional
+	Seats       int     `mapstructure:"seats"`        // Paid seats, for per-seat subscriptions
+	SeatPrice   float64 `mapstructure:"seat_price"`   // Monthly price of one seat
+	ActiveUsers *int    `mapstructure:"active_users"` // Seats in use, if known
+}
+
+// UnusedSeats returns how many paid seats have no active user, or 0 if utilization is not known.
+func (s Subscription) UnusedSeats() int {
+	if s.ActiveUsers == nil || *s.ActiveUsers >= s.Seats {
+		return 0
+	}
+	return s.Seats - *s.ActiveUsers
+}
+
+// UnusedCost returns the monthly cost of the unused seats.
+func (s Subscription) UnusedCost() float64 {
+	if s.Seats == 0 {
+		return 0
+	}
+	return s.MonthlyCost * float64(s.UnusedSeats()) / float64(s.Seats)
+}
+
+// NextRenewal returns the first renewal on or after today. A renewal date that has passed is assumed
+// to recur yearly, as most SaaS contracts do. ok is false when no renewal date is set.
+func (s Subscription) NextRenewal(today time.Time) (renewal time.Time, ok bool, err error) {
+	if s.Renews == "" {
+		return time.Time{}, false, nil
+	}
+	renewal, err = time.Parse(AWSDateFormat, s.Renews)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("subscription %q: invalid renewal date %q, expected YYYY-MM-DD", s.Name, s.Renews)
+	}
+	for renewal.Before(today) {
+		renewal = renewal.AddDate(1, 0, 0)
+	}
+	return renewal, true, nil
+}
+
+// validate checks the subscription and fills in the default currency.
+func (s *Subscription) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("subscription without a name")
+	}
+	if s.MonthlyCost < 0 || s.SeatPrice < 0 || s.Seats < 0 {
+		return fmt.Errorf("subscription %q: monthly_cost, seats and seat_price must not be negative", s.Name)
+	}
+	if s.ActiveUsers != nil && (*s.ActiveUsers < 0 || s.Seats == 0) {
+		return fmt.Errorf("subscription %q: active_users needs a number of seats and must not be negative", s.Name)
+	}
+	if s.MonthlyCost == 0 {
+		s.MonthlyCost = float64(s.Seats) * s.SeatPrice
+	}
+	if s.Currency == "" {
+		s.Currency = DefaultSubscriptionCurrency
+	}
+	s.Currency = strings.ToUpper(s.Currency)
+	_, _, err := s.NextRenewal(time.Time{})
+	return err
+}
+
+// readSubscriptionsCSV reads subscriptions from CSV with a header row naming the Subscription columns.
+// Only name is required; columns may come in any order and empty cells are unset.
+func readSubscriptionsCSV(r io.Reader) ([]Subscription, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("missing name column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var subscriptions []Subscription
+	for line, record := range records[1:] {
+		s := Subscription{
+			Name:     field(record, "name"),
+			Owner:    field(record, "owner"),
+			Currency: field(record, "currency"),
+			Renews:   field(record, "renews"),
+		}
+		invalid := func(column string) error {
+			return fmt.Errorf("line %d: invalid %s %q", line+2, column, field(record, column))
+		}
+		if value := field(record, "monthly_cost"); value != "" {
+			if s.MonthlyCost, err = strconv.ParseFloat(value, 64); err != nil {
+				return nil, invalid("monthly_cost")
+			}
+		}
+		if value := field(record, "seat_price"); value != "" {
+			if s.SeatPrice, err = strconv.ParseFloat(value, 64); err != nil {
+				return nil, invalid("seat_price")
+			}
+		}
+		if value := field(record, "seats"); value != "" {
+			if s.Seats, err = strconv.Atoi(value); err != nil {
+				return nil, invalid("seats")
+			}
+		}
+		if value := field(record, "active_users"); value != "" {
+			active, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, invalid("active_users")
+			}
+			s.ActiveUsers = &active
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, nil
+}
+
+// SubscriptionsFromViper returns the subscriptions in saas.subscriptions followed by those in the CSV
+// file at saas.file, if set.
+func SubscriptionsFromViper() ([]Subscription, error) {
+	var subscriptions []Subscription
+	if err := unmarshalConfigKey("saas.subscriptions", &subscriptions); err != nil {
+		return nil, fmt.Errorf("invalid saas.subscriptions: %w", err)
+	}
+	if path := viper.GetString("saas.file"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open saas.file: %w", err)
+		}
+		defer f.Close()
+		imported, err := readSubscriptionsCSV(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		subscriptions = append(subscriptions, imported...)
+	}
+	for i := range subscriptions {
+		if err := subscriptions[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	return subscriptions, nil
+}
+
+// SaaSReport is the result of buildSaaSReport.
+type SaaSReport struct {
+	Subscriptions []Subscription       // Largest monthly cost first
+	Renewals      map[string]time.Time // Subscription name -> next renewal, for those that have one
+	RenewalDays   int                  // Renewals within this many days are flagged
+	SeatThreshold float64              // Subscriptions with a larger fraction of unused seats are flagged
+	Today         time.Time
+	Totals        map[string]float64 // Currency -> monthly total
+	AWSMonthly    float64            // AWS spend projected to a 30-day month; 0 unless requested
+	AWSUnit       string
+}
+
+// buildSaaSReport sorts the subscriptions, totals them per currency and works out their next renewals.
+func buildSaaSReport(subscriptions []Subscription, today time.Time, renewalDays int, seatThreshold float64) (*SaaSReport, error) {
+	report := &SaaSReport{
+		Subscriptions: append([]Subscription(nil), subscriptions...),
+		Renewals:      make(map[string]time.Time),
+		RenewalDays:   renewalDays,
+		SeatThreshold: seatThreshold,
+		Today:         today,
+		Totals:        make(map[string]float64),
+	}
+	for _, s := range report.Subscriptions {
+		renewal, ok, err := s.NextRenewal(today)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			report.Renewals[s.Name] = renewal
+		}
+		report.Totals[s.Currency] += s.MonthlyCost
+	}
+	sort.SliceStable(report.Subscriptions, func(i, j int) bool {
+		return report.Subscriptions[i].MonthlyCost > report.Subscriptions[j].MonthlyCost
+	})
+	return report, nil
+}
+
+// RenewsSoon reports whether the subscription renews within RenewalDays.
+func (r *SaaSReport) RenewsSoon(name string) bool {
+	renewal, ok := r.Renewals[name]
+	return ok && renewal.Sub(r.Today) <= time.Duration(r.RenewalDays)*24*time.Hour
+}
+
+// Overprovisioned reports whether more than SeatThreshold of the subscription's paid seats are unused.
+func (r *SaaSReport) Overprovisioned(s Subscription) bool {
+	return s.UnusedSeats() > 0 && float64(s.UnusedSeats())/float64(s.Seats) > r.SeatThreshold
+}
+
+// Reminders returns one line per upcoming renewal and overprovisioned subscription, in report order.
+// Renewals of overprovisioned subscriptions say so, since that is the time to reduce seats.
+func (r *SaaSReport) Reminders() []string {
+	var reminders []string
+	for _, s := range r.Subscriptions {
+		unused := ""
+		if r.Overprovisioned(s) {
+			unused = fmt.Sprintf("%d of %d paid seats are unused (%.2f %s a month)", s.UnusedSeats(), s.Seats, s.UnusedCost(), s.Currency)
+		}
+		switch {
+		case r.RenewsSoon(s.Name) && unused != "":
+			reminders = append(reminders, fmt.Sprintf("%s renews on %s; %s, so reduce seats before then", s.Name, r.Renewals[s.Name].Format(AWSDateFormat), unused))
+		case r.RenewsSoon(s.Name):
+			reminders = append(reminders, fmt.Sprintf("%s renews on %s", s.Name, r.Renewals[s.Name].Format(AWSDateFormat)))
+		case unused != "":
+			reminders = append(reminders, fmt.Sprintf("%s: %s", s.Name, unused))
+		}
+	}
+	return reminders
+}
+
+// displaySaaSReport writes the subscriptions to w, marking those with a reminder with "!", and then
+// the reminders.
+func displaySaaSReport(w io.Writer, r *SaaSReport) {
+	fmt.Fprintln(w, "SaaS subscriptions:")
+	fmt.Fprintln(w, "=====================================")
+	if len(r.Subscriptions) == 0 {
+		fmt.Fprintln(w, "No subscriptions configured.")
+		return
+	}
+
+	fmt.Fprintf(w, "  %-30s %-16s %14s %-4s %-11s %s\n", "Subscription", "Owner", "Monthly cost", "", "Seats used", "Renews")
+	for _, s := range r.Subscriptions {
+		marker := " "
+		if r.RenewsSoon(s.Name) || r.Overprovisioned(s) {
+			marker = "!"
+		}
+		seats := ""
+		if s.ActiveUsers != nil {
+			seats = fmt.Sprintf("%d/%d", *s.ActiveUsers, s.Seats)
+		} else if s.Seats > 0 {
+			seats = fmt.Sprintf("?/%d", s.Seats)
+		}
+		renews := ""
+		if renewal, ok := r.Renewals[s.Name]; ok {
+			renews = renewal.Format(AWSDateFormat)
+		}
+		line := fmt.Sprintf("%s %-30s %-16s %14.2f %-4s %-11s %s", marker, truncateName(s.Name, 30), truncateName(s.Owner, 16), s.MonthlyCost, s.Currency, seats, renews)
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+
+	currencies := make([]string, 0, len(r.Totals))
+	for currency := range r.Totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		fmt.Fprintf(w, "  %-30s %-16s %14.2f %s\n", "Total", "", r.Totals[currency], currency)
+	}
+	if r.AWSUnit != "" {
+		fmt.Fprintf(w, "  %-30s %-16s %14.2f %s\n", "AWS", "", r.AWSMonthly, r.AWSUnit)
+		fmt.Fprintf(w, "  %-30s %-16s %14.2f %s\n", "Total engineering spend", "", r.AWSMonthly+r.Totals[r.AWSUnit], r.AWSUnit)
+	}
+	fmt.Fprintf(w, "! marks renewals in the next %d days and subscriptions with more than %.0f%% of seats unused.\n", r.RenewalDays, r.SeatThreshold*100)
+	if reminders := r.Reminders(); len(reminders) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Reminders:")
+		for _, reminder := range reminders {
+			fmt.Fprintf(w, "  %s\n", reminder)
+		}
+	}
+}
+
+var saasCmd = &cobra.Command{
+	Use:   "saas",
+	Short: "List SaaS subscriptions, their monthly cost and upcoming renewals.",
+	Long: `Lists the SaaS subscriptions in saas.subscriptions and the CSV file saas.file, largest monthly cost first,
+with their totals per currency. It flags renewals in the next saas.renewal_days days and per-seat subscriptions
+whose unused seats exceed saas.unused_seat_threshold; --notify also sends these reminders to Slack. --with-aws
+adds AWS spend over --days, projected to a 30-day month, for the total engineering spend.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		subscriptions, err := SubscriptionsFromViper()
+		if err != nil {
+			logger.Fatalw("Invalid SaaS subscriptions", "error", err)
+		}
+		report, err := buildSaaSReport(subscriptions, time.Now().UTC().Truncate(24*time.Hour),
+			viper.GetInt("saas.renewal_days"), viper.GetFloat64("saas.unused_seat_threshold"))
+		if err != nil {
+			logger.Fatalw("Invalid SaaS subscriptions", "error", err)
+		}
+
+		if withAWS, _ := cmd.Flags().GetBool("with-aws"); withAWS {
+			tracker, query, _ := setupReport(ctx)
+			costs, err := tracker.GetCosts(ctx, query)
+			if err != nil {
+				errMsg := fmt.Sprintf("Error getting costs: %v", err)
+				sendSlackNotification("Cost Tracker Error: " + errMsg)
+				logger.Fatalw("Error getting costs", "error", err)
+			}
+			totals, unit := totalsByService(costs)
+			for _, amount := range totals {
+				report.AWSMonthly += amount
+			}
+			report.AWSMonthly = report.AWSMonthly / (query.End.Sub(query.Start).Hours() / 24) * DaysPerMonth
+			report.AWSUnit = unit
+		}
+
+		logger.Info("Displaying SaaS subscriptions to console.")
+		out, done := consoleWriter()
+		displaySaaSReport(out, report)
+		done()
+
+		if notify, _ := cmd.Flags().GetBool("notify"); notify {
+			if reminders := report.Reminders(); len(reminders) > 0 {
+				sendSlackNotification("SaaS subscription reminders:\n" + strings.Join(reminders, "\n"))
+			}
+		}
+	},
+}
+
+func init() {
+	viper.SetDefault("saas.file", "")                   // CSV file of subscriptions, in addition to saas.subscriptions
+	viper.SetDefault("saas.renewal_days", 30)           // Renewals within this many days are flagged
+	viper.SetDefault("saas.unused_seat_threshold", 0.1) // Subscriptions with more than this fraction of seats unused are flagged
+
+	saasCmd.Flags().Bool("notify", false, "Send renewal and unused seat reminders to Slack")
+	saasCmd.Flags().Bool("with-aws", false, "Add AWS spend over --days, projected to a month, to the total")
+	rootCmd.AddCommand(saasCmd)
+}
@@ -0,0 +1,93 @@
+// File: saas_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadSubscriptionsCSV(t *testing.T) {
+	input := "name,monthly_cost,renews,owner,seats,seat_price,active_users\n" +
+		"Datadog,1200.50,2024-03-01,platform,,,\n" +
+		"GitHub,,,,50,21,38\n"
+	subscriptions, err := readSubscriptionsCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(subscriptions) != 2 {
+		t.Fatalf("expected 2 subscriptions, got %d", len(subscriptions))
+	}
+	if s := subscriptions[0]; s.Name != "Datadog" || s.MonthlyCost != 1200.50 || s.Renews != "2024-03-01" || s.Owner != "platform" {
+		t.Errorf("unexpected subscription: %+v", s)
+	}
+
+	if s := subscriptions[1]; s.Seats != 50 || s.SeatPrice != 21 || s.ActiveUsers == nil || *s.ActiveUsers != 38 {
+		t.Errorf("unexpected per-seat subscription: %+v", s)
+	}
+
+	if _, err := readSubscriptionsCSV(strings.NewReader("monthly_cost\n12\n")); err == nil {
+		t.Error("expected an error for a file without a name column")
+	}
+	if _, err := readSubscriptionsCSV(strings.NewReader("name,monthly_cost\nDatadog,lots\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error naming line 2, got %v", err)
+	}
+}
+
+func TestBuildSaaSReport(t *testing.T) {
+	today := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	subscriptions := []Subscription{
+		{Name: "GitHub", MonthlyCost: 400, Currency: "USD", Renews: "2023-07-01"},
+		{Name: "Datadog", MonthlyCost: 1200, Currency: "USD", Renews: "2024-12-01"},
+		{Name: "Atlassian", MonthlyCost: 300, Currency: "EUR"},
+	}
+
+	report, err := buildSaaSReport(subscriptions, today, 30, 0.1)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if report.Subscriptions[0].Name != "Datadog" {
+		t.Errorf("expected the largest subscription first, got %s", report.Subscriptions[0].Name)
+	}
+	if renewal := report.Renewals["GitHub"]; !renewal.Equal(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected a past renewal to roll forward a year, got %s", renewal)
+	}
+	if !report.RenewsSoon("GitHub") || report.RenewsSoon("Datadog") || report.RenewsSoon("Atlassian") {
+		t.Error("expected only GitHub to renew within 30 days")
+	}
+	if report.Totals["USD"] != 1600 || report.Totals["EUR"] != 300 {
+		t.Errorf("unexpected totals: %v", report.Totals)
+	}
+}
+
+func TestSaaSReminders(t *testing.T) {
+	today := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	active := func(n int) *int { return &n }
+	subscriptions := []Subscription{
+		{Name: "Figma", Seats: 50, SeatPrice: 20, ActiveUsers: active(40), Renews: "2024-07-01"},
+		{Name: "Slack", Seats: 100, SeatPrice: 8, ActiveUsers: active(95)},
+		{Name: "Linear", Seats: 20, SeatPrice: 10, ActiveUsers: active(10)},
+		{Name: "Datadog", MonthlyCost: 1200, Renews: "2024-06-20"},
+	}
+	for i := range subscriptions {
+		if err := subscriptions[i].validate(); err != nil {
+			t.Fatalf("did not expect an error, but got: %v", err)
+		}
+	}
+	if subscriptions[0].MonthlyCost != 1000 {
+		t.Errorf("expected a per-seat monthly cost of 1000, got %.2f", subscriptions[0].MonthlyCost)
+	}
+
+	report, err := buildSaaSReport(subscriptions, today, 30, 0.1)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	expected := []string{
+		"Datadog renews on 2024-06-20",
+		"Figma renews on 2024-07-01; 10 of 50 paid seats are unused (200.00 USD a month), so reduce seats before then",
+		"Linear: 10 of 20 paid seats are unused (100.00 USD a month)",
+	}
+	if got := report.Reminders(); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected reminders:\n%s", strings.Join(got, "\n"))
+	}
+}
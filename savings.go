@@ -0,0 +1,230 @@
+// File: savings.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+)
+
+const (
+	PurchaseTypeOnDemand       = "On Demand Instances"
+	PurchaseTypeSpot           = "Spot Instances"
+	UsageTypeGroupRunningHours = "EC2: Running Hours" // EC2 instance hours, whatever the purchase type
+)
+
+// SavingsLine is what one way of buying usage saved against on-demand rates.
+type SavingsLine struct {
+	Source        string
+	OnDemand      float64 // What the usage would have cost on demand
+	Actual        float64 // What it cost, amortized
+	UnpricedHours float64 // Hours without an on-demand rate to price them at, which understate the savings
+}
+
+// Savings returns how much less the usage cost than it would have on demand.
+func (l SavingsLine) Savings() float64 {
+	return l.OnDemand - l.Actual
+}
+
+// SavingsReport is the result of GetOnDemandEquivalent.
+type SavingsReport struct {
+	Days      float64
+	Unit      string
+	Lines     []SavingsLine
+	Amortized float64 // All spend matching the query, amortized
+}
+
+// Savings returns the savings of all lines.
+func (r *SavingsReport) Savings() float64 {
+	var savings float64
+	for _, l := range r.Lines {
+		savings += l.Savings()
+	}
+	return savings
+}
+
+// OnDemand returns what all spend matching the query would have cost at on-demand rates.
+func (r *SavingsReport) OnDemand() float64 {
+	return r.Amortized + r.Savings()
+}
+
+// GetOnDemandEquivalent estimates what the spend matching q would have cost at on-demand rates, against
+// its amortized cost. Savings Plan and reserved instance savings are those of GetCommitmentBenefits.
+// Spot savings are estimated by pricing each instance type's spot hours at the on-demand rate the same
+// type ran at in the query range; instance types that never ran on demand are left out. The GroupBy and
+// Metrics of q are ignored.
+func (ct *CostTracker) GetOnDemandEquivalent(ctx context.Context, q CostQuery) (*SavingsReport, error) {
+	commitments, err := ct.GetCommitmentBenefits(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	report := &SavingsReport{Days: commitments.Days, Unit: commitments.Unit}
+	commitmentLine := SavingsLine{Source: "Savings Plans and reserved instances"}
+	for _, b := range commitments.Accounts {
+		commitmentLine.OnDemand += b.OnDemand
+		commitmentLine.Actual += b.Effective
+		commitmentLine.UnpricedHours += b.UnpricedHours
+	}
+	spotLine, err := ct.spotOnDemandEquivalent(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	report.Lines = []SavingsLine{commitmentLine, spotLine}
+
+	total := q
+	total.Metrics = []string{MetricAmortizedCost}
+	total.GroupBy = nil
+	total.Granularity = GranularityMonthly
+	costs, err := ct.GetCosts(ctx, total)
+	if err != nil {
+		return nil, err
+	}
+	totals, unit := totalsByService(costs)
+	for _, amount := range totals {
+		report.Amortized += amount
+	}
+	if unit != "" {
+		report.Unit = unit
+	}
+	return report, nil
+}
+
+// instanceHours is the running hours and cost of one instance type.
+type instanceHours struct {
+	Hours float64
+	Cost  float64
+}
+
+// spotOnDemandEquivalent prices the EC2 spot hours matching q at the on-demand rate of their instance
+// types.
+func (ct *CostTracker) spotOnDemandEquivalent(ctx context.Context, q CostQuery) (SavingsLine, error) {
+	line := SavingsLine{Source: "Spot instances"}
+	input := &costexplorer.GetCostAndUsageInput{
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(q.Start.Format(AWSDateFormat)),
+			End:   aws.String(q.End.Format(AWSDateFormat)),
+		},
+		Filter: andExpression(q.Filter, &types.Expression{And: []types.Expression{
+			{Dimensions: &types.DimensionValues{Key: types.DimensionUsageTypeGroup, Values: []string{UsageTypeGroupRunningHours}}},
+			{Dimensions: &types.DimensionValues{Key: types.DimensionPurchaseType, Values: []string{PurchaseTypeOnDemand, PurchaseTypeSpot}}},
+		}}),
+		Granularity: GranularityMonthly,
+		Metrics:     []string{MetricAmortizedCost, MetricUsageQuantity},
+		GroupBy: []types.GroupDefinition{
+			{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionPurchaseType))},
+			{Type: GroupByTypeDimension, Key: aws.String(string(types.DimensionInstanceType))},
+		},
+	}
+
+	onDemand := make(map[string]instanceHours) // By instance type
+	spot := make(map[string]instanceHours)
+	for {
+		result, err := ct.client.GetCostAndUsage(ctx, input)
+		if err != nil {
+			return line, fmt.Errorf("failed to get instance hours from AWS Cost Explorer: %w", err)
+		}
+		for _, resultByTime := range result.ResultsByTime {
+			for _, group := range resultByTime.Groups {
+				if len(group.Keys) < 2 {
+					continue
+				}
+				cost, costOK := metricAmount(group.Metrics, MetricAmortizedCost)
+				hours, hoursOK := metricAmount(group.Metrics, MetricUsageQuantity)
+				if !costOK || !hoursOK {
+					logger.Warnw("Cost or hours not found for instance type",
+						"purchase_type", group.Keys[0],
+						"instance_type", group.Keys[1],
+						"periodStart", aws.ToString(resultByTime.TimePeriod.Start))
+					continue
+				}
+				byType := onDemand
+				if group.Keys[0] == PurchaseTypeSpot {
+					byType = spot
+				}
+				acc := byType[group.Keys[1]]
+				acc.Hours += hours
+				acc.Cost += cost
+				byType[group.Keys[1]] = acc
+			}
+		}
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			break
+		}
+		input.NextPageToken = result.NextPageToken
+	}
+
+	for instanceType, s := range spot {
+		od := onDemand[instanceType]
+		if od.Hours == 0 {
+			line.UnpricedHours += s.Hours
+			continue
+		}
+		line.OnDemand += s.Hours * od.Cost / od.Hours
+		line.Actual += s.Cost
+	}
+	return line, nil
+}
+
+// displaySavingsReport writes the savings of each line and what all spend would have cost on demand.
+func displaySavingsReport(w io.Writer, report *SavingsReport) {
+	fmt.Fprintf(w, "What it would have cost at on-demand rates for the last %.0f days (%s):\n", report.Days, report.Unit)
+	fmt.Fprintln(w, "=====================================")
+	fmt.Fprintf(w, "%-40s %14s %14s %14s\n", "Source", "On-demand", "Actual", "Savings")
+	var onDemand, actual, unpriced float64
+	for _, l := range report.Lines {
+		fmt.Fprintf(w, "%-40s %14.2f %14.2f %14.2f\n", l.Source, l.OnDemand, l.Actual, l.Savings())
+		onDemand += l.OnDemand
+		actual += l.Actual
+		unpriced += l.UnpricedHours
+	}
+	fmt.Fprintf(w, "%-40s %14.2f %14.2f %14.2f\n", "Total", onDemand, actual, onDemand-actual)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%-40s %14.2f\n", "All spend, amortized", report.Amortized)
+	fmt.Fprintf(w, "%-40s %14.2f\n", "All spend at on-demand rates", report.OnDemand())
+	if report.OnDemand() > 0 {
+		fmt.Fprintf(w, "Commitments and spot saved %.1f%% of what the spend would have cost on demand.\n", report.Savings()/report.OnDemand()*100)
+	}
+	if math.Round(unpriced) > 0 {
+		fmt.Fprintf(w, "%.0f hours of instance types that never ran on demand could not be priced, so savings are understated.\n", unpriced)
+	}
+}
+
+var savingsCmd = &cobra.Command{
+	Use:   "savings",
+	Short: "Estimate what spend would have cost at on-demand rates, and what commitments and spot saved.",
+	Long: `Compares the amortized cost of all spend with what it would have cost at on-demand rates, split into the savings
+of Savings Plans and reserved instances, as the commitments command attributes them, and of EC2 spot instances.
+Spot hours are priced at the on-demand rate the same instance types ran at in the period, so instance types that
+never ran on demand are left out and counted separately. Credits and negotiated discounts are not counted as
+savings:
+
+  cost-tracker savings --period last-fiscal-quarter`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		tracker, query, _ := setupReport(ctx)
+		report, err := tracker.GetOnDemandEquivalent(ctx, query)
+		if err != nil {
+			errMsg := fmt.Sprintf("Error estimating on-demand equivalent cost: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error estimating on-demand equivalent cost", "error", err)
+		}
+
+		logger.Info("Displaying on-demand equivalent cost to console.")
+		out, done := consoleWriter()
+		displaySavingsReport(out, report)
+		done()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(savingsCmd)
+}
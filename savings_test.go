@@ -0,0 +1,84 @@
+// File: savings_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGetOnDemandEquivalent(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	metrics := func(values map[string]string) map[string]types.MetricValue {
+		m := make(map[string]types.MetricValue)
+		for name, amount := range values {
+			m[name] = types.MetricValue{Amount: aws.String(amount), Unit: aws.String("USD")}
+		}
+		return m
+	}
+	result := func(groups ...types.Group) *costexplorer.GetCostAndUsageOutput {
+		return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []types.ResultByTime{{
+			TimePeriod: &types.DateInterval{Start: aws.String("2024-01-01"), End: aws.String("2024-01-31")},
+			Groups:     groups,
+		}}}
+	}
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			switch {
+			case len(params.GroupBy) == 2 && aws.ToString(params.GroupBy[1].Key) == string(types.DimensionRecordType):
+				return result(types.Group{
+					Keys:    []string{"111111111111", RecordTypeSavingsPlanCoveredUsage},
+					Metrics: metrics(map[string]string{MetricUnblendedCost: "100", MetricAmortizedCost: "60"}),
+				}), nil
+			case len(params.GroupBy) == 2 && aws.ToString(params.GroupBy[0].Key) == string(types.DimensionPurchaseType):
+				// m5.large runs at 0.1 an hour on demand; c5.large only ran on spot.
+				return result(
+					types.Group{Keys: []string{PurchaseTypeOnDemand, "m5.large"}, Metrics: metrics(map[string]string{MetricAmortizedCost: "10", MetricUsageQuantity: "100"})},
+					types.Group{Keys: []string{PurchaseTypeSpot, "m5.large"}, Metrics: metrics(map[string]string{MetricAmortizedCost: "6", MetricUsageQuantity: "200"})},
+					types.Group{Keys: []string{PurchaseTypeSpot, "c5.large"}, Metrics: metrics(map[string]string{MetricAmortizedCost: "3", MetricUsageQuantity: "70"})},
+				), nil
+			default:
+				if len(params.Metrics) != 1 || params.Metrics[0] != MetricAmortizedCost {
+					t.Errorf("expected total spend to be amortized, got %v", params.Metrics)
+				}
+				return result(
+					types.Group{Keys: []string{"Amazon EC2"}, Metrics: metrics(map[string]string{MetricAmortizedCost: "500"})},
+					types.Group{Keys: []string{"Amazon S3"}, Metrics: metrics(map[string]string{MetricAmortizedCost: "100"})},
+				), nil
+			}
+		},
+	}}
+	q := CostQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	report, err := tracker.GetOnDemandEquivalent(context.Background(), q)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(report.Lines) != 2 || report.Lines[0].Savings() != 40 {
+		t.Fatalf("expected a commitment saving of 40, got %+v", report.Lines)
+	}
+	if spot := report.Lines[1]; math.Abs(spot.OnDemand-20) > 1e-9 || spot.Actual != 6 || spot.UnpricedHours != 70 {
+		t.Errorf("expected 200 spot hours priced at 0.1 against 6, and 70 unpriced hours, got %+v", spot)
+	}
+	if report.Amortized != 600 || math.Abs(report.OnDemand()-654) > 1e-9 {
+		t.Errorf("expected 600 amortized and 654 on demand, got %.2f and %.2f", report.Amortized, report.OnDemand())
+	}
+
+	var buf bytes.Buffer
+	displaySavingsReport(&buf, report)
+	if !strings.Contains(buf.String(), "saved 8.3%") || !strings.Contains(buf.String(), "70 hours") {
+		t.Errorf("unexpected report:\n%s", buf.String())
+	}
+}
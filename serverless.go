@@ -0,0 +1,121 @@
+// File: serverless.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+const (
+	ServerlessCategoryLambdaCompute  = "Lambda: compute"
+	ServerlessCategoryLambdaRequests = "Lambda: requests"
+	InvocationsPerUnitCost           = 1e6 // Unit costs are quoted per million invocations
+)
+
+// serverlessDefinition covers Lambda, API Gateway, Step Functions and EventBridge.
+// EventBridge is still reported as "CloudWatch Events" on older bills, so both names are queried.
+var serverlessDefinition = SpotlightDefinition{
+	Title:    "Serverless cost breakdown",
+	Services: []string{"AWS Lambda", "Amazon API Gateway", "AWS Step Functions", "Amazon EventBridge", "CloudWatch Events"},
+	Categories: []SpotlightCategory{
+		{Name: "API Gateway: requests", Pattern: regexp.MustCompile(`ApiGateway(Http)?Request`)},
+		{Name: "API Gateway: WebSocket", Pattern: regexp.MustCompile(`ApiGateway(Message|Minute)`)},
+		{Name: "API Gateway: cache", Pattern: regexp.MustCompile(`ApiGatewayCacheUsage`)},
+		{Name: "Step Functions: state transitions", Pattern: regexp.MustCompile(`StateTransition`)},
+		{Name: "Step Functions: express workflows", Pattern: regexp.MustCompile(`StepFunctions-(Request|GB-Second)`)},
+		{Name: "EventBridge: events", Pattern: regexp.MustCompile(`Event`)},
+		{Name: ServerlessCategoryLambdaCompute, Pattern: regexp.MustCompile(`Lambda-(Provisioned-)?GB-Second`)},
+		{Name: "Lambda: provisioned concurrency", Pattern: regexp.MustCompile(`Lambda-Provisioned-Concurrency`)},
+		{Name: "Lambda: ephemeral storage", Pattern: regexp.MustCompile(`Lambda-Storage`)},
+		{Name: ServerlessCategoryLambdaRequests, Pattern: regexp.MustCompile(`(^|-)Request(-ARM)?$`)},
+	},
+	Other: "Other serverless usage",
+}
+
+// serverlessInvocationCategories are the categories whose usage quantity counts invocations.
+var serverlessInvocationCategories = []string{
+	ServerlessCategoryLambdaRequests,
+	"API Gateway: requests",
+	"Step Functions: state transitions",
+	"EventBridge: events",
+}
+
+// costPerMillion returns the cost per million invocations, or false if there were none.
+func costPerMillion(cost, invocations float64) (float64, bool) {
+	if invocations == 0 {
+		return 0, false
+	}
+	return cost / invocations * InvocationsPerUnitCost, true
+}
+
+// formatUnitCost renders a unit cost for a report section, with "n/a" when there were no invocations.
+func formatUnitCost(cost, invocations float64) string {
+	if unitCost, ok := costPerMillion(cost, invocations); ok {
+		return fmt.Sprintf("%.4f", unitCost)
+	}
+	return "n/a"
+}
+
+// lambdaAllInCost returns the compute and request cost of Lambda and its invocation count.
+func lambdaAllInCost(report *SpotlightReport) (cost, invocations float64) {
+	if line := report.Line(ServerlessCategoryLambdaCompute); line != nil {
+		cost += line.Cost
+	}
+	if line := report.Line(ServerlessCategoryLambdaRequests); line != nil {
+		cost += line.Cost
+		invocations = line.Quantity
+	}
+	return cost, invocations
+}
+
+// annotateUnitCosts adds cost per million invocations for the window and the preceding window of
+// the same length, so cost-per-invocation trends are visible without a separate query.
+func annotateUnitCosts(ctx context.Context, tracker *CostTracker, q CostQuery, report *SpotlightReport) error {
+	window := q.End.Sub(q.Start)
+	previousQuery := q
+	previousQuery.Start, previousQuery.End = q.Start.Add(-window), q.Start
+	previous, err := tracker.GetSpotlight(ctx, previousQuery, serverlessDefinition)
+	if err != nil {
+		return fmt.Errorf("failed to get previous period for comparison: %w", err)
+	}
+
+	section := ReportSection{
+		Title:  fmt.Sprintf("Cost per million invocations (in %s)", report.Unit),
+		Header: []string{"Category", "Invocations", "Current", "Previous"},
+	}
+	for _, category := range serverlessInvocationCategories {
+		line := report.Line(category)
+		if line == nil {
+			continue
+		}
+		prevCost, prevInvocations := 0.0, 0.0
+		if prev := previous.Line(category); prev != nil {
+			prevCost, prevInvocations = prev.Cost, prev.Quantity
+		}
+		section.Rows = append(section.Rows, []string{category, fmt.Sprintf("%.0f", line.Quantity),
+			formatUnitCost(line.Cost, line.Quantity), formatUnitCost(prevCost, prevInvocations)})
+	}
+
+	cost, invocations := lambdaAllInCost(report)
+	prevCost, prevInvocations := lambdaAllInCost(previous)
+	if invocations > 0 {
+		section.Rows = append(section.Rows, []string{"Lambda: all-in (compute + requests)", fmt.Sprintf("%.0f", invocations),
+			formatUnitCost(cost, invocations), formatUnitCost(prevCost, prevInvocations)})
+	}
+
+	if len(section.Rows) == 0 {
+		report.Notes = append(report.Notes, "No invocation usage found; unit costs are unavailable.")
+		return nil
+	}
+	report.Sections = append(report.Sections, section)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(newSpotlightCommand("serverless",
+		"Break down Lambda, API Gateway, Step Functions and EventBridge costs.",
+		`Splits serverless spend into Lambda compute and requests, API Gateway, Step Functions and EventBridge usage,
+and derives the cost per million invocations for this period and the one before it.`,
+		serverlessDefinition, annotateUnitCosts))
+}
@@ -0,0 +1,99 @@
+// File: serverless_test.go
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServerlessDefinition(t *testing.T) {
+	testCases := map[string]string{
+		"Lambda-GB-Second":               "Lambda: compute",
+		"APS2-Lambda-GB-Second-ARM":      "Lambda: compute",
+		"Lambda-Provisioned-GB-Second":   "Lambda: compute",
+		"Lambda-Provisioned-Concurrency": "Lambda: provisioned concurrency",
+		"USW2-Lambda-Storage-GB-Second":  "Lambda: ephemeral storage",
+		"Request":                        "Lambda: requests",
+		"APS2-Request-ARM":               "Lambda: requests",
+		"USE1-ApiGatewayRequest":         "API Gateway: requests",
+		"ApiGatewayHttpRequest":          "API Gateway: requests",
+		"ApiGatewayMessage":              "API Gateway: WebSocket",
+		"ApiGatewayCacheUsage:0.5GB":     "API Gateway: cache",
+		"USE1-StateTransition":           "Step Functions: state transitions",
+		"StepFunctions-GB-Second":        "Step Functions: express workflows",
+		"USE1-Event-64K-Chunks":          "EventBridge: events",
+		"APS2-DataTransfer-Out-Bytes":    "Other serverless usage",
+	}
+	for usageType, expected := range testCases {
+		lines := classifyUsage([]UsageTypeCost{{UsageType: usageType, Cost: 1}}, serverlessDefinition)
+		got := ""
+		if len(lines) == 1 {
+			got = lines[0].Category
+		}
+		if got != expected {
+			t.Errorf("usage type %q classified as %q, expected %q", usageType, got, expected)
+		}
+	}
+}
+
+func TestAnnotateUnitCosts(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	q := CostQuery{
+		Start: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC),
+	}
+	mockClient := &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			// Only the preceding window is queried; it must end where the current one starts.
+			if aws.ToString(params.TimePeriod.Start) != "2024-01-01" || aws.ToString(params.TimePeriod.End) != "2024-01-15" {
+				t.Errorf("unexpected comparison window %s - %s", aws.ToString(params.TimePeriod.Start), aws.ToString(params.TimePeriod.End))
+			}
+			return &costexplorer.GetCostAndUsageOutput{
+				ResultsByTime: []types.ResultByTime{{Groups: []types.Group{
+					usageGroup("Request", "0.40", "2000000", "Requests"),
+					usageGroup("Lambda-GB-Second", "3.60", "216000", "Lambda-GB-Second"),
+				}}},
+			}, nil
+		},
+	}
+	tracker := &CostTracker{client: mockClient}
+	report := &SpotlightReport{
+		Unit: "USD",
+		Lines: []SpotlightLine{
+			{Category: ServerlessCategoryLambdaCompute, Cost: 6, Quantity: 360000, QuantityUnit: "Lambda-GB-Second"},
+			{Category: ServerlessCategoryLambdaRequests, Cost: 0.6, Quantity: 3000000, QuantityUnit: "Requests"},
+			{Category: "API Gateway: requests", Cost: 3.5, Quantity: 1000000, QuantityUnit: "Requests"},
+		},
+	}
+
+	if err := annotateUnitCosts(context.Background(), tracker, q, report); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(report.Sections) != 1 {
+		t.Fatalf("expected a unit cost section, got %d", len(report.Sections))
+	}
+
+	expected := [][]string{
+		{"Lambda: requests", "3000000", "0.2000", "0.2000"},
+		{"API Gateway: requests", "1000000", "3.5000", "n/a"},
+		{"Lambda: all-in (compute + requests)", "3000000", "2.2000", "2.0000"},
+	}
+	rows := report.Sections[0].Rows
+	if len(rows) != len(expected) {
+		t.Fatalf("expected %d rows, got %v", len(expected), rows)
+	}
+	for i, row := range expected {
+		for j, cell := range row {
+			if rows[i][j] != cell {
+				t.Errorf("row %d column %d: expected %q, got %q", i, j, cell, rows[i][j])
+			}
+		}
+	}
+}
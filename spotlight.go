@@ -0,0 +1,332 @@
+// File: spotlight.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+)
+
+const (
+	MetricUsageQuantity = "UsageQuantity" // Metric for the amount of usage, in the usage type's own unit
+	GroupByUsageTypeKey = "USAGE_TYPE"    // Key for grouping by usage type
+)
+
+// UsageTypeCost is the cost and usage quantity of a single usage type over a query range.
+type UsageTypeCost struct {
+	UsageType    string
+	Cost         float64
+	Unit         string
+	Quantity     float64
+	QuantityUnit string
+}
+
+// GetUsageByType retrieves cost and usage quantity grouped by usage type, summed over the query range.
+// The GroupBy of q is ignored; results are always grouped by USAGE_TYPE.
+func (ct *CostTracker) GetUsageByType(ctx context.Context, q CostQuery) ([]UsageTypeCost, error) {
+	if !q.Start.Before(q.End) {
+		return nil, fmt.Errorf("start date %s must be before end date %s", q.Start.Format(AWSDateFormat), q.End.Format(AWSDateFormat))
+	}
+
+	input := &costexplorer.GetCostAndUsageInput{
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(q.Start.Format(AWSDateFormat)),
+			End:   aws.String(q.End.Format(AWSDateFormat)),
+		},
+		Filter:      q.Filter,
+		Granularity: GranularityMonthly,
+		Metrics:     []string{MetricBlendedCost, MetricUsageQuantity},
+		GroupBy: []types.GroupDefinition{
+			{Type: GroupByTypeDimension, Key: aws.String(GroupByUsageTypeKey)},
+		},
+	}
+
+	var usage []UsageTypeCost
+	index := make(map[string]int)
+	for {
+		result, err := ct.client.GetCostAndUsage(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get usage data from AWS Cost Explorer: %w", err)
+		}
+		for _, resultByTime := range result.ResultsByTime {
+			for _, group := range resultByTime.Groups {
+				if len(group.Keys) == 0 {
+					continue
+				}
+				usageType := group.Keys[0]
+				cost, costOK := metricAmount(group.Metrics, MetricBlendedCost)
+				quantity, quantityOK := metricAmount(group.Metrics, MetricUsageQuantity)
+				if !costOK {
+					logger.Warnw("Metric not found or incomplete for usage type",
+						"metric", MetricBlendedCost,
+						"usageType", usageType)
+					continue
+				}
+				i, ok := index[usageType]
+				if !ok {
+					i = len(usage)
+					index[usageType] = i
+					usage = append(usage, UsageTypeCost{UsageType: usageType})
+				}
+				usage[i].Cost += cost
+				usage[i].Unit = aws.ToString(group.Metrics[MetricBlendedCost].Unit)
+				if quantityOK {
+					usage[i].Quantity += quantity
+					usage[i].QuantityUnit = aws.ToString(group.Metrics[MetricUsageQuantity].Unit)
+				}
+			}
+		}
+		if result.NextPageToken == nil || *result.NextPageToken == "" {
+			break
+		}
+		input.NextPageToken = result.NextPageToken
+	}
+	return usage, nil
+}
+
+// metricAmount parses the named metric's amount, reporting false if it is missing or malformed.
+func metricAmount(metrics map[string]types.MetricValue, name string) (float64, bool) {
+	metric, ok := metrics[name]
+	if !ok || metric.Amount == nil {
+		return 0, false
+	}
+	amount, err := strconv.ParseFloat(*metric.Amount, 64)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
+
+// SpotlightCategory is one line of a spotlight report, matching usage types by regular expression.
+type SpotlightCategory struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// SpotlightDefinition describes a report that isolates a slice of spend and breaks it down by usage type.
+type SpotlightDefinition struct {
+	Title      string
+	Services   []string            // SERVICE values the report is restricted to
+	Categories []SpotlightCategory // Ordered; the first matching category wins
+	Other      string              // Name of the catch-all line for unmatched usage; empty drops it
+}
+
+// SpotlightLine is the cost and usage of one category.
+type SpotlightLine struct {
+	Category     string
+	Cost         float64
+	Quantity     float64
+	QuantityUnit string // Empty when the category mixes usage types with different units
+	UsageTypes   []UsageTypeCost
+}
+
+// SpotlightReport is the result of GetSpotlight.
+type SpotlightReport struct {
+	Title    string
+	Days     float64
+	Unit     string
+	Lines    []SpotlightLine
+	Sections []ReportSection // Report-specific tables printed after the category table
+	Notes    []string        // Report-specific observations printed last
+}
+
+// ReportSection is an additional table in a report. The first column is a label; the rest are values.
+type ReportSection struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+}
+
+// Total returns the summed cost of every line.
+func (r *SpotlightReport) Total() float64 {
+	var total float64
+	for _, line := range r.Lines {
+		total += line.Cost
+	}
+	return total
+}
+
+// Line returns the line for a category, or nil if it has no usage.
+func (r *SpotlightReport) Line(category string) *SpotlightLine {
+	for i := range r.Lines {
+		if r.Lines[i].Category == category {
+			return &r.Lines[i]
+		}
+	}
+	return nil
+}
+
+// mixedUnits marks a category whose usage types report quantities in different units.
+const mixedUnits = "\x00mixed"
+
+// classifyUsage groups usage types into the definition's categories, in category order.
+func classifyUsage(usage []UsageTypeCost, def SpotlightDefinition) []SpotlightLine {
+	names := make([]string, 0, len(def.Categories)+1)
+	for _, category := range def.Categories {
+		names = append(names, category.Name)
+	}
+	if def.Other != "" {
+		names = append(names, def.Other)
+	}
+
+	lines := make(map[string]*SpotlightLine)
+	for _, u := range usage {
+		name := def.Other
+		for _, category := range def.Categories {
+			if category.Pattern.MatchString(u.UsageType) {
+				name = category.Name
+				break
+			}
+		}
+		if name == "" {
+			continue
+		}
+		line, ok := lines[name]
+		if !ok {
+			line = &SpotlightLine{Category: name, QuantityUnit: u.QuantityUnit}
+			lines[name] = line
+		}
+		line.Cost += u.Cost
+		line.Quantity += u.Quantity
+		if line.QuantityUnit != u.QuantityUnit {
+			line.QuantityUnit = mixedUnits
+		}
+		line.UsageTypes = append(line.UsageTypes, u)
+	}
+
+	var ordered []SpotlightLine
+	for _, name := range names {
+		if line, ok := lines[name]; ok {
+			if line.QuantityUnit == mixedUnits {
+				line.QuantityUnit = ""
+			}
+			ordered = append(ordered, *line)
+		}
+	}
+	return ordered
+}
+
+// GetSpotlight runs a spotlight report for the definition over the query range.
+func (ct *CostTracker) GetSpotlight(ctx context.Context, q CostQuery, def SpotlightDefinition) (*SpotlightReport, error) {
+	q.Filter = andExpression(q.Filter, &types.Expression{
+		Dimensions: &types.DimensionValues{Key: types.DimensionService, Values: def.Services},
+	})
+	usage, err := ct.GetUsageByType(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SpotlightReport{
+		Title: def.Title,
+		Days:  q.End.Sub(q.Start).Hours() / 24,
+		Lines: classifyUsage(usage, def),
+	}
+	for _, u := range usage {
+		if u.Unit != "" {
+			report.Unit = u.Unit
+			break
+		}
+	}
+	return report, nil
+}
+
+// displaySpotlightReport writes a spotlight report to w, one line per category.
+func displaySpotlightReport(w io.Writer, report *SpotlightReport) {
+	fmt.Fprintf(w, "%s for the last %.0f days:\n", report.Title, report.Days)
+	fmt.Fprintln(w, "=====================================")
+	if len(report.Lines) == 0 {
+		fmt.Fprintln(w, "No matching costs found for the specified period.")
+		return
+	}
+
+	fmt.Fprintf(w, "%-40s %14s %18s\n", "Category", "Cost", "Quantity")
+	for _, line := range report.Lines {
+		quantity := ""
+		if line.QuantityUnit != "" {
+			quantity = fmt.Sprintf("%.2f %s", line.Quantity, line.QuantityUnit)
+		}
+		fmt.Fprintf(w, "%-40s %14.2f %18s\n", line.Category, line.Cost, quantity)
+	}
+	fmt.Fprintf(w, "%-40s %14.2f\n", "Total", report.Total())
+	fmt.Fprintf(w, "Amounts in %s.\n", report.Unit)
+	for _, section := range report.Sections {
+		displayReportSection(w, section)
+	}
+	for _, note := range report.Notes {
+		fmt.Fprintln(w, note)
+	}
+}
+
+// newSpotlightCommand builds a report command that runs a spotlight definition.
+// annotate, if non-nil, can add report-specific sections and notes before the report is displayed.
+func newSpotlightCommand(use, short, long string, def SpotlightDefinition, annotate func(ctx context.Context, tracker *CostTracker, q CostQuery, report *SpotlightReport) error) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long:  long,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+
+			tracker, query, _ := setupReport(ctx)
+			report, err := tracker.GetSpotlight(ctx, query, def)
+			if err == nil && annotate != nil {
+				err = annotate(ctx, tracker, query, report)
+			}
+			if err != nil {
+				errMsg := fmt.Sprintf("Error getting %s report: %v", use, err)
+				sendSlackNotification("Cost Tracker Error: " + errMsg)
+				logger.Fatalw("Error getting report", "report", use, "error", err)
+			}
+
+			logger.Infow("Displaying report to console.", "report", use)
+			out, done := consoleWriter()
+			displaySpotlightReport(out, report)
+			done()
+		},
+	}
+}
+
+// displayReportSection writes an additional report table to w. Columns widen to fit their longest cell.
+func displayReportSection(w io.Writer, section ReportSection) {
+	widths := make([]int, len(section.Header))
+	for i := range widths {
+		widths[i] = 14
+	}
+	if len(widths) > 0 {
+		widths[0] = 40
+	}
+	for _, row := range append([][]string{section.Header}, section.Rows...) {
+		for i, cell := range row {
+			if i < len(widths) && len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n%s:\n", section.Title)
+	writeRow := func(row []string) {
+		for i, cell := range row {
+			if i == 0 {
+				fmt.Fprintf(w, "%-*s", widths[i], cell)
+				continue
+			}
+			fmt.Fprintf(w, " %*s", widths[i], cell)
+		}
+		fmt.Fprintln(w)
+	}
+	writeRow(section.Header)
+	shown, hidden := rowLimit(len(section.Rows))
+	for _, row := range section.Rows[:shown] {
+		writeRow(row)
+	}
+	writeHiddenRows(w, hidden)
+}
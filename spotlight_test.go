@@ -0,0 +1,135 @@
+// File: spotlight_test.go
+package main
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+// usageGroup builds a Cost Explorer group for a usage type with cost and quantity metrics.
+func usageGroup(usageType, cost, quantity, quantityUnit string) types.Group {
+	return types.Group{
+		Keys: []string{usageType},
+		Metrics: map[string]types.MetricValue{
+			MetricBlendedCost:   {Amount: aws.String(cost), Unit: aws.String("USD")},
+			MetricUsageQuantity: {Amount: aws.String(quantity), Unit: aws.String(quantityUnit)},
+		},
+	}
+}
+
+func TestGetUsageByType(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	mockClient := &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			if len(params.GroupBy) != 1 || aws.ToString(params.GroupBy[0].Key) != GroupByUsageTypeKey {
+				t.Errorf("expected grouping by USAGE_TYPE, got %+v", params.GroupBy)
+			}
+			if len(params.Metrics) != 2 {
+				t.Errorf("expected cost and quantity metrics, got %v", params.Metrics)
+			}
+			return &costexplorer.GetCostAndUsageOutput{
+				ResultsByTime: []types.ResultByTime{
+					{Groups: []types.Group{usageGroup("EBS:VolumeUsage.gp2", "10", "100", "GB-Mo")}},
+					{Groups: []types.Group{
+						usageGroup("EBS:VolumeUsage.gp2", "5", "50", "GB-Mo"),
+						{Keys: []string{"broken"}, Metrics: map[string]types.MetricValue{}},
+					}},
+				},
+			}, nil
+		},
+	}
+	tracker := &CostTracker{client: mockClient}
+
+	usage, err := tracker.GetUsageByType(context.Background(), lastNDays(30))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(usage) != 1 {
+		t.Fatalf("expected 1 usage type (incomplete metrics skipped), got %d", len(usage))
+	}
+	if usage[0].Cost != 15 || usage[0].Quantity != 150 || usage[0].QuantityUnit != "GB-Mo" {
+		t.Errorf("expected periods to be summed to 15 USD / 150 GB-Mo, got %+v", usage[0])
+	}
+}
+
+func TestGetUsageByTypeFollowsPages(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	var requests int
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			requests++
+			if params.NextPageToken == nil {
+				return &costexplorer.GetCostAndUsageOutput{
+					ResultsByTime: []types.ResultByTime{{Groups: []types.Group{usageGroup("EBS:VolumeUsage.gp2", "10", "100", "GB-Mo")}}},
+					NextPageToken: aws.String("page-2"),
+				}, nil
+			}
+			return &costexplorer.GetCostAndUsageOutput{
+				ResultsByTime: []types.ResultByTime{{Groups: []types.Group{
+					usageGroup("EBS:VolumeUsage.gp2", "5", "50", "GB-Mo"),
+					usageGroup("EBS:SnapshotUsage", "2", "40", "GB-Mo"),
+				}}},
+			}, nil
+		},
+	}}
+
+	usage, err := tracker.GetUsageByType(context.Background(), lastNDays(30))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+	if len(usage) != 2 || usage[0].Cost != 15 || usage[1].UsageType != "EBS:SnapshotUsage" {
+		t.Errorf("expected usage types from both pages, got %+v", usage)
+	}
+}
+
+func TestClassifyUsage(t *testing.T) {
+	def := SpotlightDefinition{
+		Categories: []SpotlightCategory{
+			{Name: "specific", Pattern: regexp.MustCompile(`Storage\.special$`)},
+			{Name: "storage", Pattern: regexp.MustCompile(`Storage`)},
+		},
+		Other: "other",
+	}
+	usage := []UsageTypeCost{
+		{UsageType: "misc", Cost: 1},
+		{UsageType: "USE1-Storage", Cost: 2, Quantity: 20, QuantityUnit: "GB-Mo"},
+		{UsageType: "Storage.special", Cost: 3, Quantity: 30, QuantityUnit: "GB-Mo"},
+		{UsageType: "APS2-Storage", Cost: 4, Quantity: 4, QuantityUnit: "Hrs"},
+	}
+
+	lines := classifyUsage(usage, def)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %+v", lines)
+	}
+	expected := []struct {
+		category string
+		cost     float64
+		unit     string
+	}{
+		{"specific", 3, "GB-Mo"},
+		{"storage", 6, ""}, // GB-Mo and Hrs cannot be added up
+		{"other", 1, ""},
+	}
+	for i, want := range expected {
+		if lines[i].Category != want.category || math.Abs(lines[i].Cost-want.cost) > 1e-9 || lines[i].QuantityUnit != want.unit {
+			t.Errorf("line %d: expected %+v, got %+v", i, want, lines[i])
+		}
+	}
+
+	def.Other = ""
+	if lines := classifyUsage(usage, def); len(lines) != 2 {
+		t.Errorf("expected unmatched usage to be dropped without an Other line, got %+v", lines)
+	}
+}
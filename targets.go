@@ -0,0 +1,120 @@
+// File: targets.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/viper"
+)
+
+// StdinPath is the --accounts-from and --tag-values-from path that reads from standard input.
+const StdinPath = "-"
+
+// readTargetList reads a list of values from the file at path, or from stdin if path is "-". Values are
+// separated by newlines, commas or spaces; lines starting with '#' are comments. Duplicates are dropped.
+func readTargetList(path string, stdin io.Reader) ([]string, error) {
+	r := stdin
+	if path != StdinPath {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var values []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, value := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			if !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values in %s", describeTargetPath(path))
+	}
+	return values, nil
+}
+
+// describeTargetPath names path in errors.
+func describeTargetPath(path string) string {
+	if path == StdinPath {
+		return "standard input"
+	}
+	return path
+}
+
+// accountIDFromTarget returns the account ID of an account ID or an ARN such as a role's,
+// arn:aws:iam::111111111111:role/ops.
+func accountIDFromTarget(target string) (string, error) {
+	if isAccountID(target) {
+		return target, nil
+	}
+	if parts := strings.SplitN(target, ":", 6); len(parts) == 6 && parts[0] == "arn" && isAccountID(parts[4]) {
+		return parts[4], nil
+	}
+	return "", fmt.Errorf("%q is neither a 12-digit account ID nor an ARN with an account ID", target)
+}
+
+// targetFilterFromConfig returns the filter selecting the accounts listed by --accounts-from and the
+// tag values listed by --tag-values-from, given as KEY=PATH, or nil if neither is set.
+func targetFilterFromConfig(stdin io.Reader) (*types.Expression, error) {
+	accountsPath := viper.GetString("accounts_from")
+	tagSpec := viper.GetString("tag_values_from")
+	var tagKey, tagPath string
+	if tagSpec != "" {
+		var ok bool
+		if tagKey, tagPath, ok = strings.Cut(tagSpec, "="); !ok || tagKey == "" || tagPath == "" {
+			return nil, fmt.Errorf("--tag-values-from must be KEY=PATH, e.g. team=-, got %q", tagSpec)
+		}
+	}
+	if accountsPath == StdinPath && tagPath == StdinPath {
+		return nil, fmt.Errorf("--accounts-from and --tag-values-from cannot both read standard input")
+	}
+
+	var filter *types.Expression
+	if accountsPath != "" {
+		targets, err := readTargetList(accountsPath, stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read accounts: %w", err)
+		}
+		var accounts []string
+		seen := make(map[string]bool)
+		for _, target := range targets {
+			account, err := accountIDFromTarget(target)
+			if err != nil {
+				return nil, fmt.Errorf("invalid account in %s: %w", describeTargetPath(accountsPath), err)
+			}
+			if !seen[account] { // Several ARNs can be in one account
+				seen[account] = true
+				accounts = append(accounts, account)
+			}
+		}
+		filter = &types.Expression{Dimensions: &types.DimensionValues{Key: types.DimensionLinkedAccount, Values: accounts}}
+	}
+	if tagPath != "" {
+		values, err := readTargetList(tagPath, stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values of tag %s: %w", tagKey, err)
+		}
+		filter = andExpression(filter, &types.Expression{Tags: &types.TagValues{Key: aws.String(tagKey), Values: values}})
+	}
+	return filter, nil
+}
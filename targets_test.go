@@ -0,0 +1,82 @@
+// File: targets_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestReadTargetList(t *testing.T) {
+	stdin := strings.NewReader("# prod accounts\n111111111111, 222222222222\n\n333333333333 111111111111\n")
+	values, err := readTargetList(StdinPath, stdin)
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if strings.Join(values, ",") != "111111111111,222222222222,333333333333" {
+		t.Errorf("unexpected values %v", values)
+	}
+
+	path := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(path, []byte("# nothing yet\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readTargetList(path, nil); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("expected an error naming the empty file, got %v", err)
+	}
+}
+
+func TestAccountIDFromTarget(t *testing.T) {
+	for target, want := range map[string]string{
+		"111111111111":                            "111111111111",
+		"arn:aws:iam::222222222222:role/ops":      "222222222222",
+		"arn:aws:ec2:eu-west-1:333333333333:vpc/": "333333333333",
+	} {
+		if got, err := accountIDFromTarget(target); err != nil || got != want {
+			t.Errorf("accountIDFromTarget(%q) = %q, %v; want %q", target, got, err, want)
+		}
+	}
+	if _, err := accountIDFromTarget("arn:aws:s3:::my-bucket"); err == nil {
+		t.Error("expected an error for an ARN without an account ID")
+	}
+}
+
+func TestTargetFilterFromConfig(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Set("accounts_from", "")
+		viper.Set("tag_values_from", "")
+	})
+	path := filepath.Join(t.TempDir(), "teams.txt")
+	if err := os.WriteFile(path, []byte("platform\ndata\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set("accounts_from", StdinPath)
+	viper.Set("tag_values_from", "team="+path)
+	filter, err := targetFilterFromConfig(strings.NewReader("arn:aws:iam::111111111111:role/a\narn:aws:iam::111111111111:role/b\n"))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(filter.And) != 2 {
+		t.Fatalf("expected accounts and tag values to be ANDed, got %+v", filter)
+	}
+	if accounts := filter.And[0].Dimensions.Values; len(accounts) != 1 || accounts[0] != "111111111111" {
+		t.Errorf("expected one account, got %v", accounts)
+	}
+	if tags := filter.And[1].Tags; *tags.Key != "team" || strings.Join(tags.Values, ",") != "platform,data" {
+		t.Errorf("unexpected tag filter %+v", tags)
+	}
+
+	viper.Set("tag_values_from", "team=-")
+	if _, err := targetFilterFromConfig(strings.NewReader("")); err == nil {
+		t.Error("expected an error when both lists read standard input")
+	}
+	viper.Set("accounts_from", "")
+	viper.Set("tag_values_from", "team")
+	if _, err := targetFilterFromConfig(nil); err == nil {
+		t.Error("expected an error for --tag-values-from without a path")
+	}
+}
@@ -0,0 +1,64 @@
+// File: unavailable.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+// ErrCostExplorerNotEnabled is returned when the account has no Cost Explorer data, because Cost
+// Explorer was never enabled or was enabled less than a day ago.
+var ErrCostExplorerNotEnabled = errors.New("Cost Explorer is not enabled for this account, or its data is still being prepared")
+
+// notEnabledHint tells the operator how to resolve ErrCostExplorerNotEnabled.
+const notEnabledHint = "enable Cost Explorer from the Billing and Cost Management console of the management account; data is available up to 24 hours later, so a scheduled run will succeed on its own once it is ready"
+
+// notEnabledError wraps a DataUnavailableException in ErrCostExplorerNotEnabled and leaves other
+// errors as they are.
+func notEnabledError(err error) error {
+	var unavailable *types.DataUnavailableException
+	if errors.As(err, &unavailable) {
+		return fmt.Errorf("%w (%s): %w", ErrCostExplorerNotEnabled, notEnabledHint, err)
+	}
+	return err
+}
+
+// notEnabledClient reports DataUnavailableException as ErrCostExplorerNotEnabled, so the failure
+// explains itself instead of surfacing a bare API error.
+type notEnabledClient struct {
+	next CostExplorerAPI
+}
+
+// GetCostAndUsage satisfies the CostExplorerAPI interface.
+func (c *notEnabledClient) GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+	result, err := c.next.GetCostAndUsage(ctx, params, optFns...)
+	return result, notEnabledError(err)
+}
+
+// GetDimensionValues satisfies the CostExplorerAPI interface.
+func (c *notEnabledClient) GetDimensionValues(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+	result, err := c.next.GetDimensionValues(ctx, params, optFns...)
+	return result, notEnabledError(err)
+}
+
+// GetReservationCoverage satisfies the CostExplorerAPI interface.
+func (c *notEnabledClient) GetReservationCoverage(ctx context.Context, params *costexplorer.GetReservationCoverageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetReservationCoverageOutput, error) {
+	result, err := c.next.GetReservationCoverage(ctx, params, optFns...)
+	return result, notEnabledError(err)
+}
+
+// GetCostForecast satisfies the CostExplorerAPI interface.
+func (c *notEnabledClient) GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+	result, err := c.next.GetCostForecast(ctx, params, optFns...)
+	return result, notEnabledError(err)
+}
+
+// GetAnomalies satisfies the CostExplorerAPI interface.
+func (c *notEnabledClient) GetAnomalies(ctx context.Context, params *costexplorer.GetAnomaliesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetAnomaliesOutput, error) {
+	result, err := c.next.GetAnomalies(ctx, params, optFns...)
+	return result, notEnabledError(err)
+}
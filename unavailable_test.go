@@ -0,0 +1,49 @@
+// File: unavailable_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestNotEnabledClient(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+
+	tests := []struct {
+		name       string
+		err        error
+		notEnabled bool
+	}{
+		{name: "data unavailable", err: &types.DataUnavailableException{Message: aws.String("Data is not available.")}, notEnabled: true},
+		{name: "other error", err: &types.LimitExceededException{Message: aws.String("Rate exceeded")}, notEnabled: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &CostTracker{client: &notEnabledClient{next: &mockCostExplorerClient{
+				GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+					return nil, tt.err
+				},
+			}}}
+			_, err := tracker.GetCosts(context.Background(), CostQuery{
+				Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			})
+			if err == nil {
+				t.Fatalf("expected an error, but got nil")
+			}
+			if errors.Is(err, ErrCostExplorerNotEnabled) != tt.notEnabled {
+				t.Errorf("expected errors.Is(err, ErrCostExplorerNotEnabled) to be %v, got error: %v", tt.notEnabled, err)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected the API error to remain in the chain, got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// File: variance.go
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/cobra"
+)
+
+// monthLayout is the format of --month and of the keys of a budget's comments.
+const monthLayout = "2006-01"
+
+// Budget is a monthly budget for the spend matching a filter expression, such as one team's tag or
+// one service. Comments hold finance commentary per month, keyed YYYY-MM.
+type Budget struct {
+	Name     string            `mapstructure:"name"`
+	Filter   string            `mapstructure:"filter"` // --filter syntax; empty is all spend
+	Monthly  float64           `mapstructure:"monthly"`
+	Comments map[string]string `mapstructure:"comments"`
+}
+
+// BudgetsFromViper reads the budgets configuration key and checks that every filter parses.
+func BudgetsFromViper() ([]Budget, error) {
+	var budgets []Budget
+	if err := unmarshalConfigKey("budgets", &budgets); err != nil {
+		return nil, fmt.Errorf("invalid budgets: %w", err)
+	}
+	for i, b := range budgets {
+		if b.Name == "" {
+			return nil, fmt.Errorf("budgets[%d] has no name", i)
+		}
+		if b.Monthly < 0 {
+			return nil, fmt.Errorf("budget %q: monthly must not be negative, got %g", b.Name, b.Monthly)
+		}
+		if b.Filter != "" {
+			if _, err := ParseFilter(b.Filter); err != nil {
+				return nil, fmt.Errorf("budget %q has an invalid filter: %w", b.Name, err)
+			}
+		}
+	}
+	return budgets, nil
+}
+
+// VarianceLine compares one budget with the actual spend in the month.
+type VarianceLine struct {
+	Name    string
+	Budget  float64
+	Actual  float64
+	Comment string
+}
+
+// Variance returns actual minus budget, so overspend is positive.
+func (l VarianceLine) Variance() float64 {
+	return l.Actual - l.Budget
+}
+
+// VarianceRate returns the variance as a fraction of the budget, or 0 for a zero budget.
+func (l VarianceLine) VarianceRate() float64 {
+	if l.Budget == 0 {
+		return 0
+	}
+	return l.Variance() / l.Budget
+}
+
+// VarianceReport is the result of GetBudgetVariance.
+type VarianceReport struct {
+	Month   time.Time
+	Partial bool // The month has not ended, so actuals are month to date
+	Unit    string
+	Lines   []VarianceLine // In budget order
+}
+
+// GetBudgetVariance sums each budget's actual spend over month, which must be the first of a month
+// in UTC, and attaches the budget's comment for that month. A month that has not ended is reported
+// to date against the full budget. filter, if not nil, narrows every budget's spend.
+func (ct *CostTracker) GetBudgetVariance(ctx context.Context, budgets []Budget, filter *types.Expression, month, now time.Time) (*VarianceReport, error) {
+	end := month.AddDate(0, 1, 0)
+	report := &VarianceReport{Month: month}
+	if today := now.UTC().Truncate(24 * time.Hour); today.Before(end) {
+		if !today.After(month) {
+			return nil, fmt.Errorf("month %s has not started", month.Format(monthLayout))
+		}
+		end, report.Partial = today, true
+	}
+
+	for _, b := range budgets {
+		q := CostQuery{Start: month, End: end, Filter: filter}
+		if b.Filter != "" {
+			expr, err := ParseFilter(b.Filter)
+			if err != nil {
+				return nil, fmt.Errorf("budget %q has an invalid filter: %w", b.Name, err)
+			}
+			q.Filter = andExpression(filter, expr)
+		}
+		costs, err := ct.GetCosts(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get costs for budget %s: %w", b.Name, err)
+		}
+		totals, unit := totalsByService(costs)
+		line := VarianceLine{Name: b.Name, Budget: b.Monthly, Comment: b.Comments[month.Format(monthLayout)]}
+		for _, amount := range totals {
+			line.Actual += amount
+		}
+		if unit != "" {
+			report.Unit = unit
+		}
+		report.Lines = append(report.Lines, line)
+	}
+	return report, nil
+}
+
+// displayVarianceReport writes the budget variance table to w, with each line's comment beneath it.
+func displayVarianceReport(w io.Writer, r *VarianceReport) {
+	partial := ""
+	if r.Partial {
+		partial = " (month to date)"
+	}
+	fmt.Fprintf(w, "Budget vs actual for %s%s:\n", r.Month.Format(monthLayout), partial)
+	fmt.Fprintln(w, "=====================================")
+	if len(r.Lines) == 0 {
+		fmt.Fprintln(w, "No budgets configured.")
+		return
+	}
+
+	fmt.Fprintf(w, "  %-30s %14s %14s %14s %9s\n", "Budget", "Budgeted", "Actual", "Variance", "Variance%")
+	var budget, actual float64
+	for _, l := range r.Lines {
+		marker := " "
+		if l.Variance() > 0 {
+			marker = "!"
+		}
+		fmt.Fprintf(w, "%s %-30s %14.2f %14.2f %+14.2f %+8.1f%%\n", marker, truncateName(l.Name, 30), l.Budget, l.Actual, l.Variance(), l.VarianceRate()*100)
+		if l.Comment != "" {
+			fmt.Fprintf(w, "    %s\n", l.Comment)
+		}
+		budget += l.Budget
+		actual += l.Actual
+	}
+	fmt.Fprintf(w, "  %-30s %14.2f %14.2f %+14.2f\n", "Total", budget, actual, actual-budget)
+	fmt.Fprintf(w, "Amounts in %s. ! marks budgets that were exceeded.\n", r.Unit)
+}
+
+// writeVarianceCSV writes one row per budget, including its comment, for the finance report.
+func writeVarianceCSV(w io.Writer, r *VarianceReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"month", "budget", "budgeted", "actual", "variance", "variance_pct", "unit", "comment"})
+	for _, l := range r.Lines {
+		cw.Write([]string{
+			r.Month.Format(monthLayout),
+			l.Name,
+			fmt.Sprintf("%.2f", l.Budget),
+			fmt.Sprintf("%.2f", l.Actual),
+			fmt.Sprintf("%.2f", l.Variance()),
+			fmt.Sprintf("%.1f", l.VarianceRate()*100),
+			r.Unit,
+			l.Comment,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var varianceCmd = &cobra.Command{
+	Use:   "variance",
+	Short: "Compare each budget with the actual spend in a month.",
+	Long: `Reports budgeted and actual spend, and the variance in amount and percent, for each budget in the budgets
+configuration key. Each budget covers the spend matching its filter expression, such as a team's tag or a
+service. Comments entered under a budget's comments, keyed by month, are shown beneath its line and written to
+the --csv file for the finance report:
+
+  cost-tracker variance --month 2024-05 --csv variance-2024-05.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		budgets, err := BudgetsFromViper()
+		if err != nil {
+			logger.Fatalw("Invalid budgets", "error", err)
+		}
+		now := time.Now().UTC()
+		month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+		if arg, _ := cmd.Flags().GetString("month"); arg != "" {
+			if month, err = time.Parse(monthLayout, arg); err != nil {
+				logger.Fatalw("Invalid --month, expected YYYY-MM", "month", arg, "error", err)
+			}
+		}
+
+		tracker, query, _ := setupReport(ctx)
+		report, err := tracker.GetBudgetVariance(ctx, budgets, query.Filter, month, now)
+		if err != nil {
+			errMsg := fmt.Sprintf("Error computing budget variance: %v", err)
+			sendSlackNotification("Cost Tracker Error: " + errMsg)
+			logger.Fatalw("Error computing budget variance", "error", err)
+		}
+
+		logger.Info("Displaying budget variance to console.")
+		out, done := consoleWriter()
+		displayVarianceReport(out, report)
+		done()
+
+		if path, _ := cmd.Flags().GetString("csv"); path != "" && !explaining() {
+			path, err := artifactPath(path, cmd.Name(), CostQuery{Start: month, End: month.AddDate(0, 1, 0)}, now)
+			if err != nil {
+				logger.Fatalw("Invalid --csv", "error", err)
+			}
+			f, err := os.Create(path)
+			if err != nil {
+				logger.Fatalw("Failed to create CSV file", "path", path, "error", err)
+			}
+			err = writeVarianceCSV(f, report)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				logger.Fatalw("Failed to write CSV file", "path", path, "error", err)
+			}
+			if activeManifest != nil {
+				activeManifest.Artifacts = []string{path}
+			}
+			logger.Infow("Wrote budget variance", "path", path)
+		}
+	},
+}
+
+func init() {
+	varianceCmd.Flags().String("month", "", "Month to report as YYYY-MM (default: last month)")
+	varianceCmd.Flags().String("csv", "", "Also write the variance lines, with comments, to this CSV file; may use {{.Command}}, {{.Date}}, {{.Start}} and {{.End}}")
+	rootCmd.AddCommand(varianceCmd)
+}
@@ -0,0 +1,100 @@
+// File: variance_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestBudgetsFromViper(t *testing.T) {
+	t.Cleanup(func() { viper.Set("budgets", nil) })
+
+	viper.Set("budgets", []map[string]interface{}{
+		{"name": "Platform", "filter": "tag:team = platform", "monthly": 1000, "comments": map[string]string{"2024-05": "Load test"}},
+	})
+	budgets, err := BudgetsFromViper()
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if len(budgets) != 1 || budgets[0].Monthly != 1000 || budgets[0].Comments["2024-05"] != "Load test" {
+		t.Errorf("unexpected budgets: %+v", budgets)
+	}
+
+	viper.Set("budgets", []map[string]interface{}{{"name": "Broken", "filter": "team ==", "monthly": 10}})
+	if _, err := BudgetsFromViper(); err == nil || !strings.Contains(err.Error(), "Broken") {
+		t.Errorf("expected an error naming the budget with an invalid filter, got %v", err)
+	}
+}
+
+func TestGetBudgetVariance(t *testing.T) {
+	logger = zaptest.NewLogger(t).Sugar()
+	var periods [][2]string
+	tracker := &CostTracker{client: &mockCostExplorerClient{
+		GetCostAndUsageFunc: func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+			periods = append(periods, [2]string{*params.TimePeriod.Start, *params.TimePeriod.End})
+			amount := "1200"
+			if params.Filter == nil {
+				amount = "500"
+			}
+			return &costexplorer.GetCostAndUsageOutput{
+				ResultsByTime: []types.ResultByTime{{
+					TimePeriod: params.TimePeriod,
+					Groups: []types.Group{{
+						Keys:    []string{"Amazon EC2"},
+						Metrics: map[string]types.MetricValue{MetricBlendedCost: {Amount: aws.String(amount), Unit: aws.String("USD")}},
+					}},
+				}},
+			}, nil
+		},
+	}}
+	budgets := []Budget{
+		{Name: "Platform", Filter: "tag:team = platform", Monthly: 1000, Comments: map[string]string{"2024-05": "Load test, one-off."}},
+		{Name: "Everything", Monthly: 1000},
+	}
+	may := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	report, err := tracker.GetBudgetVariance(context.Background(), budgets, nil, may, time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if report.Partial || periods[0] != [2]string{"2024-05-01", "2024-06-01"} {
+		t.Errorf("expected the whole of May, got periods %v (partial %v)", periods, report.Partial)
+	}
+	platform, everything := report.Lines[0], report.Lines[1]
+	if platform.Variance() != 200 || platform.VarianceRate() != 0.2 || platform.Comment != "Load test, one-off." {
+		t.Errorf("unexpected platform line: %+v", platform)
+	}
+	if everything.Variance() != -500 || everything.Comment != "" {
+		t.Errorf("unexpected line for all spend: %+v", everything)
+	}
+
+	var buf bytes.Buffer
+	if err := writeVarianceCSV(&buf, report); err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if !strings.Contains(buf.String(), "2024-05,Platform,1000.00,1200.00,200.00,20.0,USD,\"Load test, one-off.\"") {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+
+	periods = nil
+	report, err = tracker.GetBudgetVariance(context.Background(), budgets, nil, may, time.Date(2024, 5, 20, 15, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("did not expect an error, but got: %v", err)
+	}
+	if !report.Partial || periods[0][1] != "2024-05-20" {
+		t.Errorf("expected May to date, got periods %v (partial %v)", periods, report.Partial)
+	}
+
+	if _, err := tracker.GetBudgetVariance(context.Background(), budgets, nil, may.AddDate(0, 1, 0), may.AddDate(0, 0, 3)); err == nil {
+		t.Error("expected an error for a month that has not started")
+	}
+}
@@ -0,0 +1,101 @@
+// File: version.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// version is the release of this build, set with go build -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+// compiledProviders and compiledSinks are the cost data providers and notification channels in this build.
+var (
+	compiledProviders = []string{ProviderAWS, ProviderMock}
+	compiledSinks     = []string{(&slackNotifier{}).Name()}
+)
+
+// VersionInfo describes a build, so automation can check that a deployed binary supports a config.
+type VersionInfo struct {
+	Version             string   `json:"version"`
+	Revision            string   `json:"revision,omitempty"` // VCS commit, when built from a checkout
+	RevisionTime        string   `json:"revision_time,omitempty"`
+	Modified            bool     `json:"modified,omitempty"` // Built with uncommitted changes
+	GoVersion           string   `json:"go_version"`
+	Platform            string   `json:"platform"`
+	Providers           []string `json:"providers"`
+	Sinks               []string `json:"sinks"`
+	ConfigSchemaVersion int      `json:"config_schema_version"` // Highest schema_version this build reads
+	OutputSchemaVersion int      `json:"output_schema_version"` // schema_version of get --output json
+	BundleVersion       int      `json:"bundle_version"`
+}
+
+// buildVersionInfo returns the VersionInfo of the running binary.
+func buildVersionInfo() VersionInfo {
+	info := VersionInfo{
+		Version:             version,
+		GoVersion:           runtime.Version(),
+		Platform:            runtime.GOOS + "/" + runtime.GOARCH,
+		Providers:           compiledProviders,
+		Sinks:               compiledSinks,
+		ConfigSchemaVersion: ConfigSchemaVersion,
+		OutputSchemaVersion: CostsSchemaVersion,
+		BundleVersion:       BundleVersion,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range build.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Revision = s.Value
+			case "vcs.time":
+				info.RevisionTime = s.Value
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version and capabilities of this build.",
+	Long: `Prints the version, source revision, cost data providers, notification channels and supported schema versions of
+this build. --json prints them as a JSON object, so a deployment can check that the binary supports its config:
+
+  cost-tracker version --json | jq -e '.config_schema_version >= 1 and (.providers | index("aws"))'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		info := buildVersionInfo()
+		out := cmd.OutOrStdout()
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(info); err != nil {
+				logger.Fatalw("Failed to write version", "error", err)
+			}
+			return
+		}
+		fmt.Fprintf(out, "cost-tracker %s (%s, %s)\n", info.Version, info.GoVersion, info.Platform)
+		if info.Revision != "" {
+			modified := ""
+			if info.Modified {
+				modified = " (modified)"
+			}
+			fmt.Fprintf(out, "Revision:              %s %s%s\n", info.Revision, info.RevisionTime, modified)
+		}
+		fmt.Fprintf(out, "Providers:             %v\n", info.Providers)
+		fmt.Fprintf(out, "Notification channels: %v\n", info.Sinks)
+		fmt.Fprintf(out, "Config schema:         %d\n", info.ConfigSchemaVersion)
+		fmt.Fprintf(out, "Output schema:         %d\n", info.OutputSchemaVersion)
+		fmt.Fprintf(out, "Bundle version:        %d\n", info.BundleVersion)
+	},
+}
+
+func init() {
+	versionCmd.Flags().Bool("json", false, "Print the version information as JSON")
+	rootCmd.AddCommand(versionCmd)
+}
@@ -0,0 +1,33 @@
+// File: version_test.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestVersionJSON(t *testing.T) {
+	var buf bytes.Buffer
+	versionCmd.SetOut(&buf)
+	t.Cleanup(func() {
+		versionCmd.SetOut(nil)
+		versionCmd.Flags().Set("json", "false")
+	})
+	versionCmd.Flags().Set("json", "true")
+	versionCmd.Run(versionCmd, nil)
+
+	var info VersionInfo
+	if err := json.Unmarshal(buf.Bytes(), &info); err != nil {
+		t.Fatalf("expected a JSON object, got %q: %v", buf.String(), err)
+	}
+	if info.Version == "" || info.GoVersion == "" || info.ConfigSchemaVersion != ConfigSchemaVersion || info.OutputSchemaVersion != CostsSchemaVersion {
+		t.Errorf("unexpected version information: %+v", info)
+	}
+	if len(info.Providers) != 2 || info.Providers[0] != ProviderAWS || info.Providers[1] != ProviderMock {
+		t.Errorf("expected the aws and mock providers, got %v", info.Providers)
+	}
+	if len(info.Sinks) != 1 || info.Sinks[0] != "slack" {
+		t.Errorf("expected the slack sink, got %v", info.Sinks)
+	}
+}